@@ -97,6 +97,37 @@ func testExporterFunc(platformAPI string) func(t *testing.T, when spec.G, it spe
 					})
 				})
 			})
+			when("the default process declares a health check", func() {
+				it("errors", func() {
+					h.SkipIf(t, api.MustParse(platformAPI).LessThan("0.4"), "Platform API < 0.4 does not have process type entrypoints")
+					metadataPath, err := filepath.Abs(filepath.Join("testdata", "exporter", "health-check", "metadata.toml"))
+					h.AssertNil(t, err)
+
+					exportFlags := []string{"-daemon", "-process-type", "web"}
+					if api.MustParse(platformAPI).LessThan("0.7") {
+						exportFlags = append(exportFlags, []string{"-run-image", exportRegFixtures.ReadOnlyRunImage}...)
+					}
+
+					exportArgs := append([]string{ctrPath(exporterPath)}, exportFlags...)
+					exportedImageName = "some-exported-image-" + h.RandString(10)
+					exportArgs = append(exportArgs, exportedImageName)
+
+					dockerArgs := append([]string{"run", "--rm"}, dockerSocketMount...)
+					dockerArgs = append(dockerArgs,
+						"--env", "CNB_PLATFORM_API="+platformAPI,
+						"--env", "CNB_REGISTRY_AUTH="+exportRegAuthConfig,
+						"--network", exportRegNetwork,
+						"--volume", metadataPath+":/layers/config/metadata.toml",
+						exportImage,
+					)
+					cmd := exec.Command("docker", append(dockerArgs, exportArgs...)...) // #nosec G204
+					output, err := cmd.CombinedOutput()
+
+					h.AssertNotNil(t, err)
+					h.AssertStringContains(t, string(output), "health checks are not supported when exporting to a docker daemon")
+				})
+			})
+
 			when("SOURCE_DATE_EPOCH is set", func() {
 				it("Image CreatedAt is set to SOURCE_DATE_EPOCH", func() {
 					h.SkipIf(t, api.MustParse(platformAPI).LessThan("0.9"), "SOURCE_DATE_EPOCH support added in 0.9")
@@ -157,6 +188,43 @@ func testExporterFunc(platformAPI string) func(t *testing.T, when spec.G, it spe
 						assertImageOSAndArchAndCreatedAt(t, exportedImageName, exportTest, imgutil.NormalizedDateTime)
 					})
 				})
+				when("the default process declares a health check", func() {
+					it("sets the health check in the image config", func() {
+						h.SkipIf(t, api.MustParse(platformAPI).LessThan("0.4"), "Platform API < 0.4 does not have process type entrypoints")
+						metadataPath, err := filepath.Abs(filepath.Join("testdata", "exporter", "health-check", "metadata.toml"))
+						h.AssertNil(t, err)
+
+						exportFlags := []string{"-process-type", "web"}
+						if api.MustParse(platformAPI).LessThan("0.7") {
+							exportFlags = append(exportFlags, []string{"-run-image", exportRegFixtures.ReadOnlyRunImage}...)
+						}
+
+						exportArgs := append([]string{ctrPath(exporterPath)}, exportFlags...)
+						exportedImageName = exportTest.RegRepoName("some-exported-image-" + h.RandString(10))
+						exportArgs = append(exportArgs, exportedImageName)
+
+						output := h.DockerRun(t,
+							exportImage,
+							h.WithFlags(
+								"--env", "CNB_PLATFORM_API="+platformAPI,
+								"--env", "CNB_REGISTRY_AUTH="+exportRegAuthConfig,
+								"--network", exportRegNetwork,
+								"--volume", metadataPath+":/layers/config/metadata.toml",
+							),
+							h.WithArgs(exportArgs...),
+						)
+						h.AssertStringContains(t, output, "Saving "+exportedImageName)
+
+						h.Run(t, exec.Command("docker", "pull", exportedImageName))
+						inspect, _, err := h.DockerCli(t).ImageInspectWithRaw(context.TODO(), exportedImageName)
+						h.AssertNil(t, err)
+						h.AssertNotNil(t, inspect.Config.Healthcheck)
+						h.AssertEq(t, inspect.Config.Healthcheck.Test, []string{"CMD", "/cnb/health/web"})
+						h.AssertEq(t, inspect.Config.Healthcheck.Interval, 10*time.Second)
+						h.AssertEq(t, inspect.Config.Healthcheck.Retries, 3)
+						h.AssertEq(t, inspect.Config.Entrypoint[0], "/cnb/process/web")
+					})
+				})
 				when("SOURCE_DATE_EPOCH is set", func() {
 					it("Image CreatedAt is set to SOURCE_DATE_EPOCH", func() {
 						h.SkipIf(t, api.MustParse(platformAPI).LessThan("0.9"), "SOURCE_DATE_EPOCH support added in 0.9")
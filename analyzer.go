@@ -35,6 +35,7 @@ func (a *Analyzer) Analyze() (platform.AnalyzedMetadata, error) {
 		cacheMeta       platform.CacheMetadata
 		previousImageID *platform.ImageIdentifier
 		runImageID      *platform.ImageIdentifier
+		salvage         *platform.MetadataSalvage
 		err             error
 	)
 
@@ -44,9 +45,9 @@ func (a *Analyzer) Analyze() (platform.AnalyzedMetadata, error) {
 			return platform.AnalyzedMetadata{}, errors.Wrap(err, "retrieving image identifier")
 		}
 
-		// continue even if the label cannot be decoded
+		// continue even if the label cannot be decoded, keeping the parts that can
 		if err := image.DecodeLabel(a.PreviousImage, platform.LayerMetadataLabel, &appMeta); err != nil {
-			appMeta = platform.LayersMetadata{}
+			appMeta, salvage = a.salvageMetadata(err)
 		}
 
 		if err := a.SBOMRestorer.RestoreFromPrevious(a.PreviousImage, bomSHA(appMeta)); err != nil {
@@ -76,12 +77,38 @@ func (a *Analyzer) Analyze() (platform.AnalyzedMetadata, error) {
 	}
 
 	return platform.AnalyzedMetadata{
-		PreviousImage: previousImageID,
-		RunImage:      runImageID,
-		Metadata:      appMeta,
+		PreviousImage:   previousImageID,
+		RunImage:        runImageID,
+		Metadata:        appMeta,
+		MetadataSalvage: salvage,
 	}, nil
 }
 
+// salvageMetadata decodes the previous image metadata per buildpack and per layer, logging the parts that were dropped.
+func (a *Analyzer) salvageMetadata(decodeErr error) (platform.LayersMetadata, *platform.MetadataSalvage) {
+	a.Logger.Warnf("Previous image metadata could not be decoded, salvaging what can be reused: %s", decodeErr)
+	label, err := a.PreviousImage.Label(platform.LayerMetadataLabel)
+	if err != nil {
+		a.Logger.Warnf("Dropped all previous image metadata: %s", err)
+		return platform.LayersMetadata{}, &platform.MetadataSalvage{Error: decodeErr.Error()}
+	}
+
+	appMeta, dropped := platform.SalvageLayersMetadata(label)
+	salvage := &platform.MetadataSalvage{
+		Error:      decodeErr.Error(),
+		Buildpacks: len(appMeta.Buildpacks),
+		Dropped:    dropped,
+	}
+	for _, bpMD := range appMeta.Buildpacks {
+		salvage.Layers += len(bpMD.Layers)
+	}
+	for _, d := range dropped {
+		a.Logger.Warnf("Dropped previous image metadata %s", d)
+	}
+	a.Logger.Infof("Salvaged metadata for %d layer(s) of %d buildpack(s) from previous image", salvage.Layers, salvage.Buildpacks)
+	return appMeta, salvage
+}
+
 func (a *Analyzer) restoresLayerMetadata() bool {
 	return a.Platform.API().LessThan("0.7")
 }
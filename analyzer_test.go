@@ -204,6 +204,51 @@ func testAnalyzerBuilder(platformAPI string) func(t *testing.T, when spec.G, it
 					h.AssertNil(t, err)
 					h.AssertEq(t, md.Metadata, platform.LayersMetadata{})
 				})
+
+				it("records the salvage", func() {
+					md, err := analyzer.Analyze()
+					h.AssertNil(t, err)
+					h.AssertStringContains(t, md.MetadataSalvage.Error, "failed to unmarshal")
+					h.AssertEq(t, md.MetadataSalvage.Dropped[0].Path, "io.buildpacks.lifecycle.metadata")
+				})
+			})
+
+			when("previous image has partially incompatible metadata", func() {
+				it.Before(func() {
+					h.AssertNil(t, image.SetLabel("io.buildpacks.lifecycle.metadata", `{"buildpacks": [
+  {"key": "metadata.buildpack", "layers": {
+    "good": {"sha": "good-sha", "launch": true},
+    "bad": {"sha": "bad-sha", "launch": "yes"}
+  }},
+  {"key": "no.cache.buildpack", "layers": {"some-layer": {"sha": "some-layer-sha", "cache": true}}}
+]}`))
+					expectedAppMetadata = platform.LayersMetadata{Buildpacks: []buildpack.LayersMetadata{
+						{ID: "metadata.buildpack", Layers: map[string]buildpack.LayerMetadata{
+							"good": {SHA: "good-sha", LayerMetadataFile: buildpack.LayerMetadataFile{Launch: true}},
+						}},
+						{ID: "no.cache.buildpack", Layers: map[string]buildpack.LayerMetadata{
+							"some-layer": {SHA: "some-layer-sha", LayerMetadataFile: buildpack.LayerMetadataFile{Cache: true}},
+						}},
+					}}
+					sbomRestorer.EXPECT().RestoreFromPrevious(image, "")
+					expectRestoresLayerMetadataIfSupported()
+				})
+
+				it("keeps the layers that decode", func() {
+					md, err := analyzer.Analyze()
+					h.AssertNil(t, err)
+					h.AssertEq(t, md.Metadata, expectedAppMetadata)
+				})
+
+				it("records the dropped layers", func() {
+					md, err := analyzer.Analyze()
+					h.AssertNil(t, err)
+					h.AssertEq(t, md.MetadataSalvage.Buildpacks, 2)
+					h.AssertEq(t, md.MetadataSalvage.Layers, 2)
+					h.AssertEq(t, len(md.MetadataSalvage.Dropped), 1)
+					h.AssertEq(t, md.MetadataSalvage.Dropped[0].Path, "buildpacks[0].layers.bad")
+					h.AssertEq(t, md.MetadataSalvage.Dropped[0].Buildpack, "metadata.buildpack")
+				})
 			})
 
 			when("previous image has an SBOM layer digest in the analyzed metadata", func() {
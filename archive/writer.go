@@ -52,6 +52,19 @@ func (tw *NormalizingTarWriter) WithModTime(modTime time.Time) {
 	})
 }
 
+// WithReplacedPrefix replaces oldPrefix with newPrefix in the Name of any subsequently written *tar.Header
+// that is oldPrefix or is inside of it
+func (tw *NormalizingTarWriter) WithReplacedPrefix(oldPrefix, newPrefix string) {
+	tw.headerOpts = append(tw.headerOpts, func(hdr *tar.Header) *tar.Header {
+		if hdr.Name == oldPrefix {
+			hdr.Name = newPrefix
+		} else if strings.HasPrefix(hdr.Name, oldPrefix+string(filepath.Separator)) {
+			hdr.Name = newPrefix + hdr.Name[len(oldPrefix):]
+		}
+		return hdr
+	})
+}
+
 // NewNormalizingTarWriter creates a NormalizingTarWriter that wraps the provided TarWriter
 func NewNormalizingTarWriter(tw TarWriter) *NormalizingTarWriter {
 	return &NormalizingTarWriter{tw, []HeaderOpt{}}
@@ -3,6 +3,7 @@ package archive_test
 import (
 	"archive/tar"
 	"math/rand"
+	"path/filepath"
 	"runtime"
 	"testing"
 	"time"
@@ -86,6 +87,21 @@ func testNormalizingTarWriter(t *testing.T, when spec.G, it spec.S) {
 				}
 			})
 		})
+
+		when("#WithReplacedPrefix", func() {
+			it("replaces the prefix of paths inside of it", func() {
+				ntw.WithReplacedPrefix(filepath.Join("/some", "src"), filepath.Join("/other", "dest"))
+
+				h.AssertNil(t, ntw.WriteHeader(&tar.Header{Name: filepath.Join("/some", "src")}))
+				h.AssertEq(t, ftw.getLastHeader().Name, "/other/dest")
+
+				h.AssertNil(t, ntw.WriteHeader(&tar.Header{Name: filepath.Join("/some", "src", "file.txt")}))
+				h.AssertEq(t, ftw.getLastHeader().Name, "/other/dest/file.txt")
+
+				h.AssertNil(t, ntw.WriteHeader(&tar.Header{Name: filepath.Join("/some", "src-other")}))
+				h.AssertEq(t, ftw.getLastHeader().Name, "/some/src-other")
+			})
+		})
 	})
 }
 
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/pkg/errors"
 
@@ -46,6 +47,9 @@ type Builder struct {
 	Out, Err       goio.Writer
 	Logger         Logger
 	BuildpackStore BuildpackStore
+	EnvHistoryPath string // if set, the history of build env changes is written to this path
+	LogEnvHistory  bool   // if set, the history of build env changes is logged at debug level
+	SecretsDir     string // if set, build-time secrets provided to buildpacks
 }
 
 func (b *Builder) Build() (*platform.BuildMetadata, error) {
@@ -70,6 +74,9 @@ func (b *Builder) Build() (*platform.BuildMetadata, error) {
 	var labels []buildpack.Label
 
 	bpEnv := env.NewBuildEnv(os.Environ())
+	if b.EnvHistoryPath != "" || b.LogEnvHistory {
+		bpEnv.History = env.History{}
+	}
 
 	for _, bp := range b.Group.Group {
 		b.Logger.Debugf("Running build for buildpack %s", bp)
@@ -134,9 +141,17 @@ func (b *Builder) Build() (*platform.BuildMetadata, error) {
 		launchBOM = []buildpack.BOMEntry{}
 	}
 
+	if err := b.reportEnvHistory(bpEnv.History); err != nil {
+		return nil, err
+	}
+
 	b.Logger.Debug("Listing processes")
 	procList := processMap.list()
 
+	if err := b.checkSecrets(procList, labels); err != nil {
+		return nil, err
+	}
+
 	b.Logger.Debug("Finished build")
 	return &platform.BuildMetadata{
 		BOM:                         launchBOM,
@@ -148,6 +163,70 @@ func (b *Builder) Build() (*platform.BuildMetadata, error) {
 	}, nil
 }
 
+// checkSecrets returns an error if a process or label contains the value of a platform or build-time secret,
+// since secrets must not be written to metadata.toml. Values shorter than minSecretLength are not checked.
+func (b *Builder) checkSecrets(procList []launch.Process, labels []buildpack.Label) error {
+	secrets, err := env.ReadSecrets(b.PlatformDir)
+	if err != nil {
+		return errors.Wrap(err, "reading platform secrets")
+	}
+	if b.SecretsDir != "" {
+		buildSecrets, err := env.ReadSecretsDir(b.SecretsDir)
+		if err != nil {
+			return errors.Wrap(err, "reading build-time secrets")
+		}
+		for name, value := range buildSecrets {
+			secrets[name] = value
+		}
+	}
+	for _, name := range shortSecrets(secrets) {
+		b.Logger.Warnf("Not checking build metadata for the value of secret '%s', it is shorter than %d characters", name, minSecretLength)
+	}
+	var names []string
+	for name, value := range secrets {
+		if len(strings.TrimSpace(value)) >= minSecretLength {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := strings.TrimSpace(secrets[name])
+		for _, proc := range procList {
+			if strings.Contains(strings.Join(append([]string{proc.Command}, proc.Args...), " "), value) {
+				return fmt.Errorf("process type '%s' contains the value of secret '%s'", proc.Type, name)
+			}
+		}
+		for _, label := range labels {
+			if strings.Contains(label.Value, value) {
+				return fmt.Errorf("label '%s' contains the value of secret '%s'", label.Key, name)
+			}
+		}
+	}
+	return nil
+}
+
+// reportEnvHistory logs which env files changed each build env variable and writes the history to EnvHistoryPath.
+// Values are not logged since they may be sensitive.
+func (b *Builder) reportEnvHistory(history env.History) error {
+	var names []string
+	for name := range history {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, change := range history[name] {
+			b.Logger.Debugf("Env var %s: %s from %s", name, change.Action, change.Source)
+		}
+	}
+	if b.EnvHistoryPath == "" {
+		return nil
+	}
+	if err := encoding.WriteJSON(b.EnvHistoryPath, history); err != nil {
+		return errors.Wrap(err, "writing env history")
+	}
+	return nil
+}
+
 // copyBOMFiles() copies any BOM files written by buildpacks during the Build() process
 // to their appropriate locations, in preparation for its final application layer.
 // This function handles both BOMs that are associated with a layer directory and BOMs that are not
@@ -236,11 +315,18 @@ func (b *Builder) BuildConfig() (buildpack.BuildConfig, error) {
 	if err != nil {
 		return buildpack.BuildConfig{}, err
 	}
+	var secretsDir string
+	if b.SecretsDir != "" {
+		if secretsDir, err = filepath.Abs(b.SecretsDir); err != nil {
+			return buildpack.BuildConfig{}, err
+		}
+	}
 
 	return buildpack.BuildConfig{
 		AppDir:      appDir,
 		PlatformDir: platformDir,
 		LayersDir:   layersDir,
+		SecretsDir:  secretsDir,
 		Out:         b.Out,
 		Err:         b.Err,
 		Logger:      b.Logger,
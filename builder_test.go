@@ -165,6 +165,41 @@ func testBuilder(t *testing.T, when spec.G, it spec.S) {
 				}
 			})
 
+			when("env history is logged", func() {
+				it("records the history of the build env", func() {
+					builder.LogEnvHistory = true
+					bpA := testmock.NewMockBuildpack(mockCtrl)
+					bpB := testmock.NewMockBuildpack(mockCtrl)
+					buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+					buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+					var history env.History
+					bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).Do(func(_ buildpack.Plan, _ buildpack.BuildConfig, bpEnv buildpack.BuildEnv) {
+						history = bpEnv.(*env.Env).History
+					})
+					bpB.EXPECT().Build(gomock.Any(), config, gomock.Any())
+
+					_, err := builder.Build()
+					h.AssertNil(t, err)
+					h.AssertNotNil(t, history)
+				})
+			})
+
+			it("does not record the history of the build env by default", func() {
+				bpA := testmock.NewMockBuildpack(mockCtrl)
+				bpB := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				history := env.History{}
+				bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).Do(func(_ buildpack.Plan, _ buildpack.BuildConfig, bpEnv buildpack.BuildEnv) {
+					history = bpEnv.(*env.Env).History
+				})
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any())
+
+				_, err := builder.Build()
+				h.AssertNil(t, err)
+				h.AssertEq(t, history == nil, true)
+			})
+
 			it("copies any created BOM files to the correct locations", func() {
 				bpA := testmock.NewMockBuildpack(mockCtrl)
 				bpB := testmock.NewMockBuildpack(mockCtrl)
@@ -876,6 +911,57 @@ func testBuilder(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("build metadata contains a secret", func() {
+			it.Before(func() {
+				h.Mkdir(t, filepath.Join(platformDir, "secrets"))
+				h.Mkfile(t, "some-token\n", filepath.Join(platformDir, "secrets", "SOME_TOKEN"))
+			})
+
+			it("should error for processes", func() {
+				bpA := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+				bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{
+					Processes: []launch.Process{{Type: "web", Command: "some-cmd", Args: []string{"--token=some-token"}}},
+				}, nil)
+				bpB := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, nil)
+
+				_, err := builder.Build()
+				h.AssertError(t, err, "process type 'web' contains the value of secret 'SOME_TOKEN'")
+			})
+
+			it("should error for labels", func() {
+				bpA := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+				bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{
+					Labels: []buildpack.Label{{Key: "some-label", Value: "some-token"}},
+				}, nil)
+				bpB := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, nil)
+
+				_, err := builder.Build()
+				h.AssertError(t, err, "label 'some-label' contains the value of secret 'SOME_TOKEN'")
+			})
+
+			it("should warn and not check values shorter than the minimum secret length", func() {
+				h.Mkfile(t, "abc\n", filepath.Join(platformDir, "secrets", "SHORT_TOKEN"))
+				bpA := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("A", "v1").Return(bpA, nil)
+				bpA.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{
+					Labels: []buildpack.Label{{Key: "some-label", Value: "abc"}},
+				}, nil)
+				bpB := testmock.NewMockBuildpack(mockCtrl)
+				buildpackStore.EXPECT().Lookup("B", "v2").Return(bpB, nil)
+				bpB.EXPECT().Build(gomock.Any(), config, gomock.Any()).Return(buildpack.BuildResult{}, nil)
+
+				_, err := builder.Build()
+				h.AssertNil(t, err)
+				assertLogEntry(t, logHandler, "Not checking build metadata for the value of secret 'SHORT_TOKEN', it is shorter than 8 characters")
+			})
+		})
+
 		when("platform api < 0.4", func() {
 			it.Before(func() {
 				builder.Platform = platform.NewPlatform("0.3")
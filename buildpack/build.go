@@ -21,6 +21,7 @@ import (
 
 const (
 	EnvLayersDir  = "CNB_LAYERS_DIR"
+	EnvSecretsDir = "CNB_SECRETS_DIR"
 	EnvBpPlanPath = "CNB_BP_PLAN_PATH"
 )
 
@@ -35,6 +36,7 @@ type BuildConfig struct {
 	AppDir      string
 	PlatformDir string
 	LayersDir   string
+	SecretsDir  string // optional, provided to buildpacks as CNB_SECRETS_DIR
 	Out         io.Writer
 	Err         io.Writer
 	Logger      Logger
@@ -191,6 +193,9 @@ func (b *Descriptor) runBuildCmd(bpLayersDir, bpPlanPath string, config BuildCon
 		}
 	}
 	cmd.Env = append(cmd.Env, EnvBuildpackDir+"="+b.Dir)
+	if config.SecretsDir != "" {
+		cmd.Env = append(cmd.Env, EnvSecretsDir+"="+config.SecretsDir)
+	}
 	if api.MustParse(b.API).AtLeast("0.8") {
 		cmd.Env = append(cmd.Env,
 			EnvLayersDir+"="+bpLayersDir,
@@ -332,6 +337,10 @@ func (b *Descriptor) readOutputFiles(bpLayersDir, bpPlanPath string, bpPlanIn Pl
 		return BuildResult{}, err
 	}
 
+	if err := validateHealthChecks(launchTOML.Processes); err != nil {
+		return BuildResult{}, err
+	}
+
 	// set data from launch.toml
 	br.Labels = append([]Label{}, launchTOML.Labels...)
 	for i := range launchTOML.Processes {
@@ -379,6 +388,18 @@ func validateNoMultipleDefaults(processes []launch.Process) error {
 	return nil
 }
 
+func validateHealthChecks(processes []launch.Process) error {
+	for _, process := range processes {
+		if process.HealthCheck == nil {
+			continue
+		}
+		if err := process.HealthCheck.Validate(); err != nil {
+			return fmt.Errorf("invalid health check for process type '%s': %w", process.Type, err)
+		}
+	}
+	return nil
+}
+
 func validateUnmet(unmet []Unmet, bpPlan Plan) error {
 	for _, unmet := range unmet {
 		if unmet.Name == "" {
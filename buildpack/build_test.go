@@ -185,6 +185,16 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 				h.AssertEq(t, actual, platformDir)
 			})
 
+			it("should set CNB_SECRETS_DIR when a secrets dir is configured", func() {
+				config.SecretsDir = filepath.Join(tmpDir, "secrets")
+				if _, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv); err != nil {
+					t.Fatalf("Unexpected error:\n%s\n", err)
+				}
+
+				actual := h.Rdfile(t, filepath.Join(appDir, "build-env-cnb-secrets-dir-A-v1"))
+				h.AssertEq(t, actual, filepath.Join(tmpDir, "secrets"))
+			})
+
 			it("should set CNB_BP_PLAN_PATH", func() {
 				if _, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv); err != nil {
 					t.Fatalf("Unexpected error:\n%s\n", err)
@@ -879,6 +889,24 @@ func testBuild(t *testing.T, when spec.G, it spec.S) {
 				})
 			})
 
+			when("a process declares an invalid health check", func() {
+				it("should error", func() {
+					mockEnv.EXPECT().WithPlatform(platformDir).Return(append(os.Environ(), "TEST_ENV=Av1"), nil)
+					h.Mkfile(t,
+						`[[processes]]`+"\n"+
+							`type = "some-type"`+"\n"+
+							`command = "some-cmd"`+"\n"+
+							`[processes.health-check]`+"\n"+
+							`type = "http"`+"\n",
+						filepath.Join(appDir, "launch-A-v1.toml"),
+					)
+					_, err := bpTOML.Build(buildpack.Plan{}, config, mockEnv)
+					h.AssertNotNil(t, err)
+					expected := "invalid health check for process type 'some-type': http health check requires a port between 1 and 65535"
+					h.AssertStringContains(t, err.Error(), expected)
+				})
+			})
+
 			when("the launch, cache and build flags are in the top level", func() {
 				it("should error", func() {
 					mockEnv.EXPECT().WithPlatform(platformDir).Return(append(os.Environ(), "TEST_ENV=Av1"), nil)
@@ -1,11 +1,15 @@
 package lifecycle
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
 
+	"github.com/BurntSushi/toml"
 	"github.com/pkg/errors"
 
 	"github.com/buildpacks/lifecycle/buildpack"
@@ -144,3 +148,150 @@ func readLayersSBOM(layersDir string, bomType string, logger Logger) (LayerDir,
 		identifier: fmt.Sprintf("%s.sbom", bomType),
 	}, nil
 }
+
+// CachePruner removes selected layers from a cache.
+type CachePruner struct {
+	Logger    Logger
+	Selectors []platform.CacheLayerSelector
+}
+
+// Prune removes the cached layers matching any of the selectors from the cache metadata and commits the cache
+// with only the remaining layers, so that blobs no longer referenced by the metadata are dropped from the cache.
+func (p *CachePruner) Prune(cacheStore Cache) error {
+	if !cacheStore.Exists() {
+		p.Logger.Info("Layer cache not found")
+		return nil
+	}
+	meta, err := cacheStore.RetrieveMetadata()
+	if err != nil {
+		return errors.Wrap(err, "retrieving cache metadata")
+	}
+
+	removed := meta.RemoveLayers(p.Selectors...)
+	if len(removed) == 0 {
+		p.Logger.Info("No cached layers matched, nothing to prune")
+		return nil
+	}
+	for _, id := range removed {
+		p.Logger.Infof("Pruning cached layer %q", id)
+	}
+
+	reused := map[string]bool{}
+	reuse := func(sha string) error {
+		if sha == "" || reused[sha] {
+			return nil
+		}
+		reused[sha] = true
+		return cacheStore.ReuseLayer(sha)
+	}
+	for _, bpMD := range meta.Buildpacks {
+		for name, lmd := range bpMD.Layers {
+			p.Logger.Debugf("Keeping cached layer %q", bpMD.ID+":"+name)
+			if err := reuse(lmd.SHA); err != nil {
+				return errors.Wrapf(err, "reusing cached layer '%s:%s'", bpMD.ID, name)
+			}
+		}
+	}
+	if err := reuse(meta.BOM.SHA); err != nil {
+		return errors.Wrap(err, "reusing cached SBOM layer")
+	}
+
+	if err := cacheStore.SetMetadata(meta); err != nil {
+		return errors.Wrap(err, "setting cache metadata")
+	}
+	if err := cacheStore.Commit(); err != nil {
+		return errors.Wrap(err, "committing cache")
+	}
+	return nil
+}
+
+type layerSizer interface {
+	LayerSize(sha string) (int64, error)
+}
+
+type storedLayerSizer interface {
+	StoredLayerSize(sha string) (int64, error)
+}
+
+// InspectCache reports the layers stored in the cache for each buildpack, along with the SBOM layer if present.
+func InspectCache(cacheStore Cache) (platform.CacheReport, error) {
+	report := platform.CacheReport{Name: cacheStore.Name()}
+	if !cacheStore.Exists() {
+		return report, nil
+	}
+	meta, err := cacheStore.RetrieveMetadata()
+	if err != nil {
+		return report, errors.Wrap(err, "retrieving cache metadata")
+	}
+
+	for _, bpMD := range meta.Buildpacks {
+		bpReport := platform.CacheBuildpackReport{ID: bpMD.ID, Version: bpMD.Version}
+		var names []string
+		for name := range bpMD.Layers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			lmd := bpMD.Layers[name]
+			size, err := cachedLayerSize(cacheStore, lmd.SHA)
+			if err != nil {
+				return report, errors.Wrapf(err, "getting size of cached layer '%s:%s'", bpMD.ID, name)
+			}
+			metadata, err := encodeCachedLayerMetadata(lmd.LayerMetadataFile)
+			if err != nil {
+				return report, errors.Wrapf(err, "encoding metadata of cached layer '%s:%s'", bpMD.ID, name)
+			}
+			bpReport.Layers = append(bpReport.Layers, platform.CacheLayerReport{
+				Name:     name,
+				SHA:      lmd.SHA,
+				Size:     size,
+				Metadata: metadata,
+			})
+		}
+		report.Buildpacks = append(report.Buildpacks, bpReport)
+	}
+
+	if meta.BOM.SHA != "" {
+		size, err := cachedLayerSize(cacheStore, meta.BOM.SHA)
+		if err != nil {
+			return report, errors.Wrap(err, "getting size of cached SBOM layer")
+		}
+		report.SBOM = &platform.CacheLayerReport{Name: "sbom", SHA: meta.BOM.SHA, Size: size}
+	}
+	return report, nil
+}
+
+func cachedLayerSize(cacheStore Cache, sha string) (int64, error) {
+	if sizer, ok := cacheStore.(storedLayerSizer); ok {
+		return sizer.StoredLayerSize(sha)
+	}
+	if sizer, ok := cacheStore.(layerSizer); ok {
+		return sizer.LayerSize(sha)
+	}
+	rc, err := cacheStore.RetrieveLayer(sha)
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	return io.Copy(ioutil.Discard, rc)
+}
+
+func encodeCachedLayerMetadata(lmf buildpack.LayerMetadataFile) (string, error) {
+	type typesTable struct {
+		Build  bool `toml:"build"`
+		Launch bool `toml:"launch"`
+		Cache  bool `toml:"cache"`
+	}
+	type layerMetadataTomlFile struct {
+		Data  interface{} `toml:"metadata,omitempty"`
+		Types typesTable  `toml:"types"`
+	}
+	buf := &bytes.Buffer{}
+	if err := toml.NewEncoder(buf).Encode(layerMetadataTomlFile{
+		Data:  lmf.Data,
+		Types: typesTable{Build: lmf.Build, Launch: lmf.Launch, Cache: lmf.Cache},
+	}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+// ArchiveSource is a cache that can be written to an archive.
+type ArchiveSource interface {
+	RetrieveMetadata() (platform.CacheMetadata, error)
+	RetrieveLayer(diffID string) (io.ReadCloser, error)
+}
+
+// WriteArchive writes the metadata and the layers referenced by the metadata of the given cache to a tar archive.
+// The archive can be read back with NewVolumeCacheFromArchive, e.g. to seed the cache of a new project.
+func WriteArchive(w io.Writer, src ArchiveSource) error {
+	meta, err := src.RetrieveMetadata()
+	if err != nil {
+		return errors.Wrap(err, "retrieving cache metadata")
+	}
+
+	tw := tar.NewWriter(w)
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return errors.Wrap(err, "serializing metadata")
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: MetadataLabel, Mode: 0644, Size: int64(len(data))}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+
+	written := map[string]bool{}
+	for _, diffID := range layerDiffIDs(meta) {
+		if written[diffID] {
+			continue
+		}
+		if err := writeArchiveLayer(tw, src, diffID); err != nil {
+			return errors.Wrapf(err, "writing layer '%s' to archive", diffID)
+		}
+		written[diffID] = true
+	}
+	return tw.Close()
+}
+
+// NewVolumeCacheFromArchive extracts an archive written by WriteArchive into dir
+// and returns a read-only cache backed by the extracted files.
+func NewVolumeCacheFromArchive(archivePath, dir string) (*VolumeCache, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening cache archive '%s'", archivePath)
+	}
+	defer f.Close()
+
+	committedDir := filepath.Join(dir, "committed")
+	if err := os.MkdirAll(committedDir, 0777); err != nil {
+		return nil, errors.Wrapf(err, "creating directory '%s'", committedDir)
+	}
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading cache archive '%s'", archivePath)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// the archive is flat, never write outside of the committed directory
+		path := filepath.Join(committedDir, filepath.Base(hdr.Name))
+		if err := writeArchiveFile(tr, path); err != nil {
+			return nil, errors.Wrapf(err, "extracting '%s'", hdr.Name)
+		}
+	}
+	return NewReadOnlyVolumeCache(dir)
+}
+
+func layerDiffIDs(meta platform.CacheMetadata) []string {
+	var diffIDs []string
+	for _, bpMD := range meta.Buildpacks {
+		for _, lmd := range bpMD.Layers {
+			diffIDs = append(diffIDs, lmd.SHA)
+		}
+	}
+	if meta.BOM.SHA != "" {
+		diffIDs = append(diffIDs, meta.BOM.SHA)
+	}
+	return diffIDs
+}
+
+func writeArchiveLayer(tw *tar.Writer, src ArchiveSource, diffID string) error {
+	rc, err := src.RetrieveLayer(diffID)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var (
+		size    int64
+		content io.Reader = rc
+	)
+	if sizer, ok := src.(interface {
+		LayerSize(diffID string) (int64, error)
+	}); ok {
+		if size, err = sizer.LayerSize(diffID); err != nil {
+			return err
+		}
+	} else {
+		// the tar header needs the size up front, spool the layer to find it
+		tmp, err := ioutil.TempFile("", "cache-archive-layer")
+		if err != nil {
+			return err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+		if size, err = io.Copy(tmp, rc); err != nil {
+			return err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		content = tmp
+	}
+
+	if err := tw.WriteHeader(&tar.Header{Name: filepath.Base(diffIDPath("", diffID)), Mode: 0644, Size: size}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, content)
+	return err
+}
+
+func writeArchiveFile(r io.Reader, path string) error {
+	fh, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+	_, err = io.Copy(fh, r)
+	return err
+}
@@ -0,0 +1,97 @@
+package cache_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/cache"
+	"github.com/buildpacks/lifecycle/platform"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestArchive(t *testing.T) {
+	spec.Run(t, "Archive", testArchive, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testArchive(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir      string
+		archivePath string
+		layerSHA    string
+		metadata    platform.CacheMetadata
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "lifecycle.cache.archive")
+		h.AssertNil(t, err)
+
+		volumeDir := filepath.Join(tmpDir, "volume")
+		h.AssertNil(t, os.Mkdir(volumeDir, 0777))
+		src, err := cache.NewVolumeCache(volumeDir)
+		h.AssertNil(t, err)
+
+		layerPath := filepath.Join(tmpDir, "some-layer.tar")
+		h.AssertNil(t, ioutil.WriteFile(layerPath, []byte("dummy data"), 0600))
+		layerSHA = "sha256:" + h.ComputeSHA256ForFile(t, layerPath)
+		metadata = platform.CacheMetadata{
+			Buildpacks: []buildpack.LayersMetadata{{
+				ID:      "bp.id",
+				Version: "1.2.3",
+				Layers: map[string]buildpack.LayerMetadata{
+					"some-layer": {SHA: layerSHA},
+				},
+			}},
+		}
+		h.AssertNil(t, src.AddLayerFile(layerPath, layerSHA))
+		h.AssertNil(t, src.SetMetadata(metadata))
+		h.AssertNil(t, src.Commit())
+
+		archivePath = filepath.Join(tmpDir, "cache.tar")
+		f, err := os.Create(archivePath)
+		h.AssertNil(t, err)
+		defer f.Close()
+		h.AssertNil(t, cache.WriteArchive(f, src))
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	when("#NewVolumeCacheFromArchive", func() {
+		var subject *cache.VolumeCache
+
+		it.Before(func() {
+			var err error
+			subject, err = cache.NewVolumeCacheFromArchive(archivePath, filepath.Join(tmpDir, "seed"))
+			h.AssertNil(t, err)
+		})
+
+		it("restores the metadata", func() {
+			got, err := subject.RetrieveMetadata()
+			h.AssertNil(t, err)
+			h.AssertEq(t, got, metadata)
+		})
+
+		it("restores the layers", func() {
+			rc, err := subject.RetrieveLayer(layerSHA)
+			h.AssertNil(t, err)
+			defer rc.Close()
+			contents, err := ioutil.ReadAll(rc)
+			h.AssertNil(t, err)
+			h.AssertEq(t, string(contents), "dummy data")
+		})
+
+		it("is read-only", func() {
+			h.AssertError(t, subject.ReuseLayer(layerSHA), "cache is read-only")
+			h.AssertError(t, subject.SetMetadata(platform.CacheMetadata{}), "cache is read-only")
+			h.AssertError(t, subject.Commit(), "cache is read-only")
+		})
+	})
+}
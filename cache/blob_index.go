@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// BlobIndex records where layer blobs were previously pushed, keyed by layer diffID,
+// so that later exports can mount them instead of uploading them again.
+type BlobIndex struct {
+	path string
+
+	mutex   sync.Mutex
+	entries map[string][]BlobLocation
+}
+
+// BlobLocation is a layer blob in a registry repository.
+type BlobLocation struct {
+	Registry   string `json:"registry"`
+	Repository string `json:"repository"`
+	Digest     string `json:"digest"`
+	Size       int64  `json:"size"`
+}
+
+// NewBlobIndex reads the index at path, starting with an empty index if the file does not exist.
+func NewBlobIndex(path string) (*BlobIndex, error) {
+	idx := &BlobIndex{path: path, entries: map[string][]BlobLocation{}}
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return idx, nil
+		}
+		return nil, errors.Wrapf(err, "opening blob index '%s'", path)
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(&idx.entries); err != nil {
+		// a corrupt index only costs uploads, start over
+		idx.entries = map[string][]BlobLocation{}
+	}
+	return idx, nil
+}
+
+// Find returns a location of the layer in the given registry, preferring the given repository.
+func (i *BlobIndex) Find(diffID, registry, repository string) (BlobLocation, bool) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	var (
+		found BlobLocation
+		ok    bool
+	)
+	for _, loc := range i.entries[diffID] {
+		if loc.Registry != registry {
+			continue
+		}
+		if loc.Repository == repository {
+			return loc, true
+		}
+		found, ok = loc, true
+	}
+	return found, ok
+}
+
+// Add records a location of the layer, replacing any previous entry for the same repository.
+func (i *BlobIndex) Add(diffID string, loc BlobLocation) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	locs := []BlobLocation{loc}
+	for _, existing := range i.entries[diffID] {
+		if existing.Registry != loc.Registry || existing.Repository != loc.Repository {
+			locs = append(locs, existing)
+		}
+	}
+	i.entries[diffID] = locs
+}
+
+// Save writes the index to disk.
+func (i *BlobIndex) Save() error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+	f, err := os.Create(i.path)
+	if err != nil {
+		return errors.Wrapf(err, "creating blob index '%s'", i.path)
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(i.entries)
+}
@@ -0,0 +1,88 @@
+package cache_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/cache"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestBlobIndex(t *testing.T) {
+	spec.Run(t, "BlobIndex", testBlobIndex, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testBlobIndex(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir    string
+		indexPath string
+		subject   *cache.BlobIndex
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "lifecycle.cache.blob-index")
+		h.AssertNil(t, err)
+		indexPath = filepath.Join(tmpDir, "blob-index.json")
+		subject, err = cache.NewBlobIndex(indexPath)
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	when("#Find", func() {
+		it.Before(func() {
+			subject.Add("sha256:some-diff-id", cache.BlobLocation{Registry: "some-registry", Repository: "other/repo", Digest: "sha256:other-digest"})
+			subject.Add("sha256:some-diff-id", cache.BlobLocation{Registry: "some-registry", Repository: "some/repo", Digest: "sha256:some-digest"})
+		})
+
+		it("prefers the given repository", func() {
+			loc, ok := subject.Find("sha256:some-diff-id", "some-registry", "some/repo")
+			h.AssertEq(t, ok, true)
+			h.AssertEq(t, loc.Digest, "sha256:some-digest")
+		})
+
+		it("falls back to another repository on the same registry", func() {
+			loc, ok := subject.Find("sha256:some-diff-id", "some-registry", "third/repo")
+			h.AssertEq(t, ok, true)
+			h.AssertEq(t, loc.Registry, "some-registry")
+		})
+
+		it("does not find layers on other registries", func() {
+			_, ok := subject.Find("sha256:some-diff-id", "other-registry", "some/repo")
+			h.AssertEq(t, ok, false)
+		})
+	})
+
+	when("#Save", func() {
+		it("persists the index", func() {
+			loc := cache.BlobLocation{Registry: "some-registry", Repository: "some/repo", Digest: "sha256:some-digest", Size: 10}
+			subject.Add("sha256:some-diff-id", loc)
+			h.AssertNil(t, subject.Save())
+
+			reloaded, err := cache.NewBlobIndex(indexPath)
+			h.AssertNil(t, err)
+			got, ok := reloaded.Find("sha256:some-diff-id", "some-registry", "some/repo")
+			h.AssertEq(t, ok, true)
+			h.AssertEq(t, got, loc)
+		})
+	})
+
+	when("the index file is corrupt", func() {
+		it("starts with an empty index", func() {
+			h.AssertNil(t, ioutil.WriteFile(indexPath, []byte("not json"), 0600))
+
+			reloaded, err := cache.NewBlobIndex(indexPath)
+			h.AssertNil(t, err)
+			_, ok := reloaded.Find("sha256:some-diff-id", "some-registry", "some/repo")
+			h.AssertEq(t, ok, false)
+		})
+	})
+}
@@ -4,13 +4,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"os"
 	"runtime"
+	"sort"
+	"sync"
 
 	"github.com/buildpacks/imgutil"
 	"github.com/buildpacks/imgutil/remote"
 	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	ggcrremote "github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/pkg/errors"
 
+	"github.com/buildpacks/lifecycle/buildpack"
 	"github.com/buildpacks/lifecycle/image"
 	"github.com/buildpacks/lifecycle/platform"
 )
@@ -19,18 +26,64 @@ const MetadataLabel = "io.buildpacks.lifecycle.cache.metadata"
 
 type ImageCache struct {
 	committed bool
+	readOnly  bool
 	origImage imgutil.Image
 	newImage  imgutil.Image
+	maxLayers int
+	logger    Logger
+
+	layers   map[string]bool         // diffIDs of the layers in the new image, or to be added to it on commit when maxLayers is set
+	pending  []cacheLayer            // layers to be added to the new image on commit, when maxLayers is set
+	metadata *platform.CacheMetadata // metadata to be set on the new image on commit, when maxLayers is set
+	evicted  map[string]bool         // diffIDs of the layers left out of the new image because of maxLayers
+
+	manifestRef      name.Reference // the cache image in the registry, when known
+	keychain         authn.Keychain
+	storedSizesOnce  sync.Once
+	storedSizes      map[string]int64 // diffID to blob size, from the manifest of the cache image
+	storedSizesError error
+}
+
+// cacheLayer is a layer to be added to the new cache image, tarPath is empty for reused layers.
+type cacheLayer struct {
+	diffID  string
+	tarPath string
+	size    int64
+}
+
+type ImageCacheOption func(*ImageCache)
+
+// Logger is used by the image cache to report evicted layers.
+type Logger interface {
+	Warnf(fmt string, v ...interface{})
 }
 
-func NewImageCache(origImage imgutil.Image, newImage imgutil.Image) *ImageCache {
-	return &ImageCache{
+// WithLayerEviction limits the number of layers in the cache image. When more layers are cached, the smallest layers are
+// evicted on commit: they are left out of the cache image and their entries are removed from the cache metadata.
+// Layers are compared by their size as stored, the size of the tarball for new layers and of the compressed blob
+// for reused layers, and layers of the same size by diffID, so that the same layers evict the same set on every build.
+// Each evicted entry is logged as a warning. A value <= 0 means no limit.
+func WithLayerEviction(maxLayers int, logger Logger) ImageCacheOption {
+	return func(c *ImageCache) {
+		c.maxLayers = maxLayers
+		c.logger = logger
+	}
+}
+
+func NewImageCache(origImage imgutil.Image, newImage imgutil.Image, opts ...ImageCacheOption) *ImageCache {
+	c := &ImageCache{
 		origImage: origImage,
 		newImage:  newImage,
+		layers:    map[string]bool{},
+		evicted:   map[string]bool{},
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
-func NewImageCacheFromName(name string, keychain authn.Keychain) (*ImageCache, error) {
+func NewImageCacheFromName(name string, keychain authn.Keychain, opts ...ImageCacheOption) (*ImageCache, error) {
 	origImage, err := remote.NewImage(
 		name,
 		keychain,
@@ -50,7 +103,79 @@ func NewImageCacheFromName(name string, keychain authn.Keychain) (*ImageCache, e
 		return nil, fmt.Errorf("creating new cache image %q: %v", name, err)
 	}
 
-	return NewImageCache(origImage, emptyImage), nil
+	c := NewImageCache(origImage, emptyImage, opts...)
+	c.withManifestRef(name, keychain)
+	return c, nil
+}
+
+// NewReadOnlyImageCacheFromName returns a cache that can be read from, but never modified, e.g. a seed cache.
+func NewReadOnlyImageCacheFromName(name string, keychain authn.Keychain) (*ImageCache, error) {
+	origImage, err := remote.NewImage(
+		name,
+		keychain,
+		remote.FromBaseImage(name),
+		remote.WithDefaultPlatform(imgutil.Platform{OS: runtime.GOOS}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("accessing cache image %q: %v", name, err)
+	}
+	c := NewImageCache(origImage, nil)
+	c.readOnly = true
+	c.withManifestRef(name, keychain)
+	return c, nil
+}
+
+func (c *ImageCache) withManifestRef(imageName string, keychain authn.Keychain) {
+	if ref, err := name.ParseReference(imageName, name.WeakValidation); err == nil {
+		c.manifestRef, c.keychain = ref, keychain
+	}
+}
+
+// StoredLayerSize returns the size of the layer as stored in the cache image, i.e. the size of the compressed blob.
+// For cache images in a registry it is read from the manifest, so the layer does not need to be downloaded.
+func (c *ImageCache) StoredLayerSize(diffID string) (int64, error) {
+	if c.manifestRef == nil {
+		rc, err := c.origImage.GetLayer(diffID)
+		if err != nil {
+			return 0, err
+		}
+		defer rc.Close()
+		return io.Copy(ioutil.Discard, rc)
+	}
+	c.storedSizesOnce.Do(func() {
+		c.storedSizes, c.storedSizesError = manifestLayerSizes(c.manifestRef, c.keychain)
+	})
+	if c.storedSizesError != nil {
+		return 0, c.storedSizesError
+	}
+	size, ok := c.storedSizes[diffID]
+	if !ok {
+		return 0, fmt.Errorf("layer with SHA '%s' not found in cache image '%s'", diffID, c.manifestRef)
+	}
+	return size, nil
+}
+
+func manifestLayerSizes(ref name.Reference, keychain authn.Keychain) (map[string]int64, error) {
+	img, err := ggcrremote.Image(ref, ggcrremote.WithAuthFromKeychain(keychain))
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting cache image '%s'", ref)
+	}
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting config of cache image '%s'", ref)
+	}
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting manifest of cache image '%s'", ref)
+	}
+	if len(manifest.Layers) != len(configFile.RootFS.DiffIDs) {
+		return nil, fmt.Errorf("cache image '%s' has %d layers but %d diff IDs", ref, len(manifest.Layers), len(configFile.RootFS.DiffIDs))
+	}
+	sizes := map[string]int64{}
+	for i, diffID := range configFile.RootFS.DiffIDs {
+		sizes[diffID.String()] = manifest.Layers[i].Size
+	}
+	return sizes, nil
 }
 
 func (c *ImageCache) Exists() bool {
@@ -62,10 +187,18 @@ func (c *ImageCache) Name() string {
 }
 
 func (c *ImageCache) SetMetadata(metadata platform.CacheMetadata) error {
-	if c.committed {
-		return errCacheCommitted
+	if err := c.checkWritable(); err != nil {
+		return err
 	}
-	data, err := json.Marshal(metadata)
+	if c.maxLayers > 0 {
+		c.metadata = &metadata
+		return nil
+	}
+	return c.setMetadataLabel(metadata)
+}
+
+func (c *ImageCache) setMetadataLabel(metadata platform.CacheMetadata) error {
+	data, err := json.Marshal(c.withoutEvictedLayers(metadata))
 	if err != nil {
 		return errors.Wrap(err, "serializing metadata")
 	}
@@ -80,18 +213,141 @@ func (c *ImageCache) RetrieveMetadata() (platform.CacheMetadata, error) {
 	return meta, nil
 }
 
+// AddLayerFile adds the layer to the new cache image. Layers already in the new image are not added twice,
+// and layers present in the original cache image are reused instead of being uploaded again.
 func (c *ImageCache) AddLayerFile(tarPath string, diffID string) error {
-	if c.committed {
-		return errCacheCommitted
+	if err := c.checkWritable(); err != nil {
+		return err
 	}
-	return c.newImage.AddLayerWithDiffID(tarPath, diffID)
+	if c.layers[diffID] {
+		return nil
+	}
+	if c.maxLayers > 0 {
+		fi, err := os.Stat(tarPath)
+		if err != nil {
+			return err
+		}
+		c.pending = append(c.pending, cacheLayer{diffID: diffID, tarPath: tarPath, size: fi.Size()})
+		c.layers[diffID] = true
+		return nil
+	}
+	if err := c.addLayer(tarPath, diffID); err != nil {
+		return err
+	}
+	c.layers[diffID] = true
+	return nil
+}
+
+// addLayer mounts the layer from the original cache image if it is there, instead of uploading it.
+func (c *ImageCache) addLayer(tarPath string, diffID string) error {
+	if err := c.newImage.ReuseLayer(diffID); err != nil {
+		return c.newImage.AddLayerWithDiffID(tarPath, diffID)
+	}
+	return nil
 }
 
 func (c *ImageCache) ReuseLayer(diffID string) error {
+	if err := c.checkWritable(); err != nil {
+		return err
+	}
+	if c.layers[diffID] {
+		return nil
+	}
+	if c.maxLayers > 0 {
+		// the size lookup fails for layers that are not in the original cache image, as reusing them would
+		size, err := c.StoredLayerSize(diffID)
+		if err != nil {
+			return err
+		}
+		c.pending = append(c.pending, cacheLayer{diffID: diffID, size: size})
+		c.layers[diffID] = true
+		return nil
+	}
+	if err := c.newImage.ReuseLayer(diffID); err != nil {
+		return err
+	}
+	c.layers[diffID] = true
+	return nil
+}
+
+func (c *ImageCache) checkWritable() error {
+	if c.readOnly {
+		return errCacheReadOnly
+	}
 	if c.committed {
 		return errCacheCommitted
 	}
-	return c.newImage.ReuseLayer(diffID)
+	return nil
+}
+
+// addPendingLayers evicts the smallest pending layers over maxLayers, adds the others to the new image
+// and sets the metadata without the evicted layers.
+func (c *ImageCache) addPendingLayers() error {
+	bySize := append([]cacheLayer{}, c.pending...)
+	sort.Slice(bySize, func(i, j int) bool {
+		if bySize[i].size != bySize[j].size {
+			return bySize[i].size > bySize[j].size
+		}
+		return bySize[i].diffID < bySize[j].diffID
+	})
+	for i := c.maxLayers; i < len(bySize); i++ {
+		c.evicted[bySize[i].diffID] = true
+	}
+	for _, layer := range c.pending {
+		if c.evicted[layer.diffID] {
+			continue
+		}
+		var err error
+		if layer.tarPath == "" {
+			err = c.newImage.ReuseLayer(layer.diffID)
+		} else {
+			err = c.addLayer(layer.tarPath, layer.diffID)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	c.pending = nil
+	if c.metadata == nil {
+		return nil
+	}
+	return c.setMetadataLabel(*c.metadata)
+}
+
+func (c *ImageCache) withoutEvictedLayers(metadata platform.CacheMetadata) platform.CacheMetadata {
+	if len(c.evicted) == 0 {
+		return metadata
+	}
+	kept := platform.CacheMetadata{BOM: metadata.BOM}
+	if c.evicted[kept.BOM.SHA] {
+		c.logEviction("sbom")
+		kept.BOM = platform.LayerMetadata{}
+	}
+	for _, bpMD := range metadata.Buildpacks {
+		var names []string
+		for name := range bpMD.Layers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		layers := map[string]buildpack.LayerMetadata{}
+		for _, name := range names {
+			lmd := bpMD.Layers[name]
+			if c.evicted[lmd.SHA] {
+				c.logEviction(bpMD.ID + ":" + name)
+				continue
+			}
+			layers[name] = lmd
+		}
+		bpMD.Layers = layers
+		kept.Buildpacks = append(kept.Buildpacks, bpMD)
+	}
+	return kept
+}
+
+func (c *ImageCache) logEviction(id string) {
+	if c.logger != nil {
+		c.logger.Warnf("Evicting cached layer %q, the cache image is limited to %d layers", id, c.maxLayers)
+	}
 }
 
 func (c *ImageCache) RetrieveLayer(diffID string) (io.ReadCloser, error) {
@@ -99,8 +355,14 @@ func (c *ImageCache) RetrieveLayer(diffID string) (io.ReadCloser, error) {
 }
 
 func (c *ImageCache) Commit() error {
-	if c.committed {
-		return errCacheCommitted
+	if err := c.checkWritable(); err != nil {
+		return err
+	}
+
+	if c.maxLayers > 0 {
+		if err := c.addPendingLayers(); err != nil {
+			return err
+		}
 	}
 
 	// Check if the cache image exists prior to saving the new cache at that same location
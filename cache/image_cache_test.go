@@ -4,13 +4,25 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/rand"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
 	"github.com/buildpacks/imgutil/fakes"
 	"github.com/buildpacks/imgutil/local"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
 
@@ -143,6 +155,72 @@ func testImageCache(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("#StoredLayerSize", func() {
+		it("counts the bytes of the layer without a registry", func() {
+			h.AssertNil(t, fakeOriginalImage.AddLayer(testLayerTarPath))
+
+			size, err := subject.StoredLayerSize(testLayerSHA)
+			h.AssertNil(t, err)
+			h.AssertEq(t, size, int64(len("dummy data")))
+		})
+
+		when("the cache image is in a registry", func() {
+			var (
+				server   *httptest.Server
+				cacheRef string
+				cacheImg v1.Image
+			)
+
+			it.Before(func() {
+				server = httptest.NewServer(registry.New())
+				serverURL, err := url.Parse(server.URL)
+				h.AssertNil(t, err)
+				cacheRef = serverURL.Host + "/some/cache-image"
+
+				cacheImg, err = random.Image(100, 2)
+				h.AssertNil(t, err)
+				configFile, err := cacheImg.ConfigFile()
+				h.AssertNil(t, err)
+				configFile.OS, configFile.Architecture = runtime.GOOS, runtime.GOARCH
+				cacheImg, err = mutate.ConfigFile(cacheImg, configFile)
+				h.AssertNil(t, err)
+				tag, err := name.NewTag(cacheRef, name.WeakValidation)
+				h.AssertNil(t, err)
+				h.AssertNil(t, remote.Write(tag, cacheImg))
+			})
+
+			it.After(func() {
+				server.Close()
+			})
+
+			it("returns the blob size from the manifest", func() {
+				imageCache, err := cache.NewReadOnlyImageCacheFromName(cacheRef, authn.DefaultKeychain)
+				h.AssertNil(t, err)
+
+				layers, err := cacheImg.Layers()
+				h.AssertNil(t, err)
+				for _, layer := range layers {
+					diffID, err := layer.DiffID()
+					h.AssertNil(t, err)
+					expected, err := layer.Size()
+					h.AssertNil(t, err)
+
+					size, err := imageCache.StoredLayerSize(diffID.String())
+					h.AssertNil(t, err)
+					h.AssertEq(t, size, expected)
+				}
+			})
+
+			it("errors for layers that are not in the cache image", func() {
+				imageCache, err := cache.NewReadOnlyImageCacheFromName(cacheRef, authn.DefaultKeychain)
+				h.AssertNil(t, err)
+
+				_, err = imageCache.StoredLayerSize("sha256:some-missing-layer")
+				h.AssertError(t, err, "layer with SHA 'sha256:some-missing-layer' not found in cache image")
+			})
+		})
+	})
+
 	when("#Commit", func() {
 		when("with #SetMetadata", func() {
 			var newMetadata platform.CacheMetadata
@@ -231,6 +309,116 @@ func testImageCache(t *testing.T, when spec.G, it spec.S) {
 					h.AssertError(t, err, fmt.Sprintf("failed to get layer with sha '%s'", testLayerSHA))
 				})
 			})
+
+			when("add the same layer twice", func() {
+				it("adds the layer once", func() {
+					h.AssertNil(t, subject.AddLayerFile(testLayerTarPath, testLayerSHA))
+					h.AssertNil(t, subject.AddLayerFile(testLayerTarPath, testLayerSHA))
+
+					h.AssertEq(t, fakeNewImage.NumberOfAddedLayers(), 1)
+				})
+			})
+
+			when("the layer is in the original image", func() {
+				it.Before(func() {
+					fakeNewImage.AddPreviousLayer(testLayerSHA, testLayerTarPath)
+				})
+
+				it("reuses the layer", func() {
+					h.AssertNil(t, subject.AddLayerFile(testLayerTarPath, testLayerSHA))
+
+					h.AssertEq(t, fakeNewImage.NumberOfAddedLayers(), 0)
+					h.AssertEq(t, fakeNewImage.ReusedLayers(), []string{testLayerSHA})
+				})
+			})
+
+			when("the image has reached the maximum number of layers", func() {
+				var (
+					otherLayerTarPath string
+					otherLayerSHA     string
+					logHandler        *memory.Handler
+				)
+
+				it.Before(func() {
+					logHandler = memory.New()
+					subject = cache.NewImageCache(fakeOriginalImage, fakeNewImage, cache.WithLayerEviction(1, &log.Logger{Handler: logHandler}))
+
+					otherLayerTarPath = filepath.Join(tmpDir, "other-layer.tar")
+					h.AssertNil(t, ioutil.WriteFile(otherLayerTarPath, []byte("other dummy data"), 0600))
+					otherLayerSHA = "sha256:" + h.ComputeSHA256ForFile(t, otherLayerTarPath)
+				})
+
+				it("evicts the smallest layers from the image and the metadata", func() {
+					h.AssertNil(t, subject.AddLayerFile(otherLayerTarPath, otherLayerSHA))
+					h.AssertNil(t, subject.AddLayerFile(testLayerTarPath, testLayerSHA))
+					h.AssertNil(t, subject.SetMetadata(platform.CacheMetadata{
+						BOM: platform.LayerMetadata{SHA: testLayerSHA},
+						Buildpacks: []buildpack.LayersMetadata{{
+							ID: "bp.id",
+							Layers: map[string]buildpack.LayerMetadata{
+								"some-layer":  {SHA: testLayerSHA},
+								"other-layer": {SHA: otherLayerSHA},
+							},
+						}},
+					}))
+					h.AssertNil(t, subject.Commit())
+
+					h.AssertEq(t, fakeNewImage.NumberOfAddedLayers(), 1)
+					_, err := fakeNewImage.GetLayer(otherLayerSHA)
+					h.AssertNil(t, err)
+					meta, err := subject.RetrieveMetadata()
+					h.AssertNil(t, err)
+					h.AssertEq(t, meta.BOM.SHA, "")
+					h.AssertEq(t, meta.Buildpacks[0].Layers, map[string]buildpack.LayerMetadata{
+						"other-layer": {SHA: otherLayerSHA},
+					})
+
+					var messages []string
+					for _, entry := range logHandler.Entries {
+						messages = append(messages, entry.Message)
+					}
+					h.AssertEq(t, messages, []string{
+						`Evicting cached layer "sbom", the cache image is limited to 1 layers`,
+						`Evicting cached layer "bp.id:some-layer", the cache image is limited to 1 layers`,
+					})
+				})
+
+				it("evicts the same layers whatever the order they are added in", func() {
+					sameSizeTarPath := filepath.Join(tmpDir, "same-size-layer.tar")
+					h.AssertNil(t, ioutil.WriteFile(sameSizeTarPath, []byte("same dummy data!"), 0600))
+					sameSizeSHA := "sha256:" + h.ComputeSHA256ForFile(t, sameSizeTarPath)
+					layers := map[string]string{
+						testLayerSHA:  testLayerTarPath,
+						otherLayerSHA: otherLayerTarPath,
+						sameSizeSHA:   sameSizeTarPath,
+					}
+
+					kept := func(order ...string) []string {
+						image := fakes.NewImage("fake-new-image", "", local.IDIdentifier{ImageID: "new"})
+						defer image.Cleanup()
+						subject := cache.NewImageCache(fakeOriginalImage, image, cache.WithLayerEviction(1, &log.Logger{Handler: memory.New()}))
+						for _, diffID := range order {
+							h.AssertNil(t, subject.AddLayerFile(layers[diffID], diffID))
+						}
+						h.AssertNil(t, subject.Commit())
+						var kept []string
+						for _, diffID := range order {
+							if _, err := image.GetLayer(diffID); err == nil {
+								kept = append(kept, diffID)
+							}
+						}
+						return kept
+					}
+
+					expected := []string{otherLayerSHA}
+					if sameSizeSHA < otherLayerSHA {
+						expected = []string{sameSizeSHA}
+					}
+					h.AssertEq(t, kept(testLayerSHA, otherLayerSHA, sameSizeSHA), expected)
+					h.AssertEq(t, kept(sameSizeSHA, otherLayerSHA, testLayerSHA), expected)
+					h.AssertEq(t, kept(otherLayerSHA, testLayerSHA, sameSizeSHA), expected)
+				})
+			})
 		})
 
 		when("with #ReuseLayer", func() {
@@ -0,0 +1,210 @@
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+
+	"github.com/buildpacks/imgutil"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
+	"github.com/pkg/errors"
+)
+
+// RemoteCachingImage is a registry image that uses a BlobIndex to mount layer blobs previously pushed
+// to the same registry instead of uploading them again.
+type RemoteCachingImage struct {
+	imgutil.Image
+	index    *BlobIndex
+	keychain authn.Keychain
+
+	mutex         sync.Mutex
+	mounted       map[string]BlobLocation // diffID to the blob mounted for the layer
+	mountedLayers int64
+	mountedBytes  int64
+}
+
+func NewRemoteCachingImage(image imgutil.Image, index *BlobIndex, keychain authn.Keychain) *RemoteCachingImage {
+	return &RemoteCachingImage{
+		Image:    image,
+		index:    index,
+		keychain: keychain,
+		mounted:  map[string]BlobLocation{},
+	}
+}
+
+func (c *RemoteCachingImage) AddLayer(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errors.Wrap(err, "opening layer file")
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return errors.Wrap(err, "hashing layer")
+	}
+	diffID := "sha256:" + hex.EncodeToString(hasher.Sum(make([]byte, 0, hasher.Size())))
+	return c.AddLayerWithDiffID(path, diffID)
+}
+
+// AddLayerWithDiffID makes a known blob for the layer available in the image repository before adding the layer,
+// so that saving the image finds the blob already present and skips the upload.
+// The upload is only skipped if the image pushes the layer as the same blob, which is checked once the image is saved.
+func (c *RemoteCachingImage) AddLayerWithDiffID(path, diffID string) error {
+	if repo, err := name.NewRepository(c.repositoryName(), name.WeakValidation); err == nil {
+		if loc, ok := c.index.Find(diffID, repo.RegistryStr(), repo.RepositoryStr()); ok {
+			if mounted, err := c.mount(repo, loc); err == nil && mounted {
+				c.mutex.Lock()
+				c.mounted[diffID] = loc
+				c.mutex.Unlock()
+			}
+		}
+	}
+	return c.Image.AddLayerWithDiffID(path, diffID)
+}
+
+// Save saves the image and records the location of its layer blobs in the index.
+func (c *RemoteCachingImage) Save(additionalNames ...string) error {
+	err := c.Image.Save(additionalNames...)
+
+	if saveSucceededFor(c.Name(), err) {
+		if err := c.indexLayers(); err != nil {
+			return errors.Wrap(err, "failed to update blob index")
+		}
+	}
+	return err
+}
+
+// MountStats returns the number of layers and bytes that were mounted rather than uploaded, once the image is saved.
+func (c *RemoteCachingImage) MountStats() (layers int64, bytes int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.mountedLayers, c.mountedBytes
+}
+
+func (c *RemoteCachingImage) repositoryName() string {
+	ref, err := name.ParseReference(c.Name(), name.WeakValidation)
+	if err != nil {
+		return c.Name()
+	}
+	return ref.Context().Name()
+}
+
+func (c *RemoteCachingImage) indexLayers() error {
+	ref, err := name.ParseReference(c.Name(), name.WeakValidation)
+	if err != nil {
+		return err
+	}
+	img, err := remote.Image(ref, remote.WithAuthFromKeychain(c.keychain))
+	if err != nil {
+		return err
+	}
+	layers, err := img.Layers()
+	if err != nil {
+		return err
+	}
+	for _, layer := range layers {
+		diffID, err := layer.DiffID()
+		if err != nil {
+			return err
+		}
+		digest, err := layer.Digest()
+		if err != nil {
+			return err
+		}
+		size, err := layer.Size()
+		if err != nil {
+			return err
+		}
+		c.countMounted(diffID.String(), digest.String())
+		c.index.Add(diffID.String(), BlobLocation{
+			Registry:   ref.Context().RegistryStr(),
+			Repository: ref.Context().RepositoryStr(),
+			Digest:     digest.String(),
+			Size:       size,
+		})
+	}
+	return c.index.Save()
+}
+
+// countMounted counts the layer as mounted if the blob mounted for it is the blob the saved image references.
+func (c *RemoteCachingImage) countMounted(diffID, digest string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if loc, ok := c.mounted[diffID]; ok && loc.Digest == digest {
+		c.mountedLayers++
+		c.mountedBytes += loc.Size
+		delete(c.mounted, diffID)
+	}
+}
+
+// mount makes the blob at loc available in repo, returning true if the blob is now present without being uploaded.
+func (c *RemoteCachingImage) mount(repo name.Repository, loc BlobLocation) (bool, error) {
+	scopes := []string{repo.Scope(transport.PushScope)}
+	if loc.Repository != repo.RepositoryStr() {
+		from, err := name.NewRepository(repo.RegistryStr()+"/"+loc.Repository, name.WeakValidation)
+		if err != nil {
+			return false, err
+		}
+		scopes = append(scopes, from.Scope(transport.PullScope))
+	}
+	auth, err := c.keychain.Resolve(repo)
+	if err != nil {
+		return false, err
+	}
+	rt, err := transport.NewWithContext(context.Background(), repo.Registry, auth, http.DefaultTransport, scopes)
+	if err != nil {
+		return false, err
+	}
+	client := &http.Client{Transport: rt}
+	base := url.URL{Scheme: repo.Registry.Scheme(), Host: repo.RegistryStr()}
+
+	// the blob may already be in the repository
+	head := base
+	head.Path = fmt.Sprintf("/v2/%s/blobs/%s", repo.RepositoryStr(), loc.Digest)
+	resp, err := client.Head(head.String())
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return true, nil
+	}
+	if loc.Repository == repo.RepositoryStr() {
+		return false, nil
+	}
+
+	post := base
+	post.Path = fmt.Sprintf("/v2/%s/blobs/uploads/", repo.RepositoryStr())
+	post.RawQuery = url.Values{"mount": {loc.Digest}, "from": {loc.Repository}}.Encode()
+	resp, err = client.Post(post.String(), "application/json", nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return true, nil
+	case http.StatusAccepted:
+		// the registry started an upload instead of mounting, abandon it
+		if location, err := resp.Location(); err == nil {
+			if req, err := http.NewRequest(http.MethodDelete, location.String(), nil); err == nil {
+				if deleteResp, err := client.Do(req); err == nil {
+					deleteResp.Body.Close()
+				}
+			}
+		}
+		return false, nil
+	default:
+		return false, transport.CheckError(resp, http.StatusCreated, http.StatusAccepted)
+	}
+}
@@ -0,0 +1,153 @@
+package cache_test
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/imgutil/fakes"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/tarball"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/cache"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestRemoteCachingImage(t *testing.T) {
+	spec.Run(t, "RemoteCachingImage", testRemoteCachingImage, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testRemoteCachingImage(t *testing.T, when spec.G, it spec.S) {
+	var (
+		subject   *cache.RemoteCachingImage
+		fakeImage *fakes.Image
+		index     *cache.BlobIndex
+		server    *httptest.Server
+		host      string
+		tmpDir    string
+		layerPath string
+		layerSHA  string
+	)
+
+	it.Before(func() {
+		var err error
+		server = httptest.NewServer(registry.New())
+		serverURL, err := url.Parse(server.URL)
+		h.AssertNil(t, err)
+		host = serverURL.Host
+
+		tmpDir, err = ioutil.TempDir("", "lifecycle.cache.remote-caching-image")
+		h.AssertNil(t, err)
+		index, err = cache.NewBlobIndex(filepath.Join(tmpDir, "blob-index.json"))
+		h.AssertNil(t, err)
+		layerPath, layerSHA, _ = h.RandomLayer(t, tmpDir)
+
+		fakeImage = fakes.NewImage(host+"/some/target:latest", "", nil)
+		subject = cache.NewRemoteCachingImage(fakeImage, index, authn.DefaultKeychain)
+	})
+
+	it.After(func() {
+		server.Close()
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	// saveWithLayer pushes an image with the layer as the target, as imgutil would, and saves the subject
+	saveWithLayer := func(layer v1.Layer) {
+		img, err := mutate.AppendLayers(empty.Image, layer)
+		h.AssertNil(t, err)
+		target, err := name.NewTag(host+"/some/target:latest", name.WeakValidation)
+		h.AssertNil(t, err)
+		h.AssertNil(t, remote.Write(target, img))
+		h.AssertNil(t, subject.Save())
+	}
+
+	when("#AddLayerWithDiffID", func() {
+		when("the layer was pushed to another repository", func() {
+			it("mounts the blob and counts the bytes", func() {
+				layer, err := tarball.LayerFromFile(layerPath)
+				h.AssertNil(t, err)
+				source, err := name.NewRepository(host+"/some/source", name.WeakValidation)
+				h.AssertNil(t, err)
+				h.AssertNil(t, remote.WriteLayer(source, layer))
+				digest, err := layer.Digest()
+				h.AssertNil(t, err)
+				size, err := layer.Size()
+				h.AssertNil(t, err)
+				index.Add(layerSHA, cache.BlobLocation{Registry: host, Repository: "some/source", Digest: digest.String(), Size: size})
+
+				h.AssertNil(t, subject.AddLayerWithDiffID(layerPath, layerSHA))
+				saveWithLayer(layer)
+
+				layers, bytes := subject.MountStats()
+				h.AssertEq(t, layers, int64(1))
+				h.AssertEq(t, bytes, size)
+				target, err := name.NewDigest(host+"/some/target@"+digest.String(), name.WeakValidation)
+				h.AssertNil(t, err)
+				_, err = remote.Layer(target)
+				h.AssertNil(t, err)
+				_, err = fakeImage.GetLayer(layerSHA)
+				h.AssertNil(t, err)
+			})
+		})
+
+		when("the indexed blob is not the blob the image pushes", func() {
+			it("does not count the mount", func() {
+				other, err := random.Layer(100, "application/vnd.docker.image.rootfs.diff.tar.gzip")
+				h.AssertNil(t, err)
+				source, err := name.NewRepository(host+"/some/source", name.WeakValidation)
+				h.AssertNil(t, err)
+				h.AssertNil(t, remote.WriteLayer(source, other))
+				digest, err := other.Digest()
+				h.AssertNil(t, err)
+				size, err := other.Size()
+				h.AssertNil(t, err)
+				index.Add(layerSHA, cache.BlobLocation{Registry: host, Repository: "some/source", Digest: digest.String(), Size: size})
+
+				h.AssertNil(t, subject.AddLayerWithDiffID(layerPath, layerSHA))
+				layer, err := tarball.LayerFromFile(layerPath)
+				h.AssertNil(t, err)
+				saveWithLayer(layer)
+
+				layers, bytes := subject.MountStats()
+				h.AssertEq(t, layers, int64(0))
+				h.AssertEq(t, bytes, int64(0))
+				_, err = fakeImage.GetLayer(layerSHA)
+				h.AssertNil(t, err)
+			})
+		})
+
+		when("the indexed blob no longer exists", func() {
+			it("adds the layer without mounting", func() {
+				index.Add(layerSHA, cache.BlobLocation{Registry: host, Repository: "some/source", Digest: "sha256:" + h.RandString(64), Size: 10})
+
+				h.AssertNil(t, subject.AddLayerWithDiffID(layerPath, layerSHA))
+
+				layers, _ := subject.MountStats()
+				h.AssertEq(t, layers, int64(0))
+				_, err := fakeImage.GetLayer(layerSHA)
+				h.AssertNil(t, err)
+			})
+		})
+
+		when("the layer is not in the index", func() {
+			it("adds the layer without mounting", func() {
+				h.AssertNil(t, subject.AddLayerWithDiffID(layerPath, layerSHA))
+
+				layers, _ := subject.MountStats()
+				h.AssertEq(t, layers, int64(0))
+			})
+		})
+	})
+}
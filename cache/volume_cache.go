@@ -15,6 +15,7 @@ import (
 
 type VolumeCache struct {
 	committed    bool
+	readOnly     bool
 	dir          string
 	backupDir    string
 	stagingDir   string
@@ -48,6 +49,19 @@ func NewVolumeCache(dir string) (*VolumeCache, error) {
 	return c, nil
 }
 
+// NewReadOnlyVolumeCache returns a cache that can be read from, but never modified, e.g. a seed cache.
+// Unlike NewVolumeCache, it does not write to dir.
+func NewReadOnlyVolumeCache(dir string) (*VolumeCache, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+	return &VolumeCache{
+		readOnly:     true,
+		dir:          dir,
+		committedDir: filepath.Join(dir, "committed"),
+	}, nil
+}
+
 func (c *VolumeCache) Exists() bool {
 	if _, err := os.Stat(c.committedDir); err != nil {
 		return false
@@ -60,8 +74,8 @@ func (c *VolumeCache) Name() string {
 }
 
 func (c *VolumeCache) SetMetadata(metadata platform.CacheMetadata) error {
-	if c.committed {
-		return errCacheCommitted
+	if err := c.checkWritable(); err != nil {
+		return err
 	}
 	metadataPath := filepath.Join(c.stagingDir, MetadataLabel)
 	file, err := os.Create(metadataPath)
@@ -96,8 +110,8 @@ func (c *VolumeCache) RetrieveMetadata() (platform.CacheMetadata, error) {
 }
 
 func (c *VolumeCache) AddLayerFile(tarPath string, diffID string) error {
-	if c.committed {
-		return errCacheCommitted
+	if err := c.checkWritable(); err != nil {
+		return err
 	}
 	layerTar := diffIDPath(c.stagingDir, diffID)
 	if _, err := os.Stat(layerTar); err == nil {
@@ -112,8 +126,8 @@ func (c *VolumeCache) AddLayerFile(tarPath string, diffID string) error {
 }
 
 func (c *VolumeCache) AddLayer(rc io.ReadCloser, diffID string) error {
-	if c.committed {
-		return errCacheCommitted
+	if err := c.checkWritable(); err != nil {
+		return err
 	}
 
 	fh, err := os.Create(diffIDPath(c.stagingDir, diffID))
@@ -129,8 +143,8 @@ func (c *VolumeCache) AddLayer(rc io.ReadCloser, diffID string) error {
 }
 
 func (c *VolumeCache) ReuseLayer(diffID string) error {
-	if c.committed {
-		return errCacheCommitted
+	if err := c.checkWritable(); err != nil {
+		return err
 	}
 	if err := os.Link(diffIDPath(c.committedDir, diffID), diffIDPath(c.stagingDir, diffID)); err != nil && !os.IsExist(err) {
 		return errors.Wrapf(err, "reusing layer (%s)", diffID)
@@ -160,6 +174,18 @@ func (c *VolumeCache) HasLayer(diffID string) (bool, error) {
 	return true, nil
 }
 
+func (c *VolumeCache) LayerSize(diffID string) (int64, error) {
+	path, err := c.RetrieveLayerFile(diffID)
+	if err != nil {
+		return 0, err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return 0, errors.Wrapf(err, "inspecting layer with SHA '%s'", diffID)
+	}
+	return fi.Size(), nil
+}
+
 func (c *VolumeCache) RetrieveLayerFile(diffID string) (string, error) {
 	path := diffIDPath(c.committedDir, diffID)
 	if _, err := os.Stat(path); err != nil {
@@ -172,8 +198,8 @@ func (c *VolumeCache) RetrieveLayerFile(diffID string) (string, error) {
 }
 
 func (c *VolumeCache) Commit() error {
-	if c.committed {
-		return errCacheCommitted
+	if err := c.checkWritable(); err != nil {
+		return err
 	}
 	c.committed = true
 	if err := os.Rename(c.committedDir, c.backupDir); err != nil {
@@ -191,6 +217,16 @@ func (c *VolumeCache) Commit() error {
 	return nil
 }
 
+func (c *VolumeCache) checkWritable() error {
+	if c.readOnly {
+		return errCacheReadOnly
+	}
+	if c.committed {
+		return errCacheCommitted
+	}
+	return nil
+}
+
 func diffIDPath(basePath, diffID string) string {
 	if runtime.GOOS == "windows" {
 		// Avoid colons in Windows file paths
@@ -13,6 +13,8 @@ import (
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/memory"
+	"github.com/buildpacks/imgutil/fakes"
+	"github.com/buildpacks/imgutil/local"
 	"github.com/golang/mock/gomock"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -22,6 +24,7 @@ import (
 	"github.com/buildpacks/lifecycle/buildpack"
 	"github.com/buildpacks/lifecycle/cache"
 	"github.com/buildpacks/lifecycle/layers"
+	"github.com/buildpacks/lifecycle/platform"
 	h "github.com/buildpacks/lifecycle/testhelpers"
 	"github.com/buildpacks/lifecycle/testmock"
 )
@@ -346,6 +349,173 @@ func testCache(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 	})
+
+	when("#Prune", func() {
+		var (
+			pruner    *lifecycle.CachePruner
+			testCache lifecycle.Cache
+			tmpDir    string
+		)
+
+		it.Before(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "lifecycle.pruner.layer")
+			h.AssertNil(t, err)
+			testCache = exportTestCache(t, tmpDir)
+			logger := &log.Logger{Handler: memory.New(), Level: log.InfoLevel}
+			pruner = &lifecycle.CachePruner{Logger: logger}
+		})
+
+		it.After(func() {
+			h.AssertNil(t, os.RemoveAll(tmpDir))
+		})
+
+		it("removes the selected layers from the metadata and the cache", func() {
+			pruner.Selectors = []platform.CacheLayerSelector{{Buildpack: "other.buildpack.id", Layer: "*"}}
+			h.AssertNil(t, pruner.Prune(testCache))
+
+			metadata, err := testCache.RetrieveMetadata()
+			h.AssertNil(t, err)
+			h.AssertEq(t, len(metadata.MetadataForBuildpack("other.buildpack.id").Layers), 0)
+			_, ok := metadata.MetadataForBuildpack("buildpack.id").Layers["cache-true-layer"]
+			h.AssertEq(t, ok, true)
+
+			assertCacheHasLayer(t, testCache, "buildpack.id:cache-true-layer")
+			_, err = testCache.RetrieveLayer(testLayerDigest("other.buildpack.id:other-buildpack-layer"))
+			h.AssertNotNil(t, err)
+		})
+
+		it("leaves the cache untouched when nothing matches", func() {
+			pruner.Selectors = []platform.CacheLayerSelector{{Buildpack: "unknown.buildpack.id", Layer: "*"}}
+			h.AssertNil(t, pruner.Prune(testCache))
+
+			assertCacheHasLayer(t, testCache, "buildpack.id:cache-true-layer")
+			assertCacheHasLayer(t, testCache, "other.buildpack.id:other-buildpack-layer")
+		})
+
+		when("the cache is an image cache", func() {
+			var (
+				origImage, newImage *fakes.Image
+				imageCache          *cache.ImageCache
+				keptSHA, prunedSHA  string
+			)
+
+			it.Before(func() {
+				var keptPath, prunedPath string
+				keptPath, keptSHA, _ = h.RandomLayer(t, tmpDir)
+				prunedPath, prunedSHA, _ = h.RandomLayer(t, tmpDir)
+
+				origImage = fakes.NewImage("cache-image", "", local.IDIdentifier{ImageID: "orig-cache-image"})
+				h.AssertNil(t, origImage.AddLayerWithDiffID(keptPath, keptSHA))
+				h.AssertNil(t, origImage.AddLayerWithDiffID(prunedPath, prunedSHA))
+				h.AssertNil(t, origImage.SetLabel(cache.MetadataLabel, fmt.Sprintf(`{"buildpacks": [
+  {"key": "paketo-buildpacks/node", "layers": {"node-modules": {"sha": "%s", "cache": true}}},
+  {"key": "paketo-buildpacks/npm", "layers": {"npm-cache": {"sha": "%s", "cache": true}}}
+]}`, keptSHA, prunedSHA)))
+
+				newImage = fakes.NewImage("cache-image", "", local.IDIdentifier{ImageID: "new-cache-image"})
+				newImage.AddPreviousLayer(keptSHA, keptPath)
+				newImage.AddPreviousLayer(prunedSHA, prunedPath)
+				imageCache = cache.NewImageCache(origImage, newImage)
+			})
+
+			it.After(func() {
+				h.AssertNil(t, origImage.Cleanup())
+				h.AssertNil(t, newImage.Cleanup())
+			})
+
+			it("commits a cache image without the orphaned layers", func() {
+				pruner.Selectors = []platform.CacheLayerSelector{{Buildpack: "*/npm", Layer: "*"}}
+				h.AssertNil(t, pruner.Prune(imageCache))
+
+				h.AssertEq(t, newImage.IsSaved(), true)
+				h.AssertEq(t, newImage.ReusedLayers(), []string{keptSHA})
+
+				metadata, err := imageCache.RetrieveMetadata()
+				h.AssertNil(t, err)
+				h.AssertEq(t, len(metadata.MetadataForBuildpack("paketo-buildpacks/node").Layers), 1)
+				h.AssertEq(t, len(metadata.MetadataForBuildpack("paketo-buildpacks/npm").Layers), 0)
+				_, err = imageCache.RetrieveLayer(prunedSHA)
+				h.AssertNotNil(t, err)
+			})
+		})
+	})
+
+	when("#InspectCache", func() {
+		var (
+			testCache lifecycle.Cache
+			tmpDir    string
+		)
+
+		it.Before(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "lifecycle.inspector.layer")
+			h.AssertNil(t, err)
+			testCache = exportTestCache(t, tmpDir)
+		})
+
+		it.After(func() {
+			h.AssertNil(t, os.RemoveAll(tmpDir))
+		})
+
+		it("reports the cached layers of each buildpack", func() {
+			report, err := lifecycle.InspectCache(testCache)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, report.Name, testCache.Name())
+			h.AssertEq(t, len(report.Buildpacks), 2)
+			h.AssertEq(t, report.Buildpacks[0].ID, "buildpack.id")
+			h.AssertEq(t, len(report.Buildpacks[0].Layers), 2)
+
+			layerReport := report.Buildpacks[0].Layers[0]
+			h.AssertEq(t, layerReport.Name, "cache-true-layer")
+			h.AssertEq(t, layerReport.SHA, testLayerDigest("buildpack.id:cache-true-layer"))
+			h.AssertEq(t, layerReport.Size, int64(len(testLayerContents("buildpack.id:cache-true-layer"))))
+			h.AssertStringContains(t, layerReport.Metadata, "cache-true-key = \"cache-true-val\"")
+			h.AssertStringContains(t, layerReport.Metadata, "launch = true")
+		})
+
+		it("reports the cached SBOM layer", func() {
+			report, err := lifecycle.InspectCache(testCache)
+			h.AssertNil(t, err)
+
+			h.AssertNotNil(t, report.SBOM)
+			h.AssertEq(t, report.SBOM.SHA, testLayerDigest("cache.sbom"))
+		})
+	})
+}
+
+func exportTestCache(t *testing.T, tmpDir string) lifecycle.Cache {
+	t.Helper()
+
+	mockCtrl := gomock.NewController(t)
+	layerFactory := testmock.NewMockLayerFactory(mockCtrl)
+	layerFactory.EXPECT().
+		DirLayer(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(id string, dir string) (layers.Layer, error) {
+			return createTestLayer(id, tmpDir)
+		}).AnyTimes()
+
+	h.AssertNil(t, os.Mkdir(filepath.Join(tmpDir, "artifacts"), 0777))
+	cacheDir := filepath.Join(tmpDir, "cache")
+	h.AssertNil(t, os.Mkdir(cacheDir, 0777))
+
+	exporter := &lifecycle.Exporter{
+		PlatformAPI: api.Platform.Latest(),
+		Buildpacks: []buildpack.GroupBuildpack{
+			{ID: "buildpack.id", API: api.Buildpack.Latest().String()},
+			{ID: "other.buildpack.id", API: api.Buildpack.Latest().String()},
+		},
+		Logger:       &log.Logger{Handler: memory.New(), Level: log.InfoLevel},
+		LayerFactory: layerFactory,
+	}
+	previousCache, err := cache.NewVolumeCache(cacheDir)
+	h.AssertNil(t, err)
+	h.AssertNil(t, exporter.Cache(filepath.Join("testdata", "cacher", "layers"), previousCache))
+
+	testCache, err := cache.NewVolumeCache(cacheDir)
+	h.AssertNil(t, err)
+	return testCache
 }
 
 func assertCacheHasLayer(t *testing.T, cache lifecycle.Cache, id string) {
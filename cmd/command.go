@@ -22,6 +22,19 @@ type Command interface {
 }
 
 func Run(c Command, asSubcommand bool) {
+	if asSubcommand {
+		run(c, os.Args[2:])
+	} else {
+		run(c, os.Args[1:])
+	}
+}
+
+// RunNested runs a command nested under a subcommand, e.g. `lifecycle cache prune`.
+func RunNested(c Command) {
+	run(c, os.Args[3:])
+}
+
+func run(c Command, args []string) {
 	var (
 		printVersion bool
 		logLevel     string
@@ -33,16 +46,9 @@ func Run(c Command, asSubcommand bool) {
 	FlagLogLevel(&logLevel)
 	FlagNoColor(&noColor)
 	c.DefineFlags()
-	if asSubcommand {
-		if err := flagSet.Parse(os.Args[2:]); err != nil {
-			// flagSet exits on error, we shouldn't get here
-			Exit(err)
-		}
-	} else {
-		if err := flagSet.Parse(os.Args[1:]); err != nil {
-			// flagSet exits on error, we shouldn't get here
-			Exit(err)
-		}
+	if err := flagSet.Parse(args); err != nil {
+		// flagSet exits on error, we shouldn't get here
+		Exit(err)
 	}
 	DisableColor(noColor)
 
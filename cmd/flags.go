@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/buildpacks/lifecycle/api"
 )
@@ -20,6 +21,8 @@ var (
 	DefaultPlatformAPI     = "0.3"
 	DefaultPlatformDir     = filepath.Join(rootDir, "platform")
 	DefaultProcessType     = "web"
+	DefaultRestoreProgress = 10 * time.Second
+	DefaultSecretsPolicy   = "fail"
 	DefaultStackPath       = filepath.Join(rootDir, "cnb", "stack.toml")
 
 	DefaultAnalyzedFile        = "analyzed.toml"
@@ -41,24 +44,52 @@ const (
 	EnvAnalyzedPath        = "CNB_ANALYZED_PATH"
 	EnvAppDir              = "CNB_APP_DIR"
 	EnvBuildpacksDir       = "CNB_BUILDPACKS_DIR"
+	EnvBuildEnvHistory     = "CNB_BUILD_ENV_HISTORY_PATH"
 	EnvCacheDir            = "CNB_CACHE_DIR"
 	EnvCacheImage          = "CNB_CACHE_IMAGE"
+	EnvCacheImageMaxLayers = "CNB_CACHE_IMAGE_MAX_LAYERS" // defaults to 0 (no limit)
 	EnvDeprecationMode     = "CNB_DEPRECATION_MODE"
+	EnvExecDParallel       = "CNB_EXEC_D_PARALLEL" // defaults to false
+	EnvExecDTimeout        = "CNB_EXEC_D_TIMEOUT"  // defaults to 0 (no timeout)
+	EnvExitPolicy          = "CNB_EXIT_POLICY"     // defaults to any
 	EnvGID                 = "CNB_GROUP_ID"
 	EnvGroupPath           = "CNB_GROUP_PATH"
 	EnvLaunchCacheDir      = "CNB_LAUNCH_CACHE_DIR"
+	EnvLauncherDryRun      = "CNB_LAUNCHER_DRY_RUN" // defaults to false
+	EnvLauncherEnvHistory  = "CNB_LAUNCHER_ENV_HISTORY_PATH"
+	EnvLauncherInit        = "CNB_LAUNCHER_INIT"               // defaults to false
+	EnvLauncherProfiles    = "CNB_LAUNCHER_INTERPRET_PROFILES" // defaults to false
 	EnvLayersDir           = "CNB_LAYERS_DIR"
 	EnvLogLevel            = "CNB_LOG_LEVEL"
-	EnvNoColor             = "CNB_NO_COLOR" // defaults to false
+	EnvMaxRestarts         = "CNB_MAX_RESTARTS" // defaults to 0 (no limit)
+	EnvNoColor             = "CNB_NO_COLOR"     // defaults to false
 	EnvOrderPath           = "CNB_ORDER_PATH"
 	EnvPlanPath            = "CNB_PLAN_PATH"
 	EnvPlatformAPI         = "CNB_PLATFORM_API"
 	EnvPlatformDir         = "CNB_PLATFORM_DIR"
 	EnvPreviousImage       = "CNB_PREVIOUS_IMAGE"
+	EnvProcessOverrides    = "CNB_PROCESS_OVERRIDES_PATH"
 	EnvProcessType         = "CNB_PROCESS_TYPE"
+	EnvProcesses           = "CNB_PROCESSES" // comma separated process types to supervise
 	EnvProjectMetadataPath = "CNB_PROJECT_METADATA_PATH"
+	EnvRebaseBatch         = "CNB_REBASE_BATCH"   // defaults to false
+	EnvRebaseDryRun        = "CNB_REBASE_DRY_RUN" // defaults to false
+	EnvRebaseForce         = "CNB_REBASE_FORCE"   // defaults to false
+	EnvRebaseImagesPath    = "CNB_REBASE_IMAGES_PATH"
+	EnvRebaseParallelism   = "CNB_REBASE_PARALLELISM" // defaults to the number of CPUs
+	EnvRebaseRollback      = "CNB_REBASE_ROLLBACK"    // defaults to false
 	EnvReportPath          = "CNB_REPORT_PATH"
+	EnvRestartPolicy       = "CNB_RESTART_POLICY" // defaults to never
+	EnvRestoreEventsPath   = "CNB_RESTORE_EVENTS_PATH"
+	EnvRestoreParallelism  = "CNB_RESTORE_PARALLELISM"       // defaults to the number of CPUs
+	EnvRestoreProgress     = "CNB_RESTORE_PROGRESS_INTERVAL" // defaults to 10s
+	EnvRunExtensionsPath   = "CNB_RUN_EXTENSIONS_PATH"
 	EnvRunImage            = "CNB_RUN_IMAGE"
+	EnvSecretsDir          = "CNB_BUILD_SECRETS_DIR"
+	EnvSecretsPolicy       = "CNB_BUILD_SECRETS_POLICY" // defaults to fail
+	EnvSeedCacheArchive    = "CNB_SEED_CACHE_ARCHIVE"
+	EnvSeedCacheDir        = "CNB_SEED_CACHE_DIR"
+	EnvSeedCacheImage      = "CNB_SEED_CACHE_IMAGE"
 	EnvSkipLayers          = "CNB_ANALYZE_SKIP_LAYERS" // defaults to false
 	EnvSkipRestore         = "CNB_SKIP_RESTORE"        // defaults to false
 	EnvStackPath           = "CNB_STACK_PATH"
@@ -80,6 +111,10 @@ func FlagAppDir(appDir *string) {
 	flagSet.StringVar(appDir, "app", EnvOrDefault(EnvAppDir, DefaultAppDir), "path to app directory")
 }
 
+func FlagBuildEnvHistoryPath(envHistoryPath *string) {
+	flagSet.StringVar(envHistoryPath, "env-history", os.Getenv(EnvBuildEnvHistory), "path to write the history of build env changes to")
+}
+
 func FlagBuildpacksDir(buildpacksDir *string) {
 	flagSet.StringVar(buildpacksDir, "buildpacks", EnvOrDefault(EnvBuildpacksDir, DefaultBuildpacksDir), "path to buildpacks directory")
 }
@@ -92,6 +127,14 @@ func FlagCacheImage(cacheImage *string) {
 	flagSet.StringVar(cacheImage, "cache-image", os.Getenv(EnvCacheImage), "cache image tag name")
 }
 
+func FlagCacheImageMaxLayers(maxLayers *int) {
+	flagSet.IntVar(maxLayers, "cache-image-max-layers", intEnv(EnvCacheImageMaxLayers), "maximum number of layers in the cache image, the smallest layers over the limit are evicted from the cache")
+}
+
+func FlagInvalidateCache(selectors *StringSlice) {
+	flagSet.Var(selectors, "invalidate-cache", "cached layers to invalidate, as <buildpack-id>[:<layer-name>] (glob patterns allowed)")
+}
+
 func FlagGID(gid *int) {
 	flagSet.IntVar(gid, "gid", intEnv(EnvGID), "GID of user's group in the stack's build and run images")
 }
@@ -120,6 +163,10 @@ func FlagNoColor(skip *bool) {
 	flagSet.BoolVar(skip, "no-color", BoolEnv(EnvNoColor), "disable color output")
 }
 
+func FlagOutputFormat(format *string) {
+	flagSet.StringVar(format, "format", "table", "output format (table or json)")
+}
+
 func FlagOrderPath(orderPath *string) {
 	flagSet.StringVar(orderPath, "order", EnvOrDefault(EnvOrderPath, PlaceholderOrderPath), "path to order.toml")
 }
@@ -156,6 +203,30 @@ func FlagPreviousImage(image *string) {
 	flagSet.StringVar(image, "previous-image", os.Getenv(EnvPreviousImage), "reference to previous image")
 }
 
+func FlagRebaseBatch(batch *bool) {
+	flagSet.BoolVar(batch, "batch", BoolEnv(EnvRebaseBatch), "rebase each image argument as a separate app image")
+}
+
+func FlagRebaseDryRun(dryRun *bool) {
+	flagSet.BoolVar(dryRun, "dry-run", BoolEnv(EnvRebaseDryRun), "validate the rebase and report the changes without saving the image")
+}
+
+func FlagRebaseForce(force *bool) {
+	flagSet.BoolVar(force, "force", BoolEnv(EnvRebaseForce), "rebase despite OS, architecture and distro mismatches with the run image")
+}
+
+func FlagRebaseImagesPath(imagesPath *string) {
+	flagSet.StringVar(imagesPath, "images", os.Getenv(EnvRebaseImagesPath), "path to a file listing app images to rebase, one per line")
+}
+
+func FlagRebaseParallelism(parallelism *int) {
+	flagSet.IntVar(parallelism, "rebase-parallelism", intEnv(EnvRebaseParallelism), "maximum number of images rebased concurrently")
+}
+
+func FlagRebaseRollback(rollback *bool) {
+	flagSet.BoolVar(rollback, "rollback", BoolEnv(EnvRebaseRollback), "rebase onto the previous run image in the rebase history of the image")
+}
+
 func FlagReportPath(reportPath *string) {
 	flagSet.StringVar(reportPath, "report", EnvOrDefault(EnvReportPath, PlaceholderReportPath), "path to report.toml")
 }
@@ -164,10 +235,46 @@ func DefaultReportPath(platformAPI, layersDir string) string {
 	return defaultPath(DefaultReportFile, platformAPI, layersDir)
 }
 
+func FlagRestoreEventsPath(restoreEventsPath *string) {
+	flagSet.StringVar(restoreEventsPath, "restore-events", os.Getenv(EnvRestoreEventsPath), "path to write restore progress events to, as JSON lines")
+}
+
+func FlagRestoreParallelism(parallelism *int) {
+	flagSet.IntVar(parallelism, "restore-parallelism", intEnv(EnvRestoreParallelism), "maximum number of cached layers restored concurrently")
+}
+
+func FlagRestoreProgressInterval(interval *time.Duration) {
+	flagSet.DurationVar(interval, "restore-progress-interval", DurationEnv(EnvRestoreProgress, DefaultRestoreProgress), "how often to log restore progress for each layer")
+}
+
+func FlagRunExtensionsPath(runExtensionsPath *string) {
+	flagSet.StringVar(runExtensionsPath, "run-extensions", os.Getenv(EnvRunExtensionsPath), "path to a Dockerfile-like file of ADD, COPY, ENV and LABEL instructions extending the run image")
+}
+
 func FlagRunImage(runImage *string) {
 	flagSet.StringVar(runImage, "run-image", os.Getenv(EnvRunImage), "reference to run image")
 }
 
+func FlagSecretsDir(secretsDir *string) {
+	flagSet.StringVar(secretsDir, "secrets", os.Getenv(EnvSecretsDir), "path to build-time secrets directory")
+}
+
+func FlagSecretsPolicy(policy *string) {
+	flagSet.StringVar(policy, "secrets-policy", EnvOrDefault(EnvSecretsPolicy, DefaultSecretsPolicy), "action when a launch layer contains a secret: fail or warn")
+}
+
+func FlagSeedCacheArchive(seedCacheArchive *string) {
+	flagSet.StringVar(seedCacheArchive, "seed-cache-archive", os.Getenv(EnvSeedCacheArchive), "path to read-only seed cache archive")
+}
+
+func FlagSeedCacheDir(seedCacheDir *string) {
+	flagSet.StringVar(seedCacheDir, "seed-cache-dir", os.Getenv(EnvSeedCacheDir), "path to read-only seed cache directory")
+}
+
+func FlagSeedCacheImage(seedCacheImage *string) {
+	flagSet.StringVar(seedCacheImage, "seed-cache-image", os.Getenv(EnvSeedCacheImage), "read-only seed cache image tag name")
+}
+
 func FlagSkipLayers(skip *bool) {
 	flagSet.BoolVar(skip, "skip-layers", BoolEnv(EnvSkipLayers), "do not provide layer metadata to buildpacks")
 }
@@ -236,6 +343,15 @@ func intEnv(k string) int {
 	return d
 }
 
+func DurationEnv(k string, defaultVal time.Duration) time.Duration {
+	v := os.Getenv(k)
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return defaultVal
+	}
+	return d
+}
+
 func BoolEnv(k string) bool {
 	v := os.Getenv(k)
 	b, err := strconv.ParseBool(v)
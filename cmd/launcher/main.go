@@ -1,12 +1,18 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/heroku/color"
+	"github.com/pkg/errors"
 
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/cmd"
@@ -21,6 +27,9 @@ func main() {
 
 func runLaunch() error {
 	color.Disable(cmd.BoolEnv(cmd.EnvNoColor))
+	if err := cmd.SetLogLevel(cmd.EnvOrDefault(cmd.EnvLogLevel, cmd.DefaultLogLevel)); err != nil {
+		return err
+	}
 
 	platformAPI := cmd.EnvOrDefault(cmd.EnvPlatformAPI, cmd.DefaultPlatformAPI)
 	if err := cmd.VerifyPlatformAPI(platformAPI); err != nil {
@@ -39,6 +48,27 @@ func runLaunch() error {
 
 	defaultProcessType := defaultProcessType(p.API(), md)
 
+	overrides, err := launch.ReadProcessOverrides(os.Getenv(cmd.EnvProcessOverrides), os.Environ())
+	if err != nil {
+		return cmd.FailErrCode(err, p.CodeFor(platform.LaunchError), "read process overrides")
+	}
+
+	execFunc, shell := launch.OSExecFunc, launch.DefaultShell
+	if cmd.BoolEnv(cmd.EnvLauncherInit) {
+		// stay as PID 1 to forward signals and reap orphaned processes
+		execFunc, shell = launch.InitExecFunc, launch.InitShell
+	}
+
+	launchEnv := env.NewLaunchEnv(os.Environ(), launch.ProcessDir, launch.LifecycleDir)
+	logger := cmd.NewStderrLogger()
+	envHistoryPath := os.Getenv(cmd.EnvLauncherEnvHistory)
+	if envHistoryPath != "" || logger.DebugEnabled() {
+		launchEnv.History = env.History{}
+	}
+
+	execDRunner := launch.NewExecDRunner()
+	execDRunner.Timeout = cmd.DurationEnv(cmd.EnvExecDTimeout, 0)
+
 	launcher := &launch.Launcher{
 		DefaultProcessType: defaultProcessType,
 		LayersDir:          cmd.EnvOrDefault(cmd.EnvLayersDir, cmd.DefaultLayersDir),
@@ -46,13 +76,44 @@ func runLaunch() error {
 		PlatformAPI:        p.API(),
 		Processes:          md.Processes,
 		Buildpacks:         md.Buildpacks,
-		Env:                env.NewLaunchEnv(os.Environ(), launch.ProcessDir, launch.LifecycleDir),
-		Exec:               launch.OSExecFunc,
-		ExecD:              launch.NewExecDRunner(),
-		Shell:              launch.DefaultShell,
+		Env:                launchEnv,
+		EnvHistoryPath:     envHistoryPath,
+		Exec:               execFunc,
+		ExecD:              execDRunner,
+		ExecDParallel:      cmd.BoolEnv(cmd.EnvExecDParallel),
+		InterpretProfiles:  cmd.BoolEnv(cmd.EnvLauncherProfiles),
+		LogEnvHistory:      logger.DebugEnabled(),
+		Logger:             logger,
+		Overrides:          overrides,
+		Shell:              shell,
 		Setenv:             os.Setenv,
 	}
 
+	if procType, ok := healthCheckProcessType(); ok {
+		if err := launcher.CheckHealth(procType); err != nil {
+			return cmd.FailErrCode(err, p.CodeFor(platform.LaunchError), "check health")
+		}
+		return nil
+	}
+
+	if args, ok := dryRunArgs(); ok {
+		launchEnv.History = env.History{}
+		result, err := launcher.DryRun(args)
+		if err != nil {
+			return cmd.FailErrCode(err, p.CodeFor(platform.LaunchError), "dry run")
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			return cmd.FailErr(err, "write dry run result")
+		}
+		return nil
+	}
+
+	if procTypes, ok := supervisedProcessTypes(md); ok {
+		return supervise(launcher, md, procTypes, p)
+	}
+
 	if err := launcher.Launch(os.Args[0], os.Args[1:]); err != nil {
 		return cmd.FailErrCode(err, p.CodeFor(platform.LaunchError), "launch")
 	}
@@ -74,6 +135,110 @@ func defaultProcessType(platformAPI *api.Version, launchMD launch.Metadata) stri
 	return ""
 }
 
+// healthCheckProcessType returns the process type to check and true if the launcher was invoked through
+// the health check symlink of the process type, e.g. /cnb/health/web.
+func healthCheckProcessType() (string, bool) {
+	if filepath.Dir(os.Args[0]) != launch.HealthDir {
+		return "", false
+	}
+	return strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0])), true
+}
+
+// dryRunArgs returns the launcher arguments without the dry run flag and true if the launcher should only print
+// the resolved process, because it was invoked with --dry-run or CNB_LAUNCHER_DRY_RUN is set.
+func dryRunArgs() ([]string, bool) {
+	if len(os.Args) > 1 && os.Args[1] == launch.DryRunFlag {
+		return os.Args[2:], true
+	}
+	return os.Args[1:], cmd.BoolEnv(cmd.EnvLauncherDryRun)
+}
+
+// supervisedProcessTypes returns the process types to supervise and true if the launcher should run in supervisor mode,
+// either because CNB_PROCESSES is set or because the launcher was invoked as the supervisor entrypoint.
+func supervisedProcessTypes(launchMD launch.Metadata) ([]string, bool) {
+	if procs := os.Getenv(cmd.EnvProcesses); procs != "" {
+		return splitProcessTypes(procs), true
+	}
+	entrypoint := strings.TrimSuffix(filepath.Base(os.Args[0]), filepath.Ext(os.Args[0]))
+	if entrypoint != launch.SupervisorEntrypoint {
+		return nil, false
+	}
+	if _, ok := launchMD.FindProcessType(entrypoint); ok {
+		// a process type with the same name takes precedence
+		return nil, false
+	}
+	if len(os.Args) > 1 {
+		return splitProcessTypes(strings.Join(os.Args[1:], ",")), true
+	}
+	var procTypes []string
+	for _, proc := range launchMD.Processes {
+		procTypes = append(procTypes, proc.Type)
+	}
+	return procTypes, true
+}
+
+func splitProcessTypes(s string) []string {
+	var procTypes []string
+	for _, procType := range strings.Split(s, ",") {
+		if procType = strings.TrimSpace(procType); procType != "" {
+			procTypes = append(procTypes, procType)
+		}
+	}
+	return procTypes
+}
+
+func supervise(launcher *launch.Launcher, launchMD launch.Metadata, procTypes []string, p *platform.Platform) error {
+	for _, procType := range procTypes {
+		if _, ok := launchMD.FindProcessType(procType); !ok {
+			return cmd.FailErrCode(fmt.Errorf("process type %s was not found", procType), cmd.CodeInvalidArgs, "supervise")
+		}
+	}
+	restartPolicy, err := launch.ParseRestartPolicy(os.Getenv(cmd.EnvRestartPolicy))
+	if err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "supervise")
+	}
+	exitPolicy, err := launch.ParseExitPolicy(os.Getenv(cmd.EnvExitPolicy))
+	if err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "supervise")
+	}
+	maxRestarts, err := strconv.Atoi(cmd.EnvOrDefault(cmd.EnvMaxRestarts, "0"))
+	if err != nil {
+		return cmd.FailErrCode(errors.Wrapf(err, "parse %s", cmd.EnvMaxRestarts), cmd.CodeInvalidArgs, "supervise")
+	}
+	self, err := os.Executable()
+	if err != nil {
+		self = launch.LauncherPath
+	}
+
+	// children must launch their process type instead of supervising again
+	var childEnv []string
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, cmd.EnvProcesses+"=") {
+			childEnv = append(childEnv, kv)
+		}
+	}
+
+	supervisor := &launch.Supervisor{
+		Processes: procTypes,
+		Command: func(procType string) *exec.Cmd {
+			return launcher.ProcessCommand(self, procType, childEnv)
+		},
+		RestartPolicy: restartPolicy,
+		MaxRestarts:   maxRestarts,
+		RestartDelay:  time.Second,
+		ExitPolicy:    exitPolicy,
+		Stdout:        os.Stdout,
+		Stderr:        os.Stderr,
+	}
+	if err := supervisor.Run(); err != nil {
+		if exitErr, ok := err.(*launch.ProcessExitError); ok {
+			return cmd.FailErrCode(exitErr, exitErr.Code, "supervise")
+		}
+		return cmd.FailErrCode(err, p.CodeFor(platform.LaunchError), "supervise")
+	}
+	return nil
+}
+
 func verifyBuildpackAPIs(bps []launch.Buildpack) error {
 	for _, bp := range bps {
 		if bp.API == "" {
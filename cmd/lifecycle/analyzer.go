@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/buildpacks/imgutil"
 	"github.com/buildpacks/imgutil/local"
@@ -31,8 +32,11 @@ type analyzeCmd struct {
 	legacyCacheDir  string
 	legacyGroupPath string
 	outputImageRef  string
+	runImageMirrors []string // run image and its mirrors from stack.toml in priority order, when -run-image is not provided
 	stackPath       string
 	uid, gid        int
+
+	runImageMirrorSelection *platform.RunImageMirrorSelection
 }
 
 // analyzeArgs contains inputs needed when run by creator.
@@ -140,11 +144,15 @@ func parseRegistry(providedRef string) (string, error) {
 
 func (a *analyzeCmd) Privileges() error {
 	var err error
-	a.keychain, err = auth.DefaultKeychain(a.registryImages()...)
+	a.keychain, err = auth.DefaultKeychain(append(a.registryImages(), a.runImageMirrors...)...)
 	if err != nil {
 		return cmd.FailErr(err, "resolve keychain")
 	}
 
+	if !a.useDaemon && len(a.runImageMirrors) > 0 {
+		a.runImageRef, a.runImageMirrorSelection = selectRunImageMirror(a.runImageMirrors, a.keychain)
+	}
+
 	if a.useDaemon {
 		var err error
 		a.docker, err = priv.DockerClient()
@@ -153,8 +161,8 @@ func (a *analyzeCmd) Privileges() error {
 		}
 	}
 	if a.platformAPIVersionGreaterThan06() {
-		if err := image.VerifyRegistryAccess(a, a.keychain); err != nil {
-			return cmd.FailErr(err)
+		if err := verifyRegistryAccess(a, a.keychain, a.platform); err != nil {
+			return err
 		}
 	}
 	if err := priv.EnsureOwner(a.uid, a.gid, a.layersDir, a.legacyCacheDir); err != nil {
@@ -166,6 +174,18 @@ func (a *analyzeCmd) Privileges() error {
 	return nil
 }
 
+// verifyRegistryAccess fails fast when the keychain cannot read or write one of the registry images, reporting every image.
+func verifyRegistryAccess(regInputs image.RegistryInputs, keychain authn.Keychain, p Platform) error {
+	report := image.CheckRegistryAccess(regInputs, keychain)
+	for _, check := range report.Checks {
+		cmd.DefaultLogger.Debugf("Checked %s", check)
+	}
+	if err := report.Err(); err != nil {
+		return cmd.FailErrCode(err, p.CodeFor(platform.RegistryAccessError), "verify registry access")
+	}
+	return nil
+}
+
 func (a *analyzeCmd) registryImages() []string {
 	var registryImages []string
 	registryImages = append(registryImages, a.ReadableRegistryImages()...)
@@ -199,6 +219,7 @@ func (a *analyzeCmd) Exec() error {
 		return err
 	}
 
+	analyzedMD.RunImageMirrors = a.runImageMirrorSelection
 	if err := encoding.WriteTOML(a.analyzedPath, analyzedMD); err != nil {
 		return errors.Wrap(err, "write analyzed.toml")
 	}
@@ -292,14 +313,31 @@ func (a *analyzeCmd) populateRunImageIfNeeded() error {
 		return err
 	}
 
-	a.runImageRef, err = stackMD.BestRunImageMirror(targetRegistry)
+	a.runImageMirrors, err = stackMD.RunImageMirrorsByPriority(targetRegistry)
 	if err != nil {
 		return errors.New("-run-image is required when there is no stack metadata available")
 	}
+	a.runImageRef = a.runImageMirrors[0]
 
 	return nil
 }
 
+// selectRunImageMirror returns the first reachable run image mirror, given in priority order, and the selection to record in analyzed.toml.
+// When no mirror is reachable the first mirror, chosen by registry, is returned.
+func selectRunImageMirror(mirrors []string, keychain authn.Keychain) (string, *platform.RunImageMirrorSelection) {
+	selection, err := (&image.MirrorResolver{Keychain: keychain}).Resolve(mirrors)
+	if err != nil {
+		cmd.DefaultLogger.Warnf("Using run image '%s': %s", mirrors[0], err)
+		return mirrors[0], &selection
+	}
+	cmd.DefaultLogger.Debugf("Selected run image mirror '%s' with digest '%s'", selection.Selected, selection.Digest)
+	if len(selection.Inconsistent) > 0 {
+		cmd.DefaultLogger.Warnf("Run image mirrors %s do not serve digest '%s' of the selected mirror '%s'",
+			strings.Join(selection.Inconsistent, ", "), selection.Digest, selection.Selected)
+	}
+	return selection.Selected, &selection
+}
+
 func (a *analyzeCmd) ensurePreviousAndTargetHaveSameRegistry() error {
 	if a.previousImageRef == a.outputImageRef {
 		return nil
@@ -326,6 +364,13 @@ func (a *analyzeCmd) ReadableRegistryImages() []string {
 	return readableImages
 }
 
+func (a *analyzeCmd) PreviousRegistryImage() string {
+	if a.useDaemon {
+		return ""
+	}
+	return a.previousImageRef
+}
+
 func (a *analyzeCmd) WriteableRegistryImages() []string {
 	var writeableImages []string
 	writeableImages = appendNotEmpty(writeableImages, a.cacheImageRef)
@@ -23,10 +23,12 @@ type buildCmd struct {
 
 type buildArgs struct {
 	// inputs needed when run by creator
-	buildpacksDir string
-	layersDir     string
-	appDir        string
-	platformDir   string
+	buildpacksDir  string
+	layersDir      string
+	appDir         string
+	envHistoryPath string
+	platformDir    string
+	secretsDir     string
 
 	platform Platform
 }
@@ -34,11 +36,13 @@ type buildArgs struct {
 // DefineFlags defines the flags that are considered valid and reads their values (if provided).
 func (b *buildCmd) DefineFlags() {
 	cmd.FlagBuildpacksDir(&b.buildpacksDir)
+	cmd.FlagBuildEnvHistoryPath(&b.envHistoryPath)
 	cmd.FlagGroupPath(&b.groupPath)
 	cmd.FlagPlanPath(&b.planPath)
 	cmd.FlagLayersDir(&b.layersDir)
 	cmd.FlagAppDir(&b.appDir)
 	cmd.FlagPlatformDir(&b.platformDir)
+	cmd.FlagSecretsDir(&b.secretsDir)
 }
 
 // Args validates arguments and flags, and fills in default values.
@@ -83,6 +87,14 @@ func (ba buildArgs) build(group buildpack.Group, plan platform.BuildPlan) error
 		return cmd.FailErrCode(err, ba.platform.CodeFor(platform.BuildError), "build")
 	}
 
+	redactor, err := redactSecrets(ba.platformDir, ba.secretsDir)
+	if err != nil {
+		return err
+	}
+	out, errOut := redactor.Writer(cmd.Stdout), redactor.Writer(cmd.Stderr)
+	defer out.Flush()
+	defer errOut.Flush()
+
 	builder := &lifecycle.Builder{
 		AppDir:         ba.appDir,
 		LayersDir:      ba.layersDir,
@@ -90,10 +102,13 @@ func (ba buildArgs) build(group buildpack.Group, plan platform.BuildPlan) error
 		Platform:       ba.platform,
 		Group:          group,
 		Plan:           plan,
-		Out:            cmd.Stdout,
-		Err:            cmd.Stderr,
+		Out:            out,
+		Err:            errOut,
 		Logger:         cmd.DefaultLogger,
 		BuildpackStore: buildpackStore,
+		EnvHistoryPath: ba.envHistoryPath,
+		LogEnvHistory:  cmd.DefaultLogger.DebugEnabled(),
+		SecretsDir:     ba.secretsDir,
 	}
 	md, err := builder.Build()
 
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+
+	"github.com/buildpacks/lifecycle"
+	"github.com/buildpacks/lifecycle/auth"
+	"github.com/buildpacks/lifecycle/cache"
+	"github.com/buildpacks/lifecycle/cmd"
+	"github.com/buildpacks/lifecycle/platform"
+	"github.com/buildpacks/lifecycle/priv"
+)
+
+func cacheSubcommand(platform Platform) {
+	if len(os.Args) < 3 {
+		cmd.Exit(cmd.FailCode(cmd.CodeInvalidArgs, "parse arguments"))
+	}
+	switch os.Args[2] {
+	case "export":
+		cmd.RunNested(&cacheExportCmd{platform: platform})
+	case "inspect":
+		cmd.RunNested(&cacheInspectCmd{platform: platform})
+	case "prune":
+		cmd.RunNested(&cachePruneCmd{platform: platform})
+	default:
+		cmd.Exit(cmd.FailCode(cmd.CodeInvalidArgs, "unknown cache command:", os.Args[2]))
+	}
+}
+
+type cachePruneCmd struct {
+	// flags: inputs
+	cacheDir            string
+	cacheImageTag       string
+	cacheImageMaxLayers int
+	uid, gid            int
+
+	keychain  authn.Keychain
+	platform  Platform
+	selectors []platform.CacheLayerSelector
+}
+
+// DefineFlags defines the flags that are considered valid and reads their values (if provided).
+func (c *cachePruneCmd) DefineFlags() {
+	cmd.FlagCacheDir(&c.cacheDir)
+	cmd.FlagCacheImage(&c.cacheImageTag)
+	cmd.FlagCacheImageMaxLayers(&c.cacheImageMaxLayers)
+	cmd.FlagUID(&c.uid)
+	cmd.FlagGID(&c.gid)
+}
+
+// Args validates arguments and flags, and fills in default values.
+func (c *cachePruneCmd) Args(nargs int, args []string) error {
+	if nargs == 0 {
+		return cmd.FailErrCode(errors.New("at least one cache layer selector is required"), cmd.CodeInvalidArgs, "parse arguments")
+	}
+	if c.cacheImageTag == "" && c.cacheDir == "" {
+		return cmd.FailErrCode(errors.New("-cache-dir or -cache-image is required"), cmd.CodeInvalidArgs, "parse arguments")
+	}
+	var err error
+	if c.selectors, err = platform.ParseCacheLayerSelectors(args); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse cache layer selectors")
+	}
+	return nil
+}
+
+func (c *cachePruneCmd) Privileges() error {
+	var err error
+	c.keychain, err = auth.DefaultKeychain(c.registryImages()...)
+	if err != nil {
+		return cmd.FailErr(err, "resolve keychain")
+	}
+	if err := priv.EnsureOwner(c.uid, c.gid, c.cacheDir); err != nil {
+		return cmd.FailErr(err, "chown volumes")
+	}
+	if err := priv.RunAs(c.uid, c.gid); err != nil {
+		return cmd.FailErr(err, fmt.Sprintf("exec as user %d:%d", c.uid, c.gid))
+	}
+	return nil
+}
+
+func (c *cachePruneCmd) Exec() error {
+	cacheStore, err := initCache(c.cacheImageTag, c.cacheDir, c.keychain, cache.WithLayerEviction(c.cacheImageMaxLayers, cmd.DefaultLogger))
+	if err != nil {
+		return err
+	}
+	pruner := &lifecycle.CachePruner{
+		Logger:    cmd.DefaultLogger,
+		Selectors: c.selectors,
+	}
+	if err := pruner.Prune(cacheStore); err != nil {
+		return cmd.FailErr(err, "prune cache")
+	}
+	return nil
+}
+
+func (c *cachePruneCmd) registryImages() []string {
+	if c.cacheImageTag != "" {
+		return []string{c.cacheImageTag}
+	}
+	return []string{}
+}
+
+type cacheInspectCmd struct {
+	// flags: inputs
+	cacheDir      string
+	cacheImageTag string
+	format        string
+
+	keychain authn.Keychain
+	platform Platform
+}
+
+// DefineFlags defines the flags that are considered valid and reads their values (if provided).
+func (c *cacheInspectCmd) DefineFlags() {
+	cmd.FlagCacheDir(&c.cacheDir)
+	cmd.FlagCacheImage(&c.cacheImageTag)
+	cmd.FlagOutputFormat(&c.format)
+}
+
+// Args validates arguments and flags, and fills in default values.
+func (c *cacheInspectCmd) Args(nargs int, args []string) error {
+	if nargs > 0 {
+		return cmd.FailErrCode(errors.New("received unexpected Args"), cmd.CodeInvalidArgs, "parse arguments")
+	}
+	if c.cacheImageTag == "" && c.cacheDir == "" {
+		return cmd.FailErrCode(errors.New("-cache-dir or -cache-image is required"), cmd.CodeInvalidArgs, "parse arguments")
+	}
+	if c.format != "table" && c.format != "json" {
+		return cmd.FailErrCode(fmt.Errorf("unsupported format %q", c.format), cmd.CodeInvalidArgs, "parse arguments")
+	}
+	return nil
+}
+
+func (c *cacheInspectCmd) Privileges() error {
+	var err error
+	if c.cacheImageTag != "" {
+		c.keychain, err = auth.DefaultKeychain(c.cacheImageTag)
+		if err != nil {
+			return cmd.FailErr(err, "resolve keychain")
+		}
+	}
+	return nil
+}
+
+func (c *cacheInspectCmd) Exec() error {
+	cacheStore, err := initCache(c.cacheImageTag, c.cacheDir, c.keychain)
+	if err != nil {
+		return err
+	}
+	report, err := lifecycle.InspectCache(cacheStore)
+	if err != nil {
+		return cmd.FailErr(err, "inspect cache")
+	}
+	if c.format == "json" {
+		enc := json.NewEncoder(cmd.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return cmd.FailErr(err, "write cache report")
+		}
+		return nil
+	}
+	if err := writeCacheReportTable(cmd.Stdout, report); err != nil {
+		return cmd.FailErr(err, "write cache report")
+	}
+	return nil
+}
+
+func writeCacheReportTable(w io.Writer, report platform.CacheReport) error {
+	fmt.Fprintf(w, "Cache: %s\n\n", report.Name)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "BUILDPACK\tLAYER\tSHA\tSIZE")
+	for _, bp := range report.Buildpacks {
+		for _, l := range bp.Layers {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", bp.ID, l.Name, l.SHA, l.Size)
+		}
+	}
+	if report.SBOM != nil {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", "-", report.SBOM.Name, report.SBOM.SHA, report.SBOM.Size)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, bp := range report.Buildpacks {
+		for _, l := range bp.Layers {
+			fmt.Fprintf(w, "\n%s:%s.toml\n", bp.ID, l.Name)
+			for _, line := range strings.Split(strings.TrimRight(l.Metadata, "\n"), "\n") {
+				fmt.Fprintf(w, "  %s\n", line)
+			}
+		}
+	}
+	return nil
+}
+
+type cacheExportCmd struct {
+	// flags: inputs
+	cacheDir      string
+	cacheImageTag string
+
+	// args: outputs
+	archivePath string
+
+	keychain authn.Keychain
+	platform Platform
+}
+
+// DefineFlags defines the flags that are considered valid and reads their values (if provided).
+func (c *cacheExportCmd) DefineFlags() {
+	cmd.FlagCacheDir(&c.cacheDir)
+	cmd.FlagCacheImage(&c.cacheImageTag)
+}
+
+// Args validates arguments and flags, and fills in default values.
+func (c *cacheExportCmd) Args(nargs int, args []string) error {
+	if nargs != 1 {
+		return cmd.FailErrCode(fmt.Errorf("received %d arguments, but expected 1", nargs), cmd.CodeInvalidArgs, "parse arguments")
+	}
+	if c.cacheImageTag == "" && c.cacheDir == "" {
+		return cmd.FailErrCode(errors.New("-cache-dir or -cache-image is required"), cmd.CodeInvalidArgs, "parse arguments")
+	}
+	c.archivePath = args[0]
+	return nil
+}
+
+func (c *cacheExportCmd) Privileges() error {
+	var err error
+	if c.cacheImageTag != "" {
+		c.keychain, err = auth.DefaultKeychain(c.cacheImageTag)
+		if err != nil {
+			return cmd.FailErr(err, "resolve keychain")
+		}
+	}
+	return nil
+}
+
+func (c *cacheExportCmd) Exec() error {
+	var (
+		src cache.ArchiveSource
+		err error
+	)
+	if c.cacheImageTag != "" {
+		src, err = cache.NewReadOnlyImageCacheFromName(c.cacheImageTag, c.keychain)
+	} else {
+		src, err = cache.NewReadOnlyVolumeCache(c.cacheDir)
+	}
+	if err != nil {
+		return cmd.FailErr(err, "open cache")
+	}
+
+	f, err := os.Create(c.archivePath)
+	if err != nil {
+		return cmd.FailErr(err, "create cache archive")
+	}
+	defer f.Close()
+	if err := cache.WriteArchive(f, src); err != nil {
+		return cmd.FailErr(err, "export cache")
+	}
+	cmd.DefaultLogger.Infof("Exported cache to %s", c.archivePath)
+	return nil
+}
@@ -11,8 +11,10 @@ import (
 
 	"github.com/buildpacks/lifecycle/auth"
 	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/cache"
 	"github.com/buildpacks/lifecycle/cmd"
 	"github.com/buildpacks/lifecycle/image"
+	"github.com/buildpacks/lifecycle/internal/encoding"
 	"github.com/buildpacks/lifecycle/platform"
 	"github.com/buildpacks/lifecycle/priv"
 )
@@ -23,6 +25,8 @@ type createCmd struct {
 	buildpacksDir       string
 	cacheDir            string
 	cacheImageRef       string
+	cacheImageMaxLayers int
+	envHistoryPath      string
 	launchCacheDir      string
 	launcherPath        string
 	layersDir           string
@@ -33,27 +37,45 @@ type createCmd struct {
 	processType         string
 	projectMetadataPath string
 	reportPath          string
+	restoreEventsPath   string
+	restoreParallelism  int
+	restoreProgress     time.Duration
+	runExtensionsPath   string
+	runImageMirrors     []string // run image and its mirrors from stack.toml in priority order, when -run-image is not provided
 	runImageRef         string
+	secretsDir          string
+	secretsPolicy       string
+	seedCacheArchive    string
+	seedCacheDir        string
+	seedCacheImageRef   string
 	stackPath           string
 	targetRegistry      string
 	uid, gid            int
 	skipRestore         bool
 	useDaemon           bool
 
-	additionalTags cmd.StringSlice
-	docker         client.CommonAPIClient // construct if necessary before dropping privileges
-	keychain       authn.Keychain
-	platform       Platform
-	stackMD        platform.StackMetadata
+	additionalTags   cmd.StringSlice
+	invalidateCache  cmd.StringSlice
+	invalidateLayers []platform.CacheLayerSelector
+	docker           client.CommonAPIClient // construct if necessary before dropping privileges
+	keychain         authn.Keychain
+	platform         Platform
+	runExtensions    []platform.RunExtension
+	stackMD          platform.StackMetadata
+
+	runImageMirrorSelection *platform.RunImageMirrorSelection
 }
 
 // DefineFlags defines the flags that are considered valid and reads their values (if provided).
 func (c *createCmd) DefineFlags() {
 	cmd.FlagAppDir(&c.appDir)
+	cmd.FlagBuildEnvHistoryPath(&c.envHistoryPath)
 	cmd.FlagBuildpacksDir(&c.buildpacksDir)
 	cmd.FlagCacheDir(&c.cacheDir)
 	cmd.FlagCacheImage(&c.cacheImageRef)
+	cmd.FlagCacheImageMaxLayers(&c.cacheImageMaxLayers)
 	cmd.FlagGID(&c.gid)
+	cmd.FlagInvalidateCache(&c.invalidateCache)
 	cmd.FlagLaunchCacheDir(&c.launchCacheDir)
 	cmd.FlagLauncherPath(&c.launcherPath)
 	cmd.FlagLayersDir(&c.layersDir)
@@ -61,7 +83,16 @@ func (c *createCmd) DefineFlags() {
 	cmd.FlagPlatformDir(&c.platformDir)
 	cmd.FlagPreviousImage(&c.previousImageRef)
 	cmd.FlagReportPath(&c.reportPath)
+	cmd.FlagRestoreEventsPath(&c.restoreEventsPath)
+	cmd.FlagRestoreParallelism(&c.restoreParallelism)
+	cmd.FlagRestoreProgressInterval(&c.restoreProgress)
+	cmd.FlagRunExtensionsPath(&c.runExtensionsPath)
 	cmd.FlagRunImage(&c.runImageRef)
+	cmd.FlagSecretsDir(&c.secretsDir)
+	cmd.FlagSecretsPolicy(&c.secretsPolicy)
+	cmd.FlagSeedCacheArchive(&c.seedCacheArchive)
+	cmd.FlagSeedCacheDir(&c.seedCacheDir)
+	cmd.FlagSeedCacheImage(&c.seedCacheImageRef)
 	cmd.FlagSkipRestore(&c.skipRestore)
 	cmd.FlagStackPath(&c.stackPath)
 	cmd.FlagUID(&c.uid)
@@ -78,15 +109,19 @@ func (c *createCmd) Args(nargs int, args []string) error {
 	}
 
 	c.outputImageRef = args[0]
-	if c.launchCacheDir != "" && !c.useDaemon {
-		cmd.DefaultLogger.Warn("Ignoring -launch-cache, only intended for use with -daemon")
-		c.launchCacheDir = ""
-	}
 
 	if c.cacheImageRef == "" && c.cacheDir == "" {
 		cmd.DefaultLogger.Warn("Not restoring or caching layer data, no cache flag specified.")
 	}
 
+	if err := verifySeedCacheFlags(c.platform.API(), c.seedCacheImageRef, c.seedCacheDir, c.seedCacheArchive); err != nil {
+		return err
+	}
+
+	if err := validateSecretsPolicy(c.secretsPolicy); err != nil {
+		return err
+	}
+
 	if c.previousImageRef == "" {
 		c.previousImageRef = c.outputImageRef
 	}
@@ -108,11 +143,19 @@ func (c *createCmd) Args(nargs int, args []string) error {
 	}
 
 	var err error
+	if c.invalidateLayers, err = platform.ParseCacheLayerSelectors(c.invalidateCache); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse cache layer selectors")
+	}
+
 	c.stackMD, err = readStack(c.stackPath)
 	if err != nil {
 		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse stack metadata")
 	}
 
+	if c.runExtensions, err = readRunExtensions(c.runExtensionsPath); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse run extensions")
+	}
+
 	c.targetRegistry, err = parseRegistry(c.outputImageRef)
 	if err != nil {
 		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse target registry")
@@ -127,11 +170,15 @@ func (c *createCmd) Args(nargs int, args []string) error {
 
 func (c *createCmd) Privileges() error {
 	var err error
-	c.keychain, err = auth.DefaultKeychain(c.registryImages()...)
+	c.keychain, err = auth.DefaultKeychain(append(c.registryImages(), c.runImageMirrors...)...)
 	if err != nil {
 		return cmd.FailErr(err, "resolve keychain")
 	}
 
+	if !c.useDaemon && len(c.runImageMirrors) > 0 {
+		c.runImageRef, c.runImageMirrorSelection = selectRunImageMirror(c.runImageMirrors, c.keychain)
+	}
+
 	if c.useDaemon {
 		var err error
 		c.docker, err = priv.DockerClient()
@@ -140,8 +187,8 @@ func (c *createCmd) Privileges() error {
 		}
 	}
 	if c.platformAPIVersionGreaterThan06() {
-		if err := image.VerifyRegistryAccess(c, c.keychain); err != nil {
-			return cmd.FailErr(err)
+		if err := verifyRegistryAccess(c, c.keychain, c.platform); err != nil {
+			return err
 		}
 	}
 	if err := priv.EnsureOwner(c.uid, c.gid, c.cacheDir, c.launchCacheDir, c.layersDir); err != nil {
@@ -157,7 +204,7 @@ func (c *createCmd) Privileges() error {
 }
 
 func (c *createCmd) Exec() error {
-	cacheStore, err := initCache(c.cacheImageRef, c.cacheDir, c.keychain)
+	cacheStore, err := initCache(c.cacheImageRef, c.cacheDir, c.keychain, cache.WithLayerEviction(c.cacheImageMaxLayers, cmd.DefaultLogger))
 	if err != nil {
 		return err
 	}
@@ -227,13 +274,31 @@ func (c *createCmd) Exec() error {
 		}
 	}
 
+	if c.runImageMirrorSelection != nil {
+		analyzedMD.RunImageMirrors = c.runImageMirrorSelection
+		if err := encoding.WriteTOML(cmd.DefaultAnalyzedPath(c.platform.API().String(), c.layersDir), analyzedMD); err != nil {
+			return errors.Wrap(err, "write analyzed.toml")
+		}
+	}
+
 	if !c.skipRestore {
+		seedCache, cleanupSeedCache, err := initSeedCache(c.seedCacheImageRef, c.seedCacheDir, c.seedCacheArchive, c.keychain)
+		if err != nil {
+			return err
+		}
+		defer cleanupSeedCache()
+
 		cmd.DefaultLogger.Phase("RESTORING")
-		err := restoreArgs{
-			keychain:   c.keychain,
-			layersDir:  c.layersDir,
-			platform:   c.platform,
-			skipLayers: c.skipRestore,
+		err = restoreArgs{
+			eventsPath:       c.restoreEventsPath,
+			invalidateLayers: c.invalidateLayers,
+			keychain:         c.keychain,
+			layersDir:        c.layersDir,
+			parallelism:      c.restoreParallelism,
+			platform:         c.platform,
+			progressInterval: c.restoreProgress,
+			seedCache:        seedCache,
+			skipLayers:       c.skipRestore,
 		}.restore(analyzedMD.Metadata, group, cacheStore)
 		if err != nil {
 			return err
@@ -244,11 +309,13 @@ func (c *createCmd) Exec() error {
 	stopPinging := startPinging(c.docker)
 	cmd.DefaultLogger.Phase("BUILDING")
 	err = buildArgs{
-		buildpacksDir: c.buildpacksDir,
-		layersDir:     c.layersDir,
-		appDir:        c.appDir,
-		platform:      c.platform,
-		platformDir:   c.platformDir,
+		buildpacksDir:  c.buildpacksDir,
+		layersDir:      c.layersDir,
+		appDir:         c.appDir,
+		envHistoryPath: c.envHistoryPath,
+		platform:       c.platform,
+		platformDir:    c.platformDir,
+		secretsDir:     c.secretsDir,
 	}.build(group, plan)
 	stopPinging()
 
@@ -270,7 +337,10 @@ func (c *createCmd) Exec() error {
 		processType:         c.processType,
 		projectMetadataPath: c.projectMetadataPath,
 		reportPath:          c.reportPath,
+		runExtensions:       c.runExtensions,
 		runImageRef:         c.runImageRef,
+		secretsDir:          c.secretsDir,
+		secretsPolicy:       c.secretsPolicy,
 		stackMD:             c.stackMD,
 		stackPath:           c.stackPath,
 		targetRegistry:      c.targetRegistry,
@@ -294,7 +364,14 @@ func (c *createCmd) ReadableRegistryImages() []string {
 	if !c.useDaemon {
 		readableImages = appendNotEmpty(readableImages, c.previousImageRef, c.runImageRef)
 	}
-	return readableImages
+	return appendNotEmpty(readableImages, c.seedCacheImageRef)
+}
+
+func (c *createCmd) PreviousRegistryImage() string {
+	if c.useDaemon {
+		return ""
+	}
+	return c.previousImageRef
 }
 
 func (c *createCmd) WriteableRegistryImages() []string {
@@ -313,10 +390,11 @@ func (c *createCmd) populateRunImage() error {
 	}
 
 	var err error
-	c.runImageRef, err = c.stackMD.BestRunImageMirror(c.targetRegistry)
+	c.runImageMirrors, err = c.stackMD.RunImageMirrorsByPriority(c.targetRegistry)
 	if err != nil {
 		return errors.New("-run-image is required when there is no stack metadata available")
 	}
+	c.runImageRef = c.runImageMirrors[0]
 	return nil
 }
 
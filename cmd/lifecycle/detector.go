@@ -88,6 +88,9 @@ func (da detectArgs) detect() (buildpack.Group, platform.BuildPlan, error) {
 	if err := da.verifyBuildpackApis(order); err != nil {
 		return buildpack.Group{}, platform.BuildPlan{}, err
 	}
+	if _, err := redactSecrets(da.platformDir, ""); err != nil {
+		return buildpack.Group{}, platform.BuildPlan{}, err
+	}
 
 	detector, err := lifecycle.NewDetector(
 		buildpack.DetectConfig{
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
@@ -33,8 +34,10 @@ type exportCmd struct {
 	//flags: inputs
 	cacheDir              string
 	cacheImageTag         string
+	cacheImageMaxLayers   int
 	groupPath             string
 	deprecatedRunImageRef string
+	runImageMirrors       []string // run image and its mirrors from stack.toml in priority order, when -run-image is not provided
 	exportArgs
 
 	//flags: paths to write outputs
@@ -50,10 +53,14 @@ type exportArgs struct {
 	processType         string
 	projectMetadataPath string
 	reportPath          string
+	runExtensionsPath   string
 	runImageRef         string
+	secretsDir          string
+	secretsPolicy       string
 	stackPath           string
 	targetRegistry      string
 	imageNames          []string
+	runExtensions       []platform.RunExtension
 	stackMD             platform.StackMetadata
 
 	useDaemon bool
@@ -72,6 +79,7 @@ func (e *exportCmd) DefineFlags() {
 	cmd.FlagAppDir(&e.appDir)
 	cmd.FlagCacheDir(&e.cacheDir)
 	cmd.FlagCacheImage(&e.cacheImageTag)
+	cmd.FlagCacheImageMaxLayers(&e.cacheImageMaxLayers)
 	cmd.FlagGID(&e.gid)
 	cmd.FlagGroupPath(&e.groupPath)
 	cmd.FlagLaunchCacheDir(&e.launchCacheDir)
@@ -80,7 +88,10 @@ func (e *exportCmd) DefineFlags() {
 	cmd.FlagProcessType(&e.processType)
 	cmd.FlagProjectMetadataPath(&e.projectMetadataPath)
 	cmd.FlagReportPath(&e.reportPath)
+	cmd.FlagRunExtensionsPath(&e.runExtensionsPath)
 	cmd.FlagRunImage(&e.runImageRef)
+	cmd.FlagSecretsDir(&e.secretsDir)
+	cmd.FlagSecretsPolicy(&e.secretsPolicy)
 	cmd.FlagStackPath(&e.stackPath)
 	cmd.FlagUID(&e.uid)
 	cmd.FlagUseDaemon(&e.useDaemon)
@@ -95,10 +106,6 @@ func (e *exportCmd) Args(nargs int, args []string) error {
 	}
 
 	e.imageNames = args
-	if e.launchCacheDir != "" && !e.useDaemon {
-		cmd.DefaultLogger.Warn("Ignoring -launch-cache, only intended for use with -daemon")
-		e.launchCacheDir = ""
-	}
 
 	if e.cacheImageTag == "" && e.cacheDir == "" {
 		cmd.DefaultLogger.Warn("Will not cache data, no cache flag specified.")
@@ -112,6 +119,10 @@ func (e *exportCmd) Args(nargs int, args []string) error {
 		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "validate run image input")
 	}
 
+	if err := validateSecretsPolicy(e.secretsPolicy); err != nil {
+		return err
+	}
+
 	if e.analyzedPath == cmd.PlaceholderAnalyzedPath {
 		e.analyzedPath = cmd.DefaultAnalyzedPath(e.platform.API().String(), e.layersDir)
 	}
@@ -143,6 +154,10 @@ func (e *exportCmd) Args(nargs int, args []string) error {
 		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse stack metadata")
 	}
 
+	if e.runExtensions, err = readRunExtensions(e.runExtensionsPath); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse run extensions")
+	}
+
 	e.targetRegistry, err = parseRegistry(e.imageNames[0])
 	if err != nil {
 		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse target registry")
@@ -171,17 +186,28 @@ func readStack(stackPath string) (platform.StackMetadata, error) {
 	return stackMD, nil
 }
 
+func readRunExtensions(runExtensionsPath string) ([]platform.RunExtension, error) {
+	if runExtensionsPath == "" {
+		return nil, nil
+	}
+	return platform.ReadRunExtensions(runExtensionsPath)
+}
+
 func (e *exportCmd) supportsRunImage() bool {
 	return e.platform.API().LessThan("0.7")
 }
 
 func (e *exportCmd) Privileges() error {
 	var err error
-	e.keychain, err = auth.DefaultKeychain(e.registryImages()...)
+	e.keychain, err = auth.DefaultKeychain(append(e.registryImages(), e.runImageMirrors...)...)
 	if err != nil {
 		return cmd.FailErr(err, "resolve keychain")
 	}
 
+	if !e.useDaemon && len(e.runImageMirrors) > 0 {
+		e.runImageRef, _ = selectRunImageMirror(e.runImageMirrors, e.keychain)
+	}
+
 	if e.useDaemon {
 		var err error
 		e.docker, err = priv.DockerClient()
@@ -207,7 +233,7 @@ func (e *exportCmd) Exec() error {
 		return err
 	}
 
-	cacheStore, err := initCache(e.cacheImageTag, e.cacheDir, e.keychain)
+	cacheStore, err := initCache(e.cacheImageTag, e.cacheDir, e.keychain, cache.WithLayerEviction(e.cacheImageMaxLayers, cmd.DefaultLogger))
 	if err != nil {
 		cmd.DefaultLogger.Infof("no stack metadata found at path '%s', stack metadata will not be exported\n", e.stackPath)
 	}
@@ -238,10 +264,11 @@ func (e *exportCmd) populateRunImageRefIfNeeded() error {
 		e.runImageRef = e.analyzedMD.RunImage.Reference
 	} else if e.runImageRef == "" {
 		var err error
-		e.runImageRef, err = e.stackMD.BestRunImageMirror(e.targetRegistry)
+		e.runImageMirrors, err = e.stackMD.RunImageMirrorsByPriority(e.targetRegistry)
 		if err != nil {
 			return errors.New("-run-image is required when there is no stack metadata available")
 		}
+		e.runImageRef = e.runImageMirrors[0]
 	}
 	return nil
 }
@@ -297,6 +324,7 @@ func (ea exportArgs) export(group buildpack.Group, cacheStore lifecycle.Cache, a
 	if err != nil {
 		return err
 	}
+	workingImage := image.NewHealthCheckImage(appImage)
 
 	report, err := exporter.Export(lifecycle.ExportOptions{
 		AdditionalNames:    ea.imageNames[1:],
@@ -306,14 +334,22 @@ func (ea exportArgs) export(group buildpack.Group, cacheStore lifecycle.Cache, a
 		LayersDir:          ea.layersDir,
 		OrigMetadata:       analyzedMD.Metadata,
 		Project:            projectMD,
+		RunExtensions:      ea.runExtensions,
 		RunImageRef:        runImageID,
+		SecretsDir:         ea.secretsDir,
+		SecretsPolicy:      ea.secretsPolicy,
 		Stack:              ea.stackMD,
-		WorkingImage:       appImage,
+		WorkingImage:       workingImage,
 	})
 	if err != nil {
 		return cmd.FailErrCode(err, ea.platform.CodeFor(platform.ExportError), "export")
 	}
 
+	if cachingImage, ok := appImage.(*cache.RemoteCachingImage); ok {
+		layers, bytes := cachingImage.MountStats()
+		cmd.DefaultLogger.Infof("Mounted %d layers from the launch cache, skipped uploading %d bytes", layers, bytes)
+	}
+
 	if err := encoding.WriteTOML(ea.reportPath, &report); err != nil {
 		return cmd.FailErrCode(err, ea.platform.CodeFor(platform.ExportError), "write export report")
 	}
@@ -387,6 +423,7 @@ func (ea exportArgs) initRemoteAppImage(analyzedMD platform.AnalyzedMetadata) (i
 		opts = append(opts, remote.WithCreatedAt(ea.customSourceDateEpoch()))
 	}
 
+	var appImage imgutil.Image
 	appImage, err := remote.NewImage(
 		ea.imageNames[0],
 		ea.keychain,
@@ -404,6 +441,14 @@ func (ea exportArgs) initRemoteAppImage(analyzedMD platform.AnalyzedMetadata) (i
 	if err != nil {
 		return nil, "", cmd.FailErr(err, "get run image reference")
 	}
+
+	if ea.launchCacheDir != "" {
+		index, err := cache.NewBlobIndex(filepath.Join(ea.launchCacheDir, "blob-index.json"))
+		if err != nil {
+			return nil, "", cmd.FailErr(err, "create launch cache")
+		}
+		appImage = cache.NewRemoteCachingImage(appImage, index, ea.keychain)
+	}
 	return appImage, runImageID.String(), nil
 }
 
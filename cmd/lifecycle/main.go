@@ -1,6 +1,9 @@
 package main
 
 import (
+	"errors"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -12,6 +15,8 @@ import (
 	"github.com/buildpacks/lifecycle/buildpack"
 	"github.com/buildpacks/lifecycle/cache"
 	"github.com/buildpacks/lifecycle/cmd"
+	"github.com/buildpacks/lifecycle/env"
+	"github.com/buildpacks/lifecycle/internal/redact"
 	lplatform "github.com/buildpacks/lifecycle/platform"
 )
 
@@ -71,6 +76,8 @@ func subcommand(platform Platform) {
 		cmd.Run(&rebaseCmd{platform: platform}, true)
 	case "create":
 		cmd.Run(&createCmd{platform: platform}, true)
+	case "cache":
+		cacheSubcommand(platform)
 	default:
 		cmd.Exit(cmd.FailCode(cmd.CodeInvalidArgs, "unknown phase:", phase))
 	}
@@ -90,13 +97,13 @@ func verifyBuildpackApis(group buildpack.Group) error {
 	return nil
 }
 
-func initCache(cacheImageTag, cacheDir string, keychain authn.Keychain) (lifecycle.Cache, error) {
+func initCache(cacheImageTag, cacheDir string, keychain authn.Keychain, opts ...cache.ImageCacheOption) (lifecycle.Cache, error) {
 	var (
 		cacheStore lifecycle.Cache
 		err        error
 	)
 	if cacheImageTag != "" {
-		cacheStore, err = cache.NewImageCacheFromName(cacheImageTag, keychain)
+		cacheStore, err = cache.NewImageCacheFromName(cacheImageTag, keychain, opts...)
 		if err != nil {
 			return nil, cmd.FailErr(err, "create image cache")
 		}
@@ -109,6 +116,76 @@ func initCache(cacheImageTag, cacheDir string, keychain authn.Keychain) (lifecyc
 	return cacheStore, nil
 }
 
+// initSeedCache returns the read-only seed cache, or nil if none was specified.
+// The returned cleanup function removes any files extracted from a seed cache archive.
+func initSeedCache(seedCacheImageTag, seedCacheDir, seedCacheArchive string, keychain authn.Keychain) (lifecycle.Cache, func(), error) {
+	noop := func() {}
+	switch {
+	case seedCacheImageTag != "":
+		seedCache, err := cache.NewReadOnlyImageCacheFromName(seedCacheImageTag, keychain)
+		if err != nil {
+			return nil, noop, cmd.FailErr(err, "create seed image cache")
+		}
+		return seedCache, noop, nil
+	case seedCacheDir != "":
+		seedCache, err := cache.NewReadOnlyVolumeCache(seedCacheDir)
+		if err != nil {
+			return nil, noop, cmd.FailErr(err, "create seed volume cache")
+		}
+		return seedCache, noop, nil
+	case seedCacheArchive != "":
+		tmpDir, err := ioutil.TempDir("", "seed-cache")
+		if err != nil {
+			return nil, noop, cmd.FailErr(err, "create seed cache directory")
+		}
+		cleanup := func() { os.RemoveAll(tmpDir) }
+		seedCache, err := cache.NewVolumeCacheFromArchive(seedCacheArchive, tmpDir)
+		if err != nil {
+			cleanup()
+			return nil, noop, cmd.FailErr(err, "read seed cache archive")
+		}
+		return seedCache, cleanup, nil
+	}
+	return nil, noop, nil
+}
+
+func verifySeedCacheFlags(platformAPI *api.Version, seedCacheImageTag, seedCacheDir, seedCacheArchive string) error {
+	seedCacheFlags := appendNotEmpty(nil, seedCacheImageTag, seedCacheDir, seedCacheArchive)
+	if len(seedCacheFlags) > 1 {
+		return cmd.FailErrCode(errors.New("only one of -seed-cache-image, -seed-cache-dir or -seed-cache-archive may be specified"), cmd.CodeInvalidArgs, "parse arguments")
+	}
+	if len(seedCacheFlags) > 0 && platformAPI.LessThan("0.7") {
+		return cmd.FailErrCode(errors.New("seed caches require Platform API 0.7 or later"), cmd.CodeInvalidArgs, "parse arguments")
+	}
+	return nil
+}
+
+// redactSecrets masks the values of the platform secrets and of the build-time secrets in secretsDir, if set, in log output
+// and returns a redactor for buildpack output.
+func redactSecrets(platformDir, secretsDir string) (*redact.Redactor, error) {
+	secrets, err := env.ReadSecrets(platformDir)
+	if err != nil {
+		return nil, cmd.FailErr(err, "read platform secrets")
+	}
+	values := env.SecretValues(secrets)
+	if secretsDir != "" {
+		buildSecrets, err := env.ReadSecretsDir(secretsDir)
+		if err != nil {
+			return nil, cmd.FailErr(err, "read build-time secrets")
+		}
+		values = append(values, env.SecretValues(buildSecrets)...)
+	}
+	cmd.RedactSecrets(values)
+	return redact.New(values), nil
+}
+
+func validateSecretsPolicy(policy string) error {
+	if policy != lifecycle.SecretsPolicyFail && policy != lifecycle.SecretsPolicyWarn {
+		return cmd.FailErrCode(fmt.Errorf("unknown secrets policy '%s', must be one of: fail, warn", policy), cmd.CodeInvalidArgs, "parse arguments")
+	}
+	return nil
+}
+
 func appendNotEmpty(slice []string, elems ...string) []string {
 	for _, v := range elems {
 		if v != "" {
@@ -2,6 +2,9 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
 
 	"github.com/buildpacks/imgutil"
 	"github.com/buildpacks/imgutil/local"
@@ -23,7 +26,13 @@ import (
 type rebaseCmd struct {
 	appImage imgutil.Image
 	//flags: inputs
+	batch                 bool
+	dryRun                bool
+	force                 bool
 	imageNames            []string
+	imagesPath            string
+	parallelism           int
+	rollback              bool
 	reportPath            string
 	runImageRef           string
 	deprecatedRunImageRef string
@@ -40,6 +49,12 @@ type rebaseCmd struct {
 // DefineFlags defines the flags that are considered valid and reads their values (if provided).
 func (r *rebaseCmd) DefineFlags() {
 	cmd.FlagGID(&r.gid)
+	cmd.FlagRebaseBatch(&r.batch)
+	cmd.FlagRebaseDryRun(&r.dryRun)
+	cmd.FlagRebaseForce(&r.force)
+	cmd.FlagRebaseImagesPath(&r.imagesPath)
+	cmd.FlagRebaseParallelism(&r.parallelism)
+	cmd.FlagRebaseRollback(&r.rollback)
 	cmd.FlagReportPath(&r.reportPath)
 	cmd.FlagRunImage(&r.runImageRef)
 	cmd.FlagUID(&r.uid)
@@ -50,10 +65,18 @@ func (r *rebaseCmd) DefineFlags() {
 
 // Args validates arguments and flags, and fills in default values.
 func (r *rebaseCmd) Args(nargs int, args []string) error {
-	if nargs == 0 {
+	r.imageNames = args
+	if r.imagesPath != "" {
+		r.batch = true
+		names, err := readImageList(r.imagesPath)
+		if err != nil {
+			return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "read image list")
+		}
+		r.imageNames = append(r.imageNames, names...)
+	}
+	if len(r.imageNames) == 0 {
 		return cmd.FailErrCode(errors.New("at least one image argument is required"), cmd.CodeInvalidArgs, "parse arguments")
 	}
-	r.imageNames = args
 	if err := image.ValidateDestinationTags(r.useDaemon, r.imageNames...); err != nil {
 		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "validate image tag(s)")
 	}
@@ -69,6 +92,23 @@ func (r *rebaseCmd) Args(nargs int, args []string) error {
 		r.reportPath = cmd.DefaultReportPath(r.platform.API().String(), "")
 	}
 
+	if r.rollback && r.runImageRef != "" {
+		return cmd.FailErrCode(errors.New("supply only one of -run-image or -rollback"), cmd.CodeInvalidArgs, "parse arguments")
+	}
+
+	if r.batch {
+		if r.rollback {
+			return cmd.FailErrCode(errors.New("-rollback is not supported when rebasing a batch of images"), cmd.CodeInvalidArgs, "parse arguments")
+		}
+		if r.runImageRef == "" {
+			return cmd.FailErrCode(errors.New("-run-image is required when rebasing a batch of images"), cmd.CodeInvalidArgs, "parse arguments")
+		}
+		if r.dryRun {
+			return cmd.FailErrCode(errors.New("-dry-run is not supported when rebasing a batch of images"), cmd.CodeInvalidArgs, "parse arguments")
+		}
+		return nil
+	}
+
 	if err := r.setAppImage(); err != nil {
 		return cmd.FailErrCode(errors.New(err.Error()), r.platform.CodeFor(platform.RebaseError), "set app image")
 	}
@@ -117,10 +157,29 @@ func (r *rebaseCmd) Exec() error {
 	}
 
 	rebaser := &lifecycle.Rebaser{
+		Force:       r.force,
 		Logger:      cmd.DefaultLogger,
+		Parallelism: r.parallelism,
 		PlatformAPI: r.platform.API(),
 	}
-	report, err := rebaser.Rebase(r.appImage, newBaseImage, r.imageNames[1:])
+	if r.useDaemon {
+		lister := &image.DaemonLayerLister{Docker: r.docker}
+		rebaser.LayerLister, rebaser.VariantReader = lister, lister
+	} else {
+		lister := &image.RegistryLayerLister{Keychain: r.keychain}
+		rebaser.LayerLister, rebaser.VariantReader = lister, lister
+	}
+	if r.batch {
+		return r.rebaseBatch(rebaser, newBaseImage)
+	}
+	if r.dryRun {
+		return r.dryRunRebase(rebaser, newBaseImage)
+	}
+	rebase := rebaser.Rebase
+	if r.rollback {
+		rebase = rebaser.Rollback
+	}
+	report, err := rebase(r.appImage, newBaseImage, r.imageNames[1:])
 	if err != nil {
 		return cmd.FailErrCode(err, r.platform.CodeFor(platform.RebaseError), "rebase")
 	}
@@ -131,6 +190,75 @@ func (r *rebaseCmd) Exec() error {
 	return nil
 }
 
+func (r *rebaseCmd) rebaseBatch(rebaser *lifecycle.Rebaser, newBaseImage imgutil.Image) error {
+	report := rebaser.RebaseBatch(r.imageNames, r.openImage, newBaseImage)
+	if err := writeReport(r.reportPath, &report); err != nil {
+		return cmd.FailErrCode(err, r.platform.CodeFor(platform.RebaseError), "write rebase report")
+	}
+	if failed := report.Failed(); failed > 0 {
+		return cmd.FailErrCode(fmt.Errorf("%d of %d images failed to rebase", failed, len(report.Images)), r.platform.CodeFor(platform.RebaseError), "rebase")
+	}
+	return nil
+}
+
+func (r *rebaseCmd) openImage(imageName string) (imgutil.Image, error) {
+	var (
+		img imgutil.Image
+		err error
+	)
+	if r.useDaemon {
+		img, err = local.NewImage(imageName, r.docker, local.FromBaseImage(imageName))
+	} else {
+		img, err = remote.NewImage(imageName, r.keychain, remote.FromBaseImage(imageName))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !img.Found() {
+		return nil, fmt.Errorf("image '%s' not found", imageName)
+	}
+	return img, nil
+}
+
+// readImageList reads the image references in path, one per line, ignoring blank lines and lines starting with '#'.
+func readImageList(path string) ([]string, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, line := range strings.Split(string(contents), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+	return names, nil
+}
+
+func (r *rebaseCmd) dryRunRebase(rebaser *lifecycle.Rebaser, newBaseImage imgutil.Image) error {
+	report, err := rebaser.DryRun(r.appImage, newBaseImage)
+	if err != nil {
+		return cmd.FailErrCode(err, r.platform.CodeFor(platform.RebaseError), "rebase")
+	}
+	if err := writeReport(r.reportPath, &report); err != nil {
+		return cmd.FailErrCode(err, r.platform.CodeFor(platform.RebaseError), "write rebase report")
+	}
+	if !report.Valid {
+		return cmd.FailErrCode(errors.New(strings.Join(report.Errors, "; ")), r.platform.CodeFor(platform.RebaseError), "validate rebase")
+	}
+	return nil
+}
+
+// writeReport writes the report as JSON when the path has a .json extension, and as TOML otherwise.
+func writeReport(path string, report interface{}) error {
+	if filepath.Ext(path) == ".json" {
+		return encoding.WriteJSON(path, report)
+	}
+	return encoding.WriteTOML(path, report)
+}
+
 func (r *rebaseCmd) registryImages() []string {
 	registryImages := r.imageNames
 	if r.runImageRef != "" {
@@ -173,6 +301,19 @@ func (r *rebaseCmd) setAppImage() error {
 		return err
 	}
 
+	if r.rollback {
+		var history platform.RebaseHistory
+		if err := image.DecodeLabel(r.appImage, platform.RebaseHistoryLabel, &history); err != nil {
+			return err
+		}
+		previous, ok := history.Previous()
+		if !ok {
+			return errors.New("no previous run image in rebase history")
+		}
+		r.runImageRef = previous.Reference
+		return nil
+	}
+
 	if r.runImageRef == "" {
 		if md.Stack.RunImage.Image == "" {
 			return cmd.FailErrCode(errors.New("-image is required when there is no stack metadata available"), cmd.CodeInvalidArgs, "parse arguments")
@@ -3,6 +3,8 @@ package main
 import (
 	"errors"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/google/go-containerregistry/pkg/authn"
@@ -18,19 +20,29 @@ import (
 
 type restoreCmd struct {
 	// flags: inputs
-	analyzedPath  string
-	cacheDir      string
-	cacheImageTag string
-	groupPath     string
-	uid, gid      int
+	analyzedPath      string
+	cacheDir          string
+	cacheImageTag     string
+	groupPath         string
+	seedCacheArchive  string
+	seedCacheDir      string
+	seedCacheImageTag string
+	uid, gid          int
+
+	invalidateCache cmd.StringSlice
 
 	restoreArgs
 }
 
 type restoreArgs struct {
-	layersDir  string
-	platform   Platform
-	skipLayers bool
+	eventsPath       string
+	invalidateLayers []platform.CacheLayerSelector
+	layersDir        string
+	parallelism      int
+	platform         Platform
+	progressInterval time.Duration
+	seedCache        lifecycle.Cache
+	skipLayers       bool
 
 	// construct if necessary before dropping privileges
 	keychain authn.Keychain
@@ -41,7 +53,14 @@ func (r *restoreCmd) DefineFlags() {
 	cmd.FlagCacheDir(&r.cacheDir)
 	cmd.FlagCacheImage(&r.cacheImageTag)
 	cmd.FlagGroupPath(&r.groupPath)
+	cmd.FlagInvalidateCache(&r.invalidateCache)
 	cmd.FlagLayersDir(&r.layersDir)
+	cmd.FlagRestoreEventsPath(&r.eventsPath)
+	cmd.FlagRestoreParallelism(&r.parallelism)
+	cmd.FlagRestoreProgressInterval(&r.progressInterval)
+	cmd.FlagSeedCacheArchive(&r.seedCacheArchive)
+	cmd.FlagSeedCacheDir(&r.seedCacheDir)
+	cmd.FlagSeedCacheImage(&r.seedCacheImageTag)
 	cmd.FlagUID(&r.uid)
 	cmd.FlagGID(&r.gid)
 	if r.restoresLayerMetadata() {
@@ -67,6 +86,15 @@ func (r *restoreCmd) Args(nargs int, args []string) error {
 		r.analyzedPath = cmd.DefaultAnalyzedPath(r.platform.API().String(), r.layersDir)
 	}
 
+	if err := verifySeedCacheFlags(r.platform.API(), r.seedCacheImageTag, r.seedCacheDir, r.seedCacheArchive); err != nil {
+		return err
+	}
+
+	var err error
+	if r.invalidateLayers, err = platform.ParseCacheLayerSelectors(r.invalidateCache); err != nil {
+		return cmd.FailErrCode(err, cmd.CodeInvalidArgs, "parse cache layer selectors")
+	}
+
 	return nil
 }
 
@@ -98,6 +126,12 @@ func (r *restoreCmd) Exec() error {
 	if err != nil {
 		return err
 	}
+	seedCache, cleanupSeedCache, err := initSeedCache(r.seedCacheImageTag, r.seedCacheDir, r.seedCacheArchive, r.keychain)
+	if err != nil {
+		return err
+	}
+	defer cleanupSeedCache()
+	r.seedCache = seedCache
 
 	var appMeta platform.LayersMetadata
 	if r.restoresLayerMetadata() {
@@ -111,16 +145,14 @@ func (r *restoreCmd) Exec() error {
 }
 
 func (r *restoreCmd) registryImages() []string {
-	if r.cacheImageTag != "" {
-		return []string{r.cacheImageTag}
-	}
-	return []string{}
+	return appendNotEmpty([]string{}, r.cacheImageTag, r.seedCacheImageTag)
 }
 
 func (r restoreArgs) restore(layerMetadata platform.LayersMetadata, group buildpack.Group, cacheStore lifecycle.Cache) error {
 	restorer := &lifecycle.Restorer{
 		LayersDir:             r.layersDir,
 		Buildpacks:            group.Group,
+		InvalidateLayers:      r.invalidateLayers,
 		Logger:                cmd.DefaultLogger,
 		Platform:              r.platform,
 		LayerMetadataRestorer: layer.NewMetadataRestorer(cmd.DefaultLogger, r.layersDir, r.skipLayers),
@@ -129,6 +161,18 @@ func (r restoreArgs) restore(layerMetadata platform.LayersMetadata, group buildp
 			LayersDir: r.layersDir,
 			Logger:    cmd.DefaultLogger,
 		}, r.platform.API()),
+		SeedCache:        r.seedCache,
+		Parallelism:      r.parallelism,
+		ProgressInterval: r.progressInterval,
+	}
+
+	if r.eventsPath != "" {
+		f, err := os.Create(r.eventsPath)
+		if err != nil {
+			return cmd.FailErr(err, "create restore events file")
+		}
+		defer f.Close()
+		restorer.ProgressWriter = f
 	}
 
 	if err := restorer.Restore(cacheStore); err != nil {
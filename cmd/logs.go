@@ -7,6 +7,8 @@ import (
 
 	"github.com/apex/log"
 	"github.com/heroku/color"
+
+	"github.com/buildpacks/lifecycle/internal/redact"
 )
 
 const (
@@ -38,10 +40,28 @@ type Logger struct {
 	*log.Logger
 }
 
+// NewStderrLogger returns a logger that writes to Stderr at the level of the DefaultLogger,
+// for messages that must not be mixed with the stdout of a launched process.
+func NewStderrLogger() *Logger {
+	return &Logger{
+		&log.Logger{
+			Handler: &handler{
+				writer: Stderr,
+			},
+			Level: DefaultLogger.Level,
+		},
+	}
+}
+
 func (l *Logger) Phase(name string) {
 	l.Infof(phaseStyle("===> %s", name))
 }
 
+// DebugEnabled returns true if debug messages are logged.
+func (l *Logger) DebugEnabled() bool {
+	return l.Level <= log.DebugLevel
+}
+
 func SetLogLevel(level string) *ErrorFail {
 	var err error
 	DefaultLogger.Level, err = log.ParseLevel(level)
@@ -57,23 +77,34 @@ func DisableColor(noColor bool) {
 	Stderr.DisableColors(noColor)
 }
 
+// RedactSecrets masks the given secret values in every message logged by the DefaultLogger.
+func RedactSecrets(secrets []string) {
+	if h, ok := DefaultLogger.Handler.(*handler); ok {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.redactor = redact.New(secrets)
+	}
+}
+
 type handler struct {
-	mu     sync.Mutex
-	writer io.Writer
+	mu       sync.Mutex
+	writer   io.Writer
+	redactor *redact.Redactor
 }
 
 func (h *handler) HandleLog(entry *log.Entry) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
+	msg := h.redactor.String(entry.Message)
 	var err error
 	switch entry.Level {
 	case log.WarnLevel:
-		_, err = h.writer.Write([]byte(warnStyle(warnLevelText) + appendMissingLineFeed(entry.Message)))
+		_, err = h.writer.Write([]byte(warnStyle(warnLevelText) + appendMissingLineFeed(msg)))
 	case log.ErrorLevel:
-		_, err = h.writer.Write([]byte(errorStyle(errorLevelText) + appendMissingLineFeed(entry.Message)))
+		_, err = h.writer.Write([]byte(errorStyle(errorLevelText) + appendMissingLineFeed(msg)))
 	default:
-		_, err = h.writer.Write([]byte(appendMissingLineFeed(entry.Message)))
+		_, err = h.writer.Write([]byte(appendMissingLineFeed(msg)))
 	}
 	return err
 }
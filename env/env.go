@@ -16,6 +16,7 @@ type Env struct {
 	// RootDirMap maps directories in a posix root filesystem to a slice of environment variables that
 	RootDirMap map[string][]string
 	Vars       *Vars
+	History    History // optional, records every change to each variable when not nil
 }
 
 // AddRootDir modifies the environment given a root dir. If the root dir contains a directory that matches a key in
@@ -35,6 +36,7 @@ func (p *Env) AddRootDir(dir string) error {
 		}
 		for _, key := range vars {
 			p.Vars.Set(key, childDir+prefix(p.Vars.Get(key), os.PathListSeparator))
+			p.record(key, childDir, ActionTypePrepend, []byte{os.PathListSeparator})
 		}
 	}
 	return nil
@@ -83,20 +85,29 @@ func (p *Env) AddEnvDir(envDir string, defaultAction ActionType) error {
 		} else {
 			action = defaultAction
 		}
+		source := filepath.Join(envDir, k)
 		switch action {
 		case ActionTypePrepend:
-			p.Vars.Set(name, v+prefix(p.Vars.Get(name), delim(envDir, name)...))
+			d := delim(envDir, name)
+			p.Vars.Set(name, v+prefix(p.Vars.Get(name), d...))
+			p.record(name, source, action, d)
 		case ActionTypeAppend:
-			p.Vars.Set(name, suffix(p.Vars.Get(name), delim(envDir, name)...)+v)
+			d := delim(envDir, name)
+			p.Vars.Set(name, suffix(p.Vars.Get(name), d...)+v)
+			p.record(name, source, action, d)
 		case ActionTypeOverride:
 			p.Vars.Set(name, v)
+			p.record(name, source, action, nil)
 		case ActionTypeDefault:
 			if p.Vars.Get(name) != "" {
 				return nil
 			}
 			p.Vars.Set(name, v)
+			p.record(name, source, action, nil)
 		case ActionTypePrependPath:
-			p.Vars.Set(name, v+prefix(p.Vars.Get(name), delim(envDir, name, os.PathListSeparator)...))
+			d := delim(envDir, name, os.PathListSeparator)
+			p.Vars.Set(name, v+prefix(p.Vars.Get(name), d...))
+			p.record(name, source, ActionTypePrepend, d)
 		}
 		return nil
 	}); err != nil {
@@ -107,7 +118,14 @@ func (p *Env) AddEnvDir(envDir string, defaultAction ActionType) error {
 
 // Set sets the environment variable with the given name to the given value.
 func (p *Env) Set(name, v string) {
+	p.SetFrom(name, v, "")
+}
+
+// SetFrom sets the environment variable with the given name to the given value,
+// recording source as the origin of the change.
+func (p *Env) SetFrom(name, v, source string) {
 	p.Vars.Set(name, v)
+	p.record(name, source, ActionTypeOverride, nil)
 }
 
 // WithPlatform returns the environment after applying modifications from the given platform dir.
@@ -115,6 +133,7 @@ func (p *Env) Set(name, v string) {
 // RootDirMap, the given variable will be set to the contents of the file. If the name does match an environment
 // variable name in the RootDirMap, the contents of the file will be prepended to the environment variable value
 // using the OS path list separator as a delimiter.
+// Secrets in the SecretsDir of the platformDir are then set, overriding variables of the same name.
 func (p *Env) WithPlatform(platformDir string) (out []string, err error) {
 	vars := NewVars(p.Vars.vals, p.Vars.ignoreCase)
 
@@ -128,6 +147,13 @@ func (p *Env) WithPlatform(platformDir string) (out []string, err error) {
 	}); err != nil {
 		return nil, err
 	}
+	secrets, err := ReadSecrets(platformDir)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range secrets {
+		vars.Set(k, v)
+	}
 	return vars.List(), nil
 }
 
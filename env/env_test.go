@@ -225,6 +225,63 @@ func testEnv(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("#History", func() {
+		it.Before(func() {
+			envv.History = env.History{}
+		})
+
+		it("records changes from env files in order", func() {
+			mkdir(t, filepath.Join(tmpDir, "first"), filepath.Join(tmpDir, "second"))
+			mkfile(t, "first-val", filepath.Join(tmpDir, "first", "VAR.default"))
+			mkfile(t, "second-val", filepath.Join(tmpDir, "second", "VAR.override"))
+			if err := envv.AddEnvDir(filepath.Join(tmpDir, "first"), env.ActionTypeOverride); err != nil {
+				t.Fatalf("Error: %s\n", err)
+			}
+			if err := envv.AddEnvDir(filepath.Join(tmpDir, "second"), env.ActionTypeOverride); err != nil {
+				t.Fatalf("Error: %s\n", err)
+			}
+			expected := []env.Change{
+				{Source: filepath.Join(tmpDir, "first", "VAR.default"), Action: env.ActionTypeDefault, Value: "first-val"},
+				{Source: filepath.Join(tmpDir, "second", "VAR.override"), Action: env.ActionTypeOverride, Value: "second-val"},
+			}
+			if s := cmp.Diff(envv.History["VAR"], expected); s != "" {
+				t.Fatalf("Unexpected history:\n%s\n", s)
+			}
+		})
+
+		it("records root dirs as prepends", func() {
+			mkdir(t, filepath.Join(tmpDir, "bin"))
+			if err := envv.AddRootDir(tmpDir); err != nil {
+				t.Fatalf("Error: %s\n", err)
+			}
+			expected := []env.Change{{
+				Source:    filepath.Join(tmpDir, "bin"),
+				Action:    env.ActionTypePrepend,
+				Delimiter: string(os.PathListSeparator),
+				Value:     filepath.Join(tmpDir, "bin"),
+			}}
+			if s := cmp.Diff(envv.History["PATH"], expected); s != "" {
+				t.Fatalf("Unexpected history:\n%s\n", s)
+			}
+		})
+
+		it("records the source of set variables", func() {
+			envv.SetFrom("VAR", "some-val", "some-exec-d")
+			expected := []env.Change{{Source: "some-exec-d", Action: env.ActionTypeOverride, Value: "some-val"}}
+			if s := cmp.Diff(envv.History["VAR"], expected); s != "" {
+				t.Fatalf("Unexpected history:\n%s\n", s)
+			}
+		})
+
+		it("records nothing when history is not enabled", func() {
+			envv.History = nil
+			envv.Set("VAR", "some-val")
+			if envv.History != nil {
+				t.Fatalf("Unexpected history: %+v", envv.History)
+			}
+		})
+	})
+
 	when("#WithPlatform", func() {
 		it("should apply platform env vars as filename=file-contents", func() {
 			mkdir(t, filepath.Join(tmpDir, "env", "some-dir"))
@@ -263,6 +320,29 @@ func testEnv(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("#WithPlatform secrets", func() {
+		it("should set secrets, overriding platform env vars", func() {
+			mkdir(t, filepath.Join(tmpDir, "env"), filepath.Join(tmpDir, "secrets"))
+			mkfile(t, "value-env", filepath.Join(tmpDir, "env", "VAR_TOKEN"))
+			mkfile(t, "value-secret", filepath.Join(tmpDir, "secrets", "VAR_TOKEN"))
+			mkfile(t, "value-path", filepath.Join(tmpDir, "secrets", "PATH"))
+
+			envv.Vars = env.NewVars(map[string]string{"PATH": "value-path-orig"}, false)
+			out, err := envv.WithPlatform(tmpDir)
+			if err != nil {
+				t.Fatalf("Unexpected error: %s\n", err)
+			}
+			sort.Strings(out)
+			expected := []string{"PATH=value-path", "VAR_TOKEN=value-secret"}
+			if s := cmp.Diff(out, expected); s != "" {
+				t.Fatalf("Unexpected env:\n%s\n", s)
+			}
+			if s := cmp.Diff(envv.List(), []string{"PATH=value-path-orig"}); s != "" {
+				t.Fatalf("Unexpected env:\n%s\n", s)
+			}
+		})
+	})
+
 	when("#Get", func() {
 		it("should get a value", func() {
 			mkdir(t,
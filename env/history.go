@@ -0,0 +1,24 @@
+package env
+
+// Change is a modification of an environment variable.
+type Change struct {
+	Source    string     `json:"source"` // the env file, layer directory or exec.d file that made the change, empty if unknown
+	Action    ActionType `json:"action"`
+	Delimiter string     `json:"delimiter,omitempty"`
+	Value     string     `json:"value"` // the value of the variable after the change
+}
+
+// History is the ordered list of changes made to each environment variable.
+type History map[string][]Change
+
+func (p *Env) record(name, source string, action ActionType, delimiter []byte) {
+	if p.History == nil {
+		return
+	}
+	p.History[name] = append(p.History[name], Change{
+		Source:    source,
+		Action:    action,
+		Delimiter: string(delimiter),
+		Value:     p.Vars.Get(name),
+	})
+}
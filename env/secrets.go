@@ -0,0 +1,36 @@
+package env
+
+import (
+	"path/filepath"
+)
+
+// SecretsDir is the directory in the platform dir that contains secret env vars, one file per variable.
+// Secrets are provided to buildpacks like the env vars in <platform>/env, but must never be written to the image or logs.
+const SecretsDir = "secrets"
+
+// ReadSecrets returns the secret env vars in the given platform dir.
+func ReadSecrets(platformDir string) (map[string]string, error) {
+	return ReadSecretsDir(filepath.Join(platformDir, SecretsDir))
+}
+
+// ReadSecretsDir returns the contents of each file in dir by file name.
+// A missing dir has no secrets.
+func ReadSecretsDir(dir string) (map[string]string, error) {
+	secrets := map[string]string{}
+	if err := eachEnvFile(dir, func(k, v string) error {
+		secrets[k] = v
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// SecretValues returns the values of the given secrets.
+func SecretValues(secrets map[string]string) []string {
+	var values []string
+	for _, v := range secrets {
+		values = append(values, v)
+	}
+	return values
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/buildpacks/imgutil"
@@ -15,6 +16,7 @@ import (
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/buildpack"
 	"github.com/buildpacks/lifecycle/cmd"
+	"github.com/buildpacks/lifecycle/env"
 	"github.com/buildpacks/lifecycle/launch"
 	"github.com/buildpacks/lifecycle/layers"
 	"github.com/buildpacks/lifecycle/platform"
@@ -40,10 +42,12 @@ type Exporter struct {
 
 //go:generate mockgen -package testmock -destination testmock/layer_factory.go github.com/buildpacks/lifecycle LayerFactory
 type LayerFactory interface {
+	CopyLayer(id, src, dest string) (layers.Layer, error)
 	DirLayer(id string, dir string) (layers.Layer, error)
 	LauncherLayer(path string) (layers.Layer, error)
 	ProcessTypesLayer(metadata launch.Metadata) (layers.Layer, error)
 	SliceLayers(dir string, slices []layers.Slice) ([]layers.Layer, error)
+	TarballLayer(id, path string) (layers.Layer, error)
 }
 
 type LauncherConfig struct {
@@ -62,6 +66,9 @@ type ExportOptions struct {
 	Stack              platform.StackMetadata
 	Project            platform.ProjectMetadata
 	DefaultProcessType string
+	RunExtensions      []platform.RunExtension // optional, applied on top of the run image before buildpack layers
+	SecretsDir         string                  // if set, launch layers are checked for the values of the build-time secrets in this dir
+	SecretsPolicy      string                  // SecretsPolicyFail or SecretsPolicyWarn, defaults to SecretsPolicyFail
 }
 
 func (e *Exporter) Export(opts ExportOptions) (platform.ExportReport, error) {
@@ -92,8 +99,18 @@ func (e *Exporter) Export(opts ExportOptions) (platform.ExportReport, error) {
 	}
 	buildMD.PlatformAPI = e.PlatformAPI
 
+	// platform-provided run image extensions
+	if err := e.addRunExtensions(opts, &meta); err != nil {
+		return platform.ExportReport{}, errors.Wrap(err, "extending run image")
+	}
+
+	secrets, err := e.readSecrets(opts)
+	if err != nil {
+		return platform.ExportReport{}, err
+	}
+
 	// buildpack-provided layers
-	if err := e.addBuildpackLayers(opts, &meta); err != nil {
+	if err := e.addBuildpackLayers(opts, secrets, &meta); err != nil {
 		return platform.ExportReport{}, err
 	}
 
@@ -104,7 +121,7 @@ func (e *Exporter) Export(opts ExportOptions) (platform.ExportReport, error) {
 	}
 
 	// app layers (split into 1 or more slices)
-	if err := e.addAppLayers(opts, buildMD.Slices, &meta); err != nil {
+	if err := e.addAppLayers(opts, buildMD.Slices, secrets, &meta); err != nil {
 		return platform.ExportReport{}, errors.Wrap(err, "exporting app layers")
 	}
 
@@ -141,6 +158,10 @@ func (e *Exporter) Export(opts ExportOptions) (platform.ExportReport, error) {
 		return platform.ExportReport{}, errors.Wrap(err, "setting cmd")
 	}
 
+	if err = e.setHealthCheck(opts, buildMD.ToLaunchMD(), entrypoint); err != nil {
+		return platform.ExportReport{}, errors.Wrap(err, "setting health check")
+	}
+
 	report := platform.ExportReport{}
 	report.Build, err = e.makeBuildReport(opts.LayersDir)
 	if err != nil {
@@ -158,7 +179,80 @@ func (e *Exporter) Export(opts ExportOptions) (platform.ExportReport, error) {
 	return report, nil
 }
 
-func (e *Exporter) addBuildpackLayers(opts ExportOptions, meta *platform.LayersMetadata) error {
+// addRunExtensions applies the run image extensions in order, and records the layers they add in the run image metadata.
+// The layers are above the run image top layer, so that rebasing the app image keeps them.
+func (e *Exporter) addRunExtensions(opts ExportOptions, meta *platform.LayersMetadata) error {
+	for _, ext := range opts.RunExtensions {
+		var (
+			layer layers.Layer
+			err   error
+		)
+		id := fmt.Sprintf("run-extension-%d", len(meta.RunImage.Extensions)+1)
+		switch ext.Instruction {
+		case platform.RunExtensionAdd:
+			layer, err = e.LayerFactory.TarballLayer(id, ext.Source)
+		case platform.RunExtensionCopy:
+			layer, err = e.LayerFactory.CopyLayer(id, ext.Source, ext.Destination)
+		case platform.RunExtensionEnv:
+			e.Logger.Debugf("Setting %s=%s", ext.Key, ext.Value)
+			if err := opts.WorkingImage.SetEnv(ext.Key, ext.Value); err != nil {
+				return errors.Wrapf(err, "set app image env %s", ext.Key)
+			}
+			continue
+		case platform.RunExtensionLabel:
+			e.Logger.Infof("Adding label '%s'", ext.Key)
+			if err := opts.WorkingImage.SetLabel(ext.Key, ext.Value); err != nil {
+				return errors.Wrapf(err, "set run extension label '%s'", ext.Key)
+			}
+			continue
+		default:
+			return fmt.Errorf("unsupported run extension instruction '%s'", ext.Instruction)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "creating layer '%s'", id)
+		}
+
+		if previousSHA(opts.OrigMetadata.RunImage.Extensions, layer.Digest) {
+			e.Logger.Infof("Reusing layer '%s'\n", layer.ID)
+			err = opts.WorkingImage.ReuseLayer(layer.Digest)
+		} else {
+			e.Logger.Infof("Adding layer '%s'\n", layer.ID)
+			err = opts.WorkingImage.AddLayerWithDiffID(layer.TarPath, layer.Digest)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "exporting layer '%s'", layer.ID)
+		}
+		e.Logger.Debugf("Layer '%s' SHA: %s\n", layer.ID, layer.Digest)
+		meta.RunImage.Extensions = append(meta.RunImage.Extensions, platform.LayerMetadata{SHA: layer.Digest})
+	}
+	return nil
+}
+
+func previousSHA(previous []platform.LayerMetadata, sha string) bool {
+	for _, layer := range previous {
+		if layer.SHA == sha {
+			return true
+		}
+	}
+	return false
+}
+
+// readSecrets reads the build-time secrets that layers are checked for, and warns about the values too short to check.
+func (e *Exporter) readSecrets(opts ExportOptions) (map[string]string, error) {
+	if opts.SecretsDir == "" {
+		return map[string]string{}, nil
+	}
+	secrets, err := env.ReadSecretsDir(opts.SecretsDir)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading build-time secrets")
+	}
+	for _, name := range shortSecrets(secrets) {
+		e.Logger.Warnf("Not checking layers for the value of secret '%s', it is shorter than %d characters", name, minSecretLength)
+	}
+	return secrets, nil
+}
+
+func (e *Exporter) addBuildpackLayers(opts ExportOptions, secrets map[string]string, meta *platform.LayersMetadata) error {
 	for _, bp := range e.Buildpacks {
 		bpDir, err := buildpack.ReadLayersDir(opts.LayersDir, bp, e.Logger)
 		e.Logger.Debugf("Processing buildpack directory: %s", bpDir.Path)
@@ -180,6 +274,9 @@ func (e *Exporter) addBuildpackLayers(opts ExportOptions, meta *platform.LayersM
 			}
 
 			if fsLayer.HasLocalContents() {
+				if err := e.checkLayerSecrets(opts, fsLayer.Identifier(), fsLayer.Path(), secrets); err != nil {
+					return err
+				}
 				layer, err := e.LayerFactory.DirLayer(fsLayer.Identifier(), fsLayer.Path())
 				if err != nil {
 					return errors.Wrapf(err, "creating layer")
@@ -220,6 +317,26 @@ func (e *Exporter) addBuildpackLayers(opts ExportOptions, meta *platform.LayersM
 	return nil
 }
 
+// checkLayerSecrets fails or warns, depending on the secrets policy, if the layer contains the value of a build-time secret.
+func (e *Exporter) checkLayerSecrets(opts ExportOptions, id, path string, secrets map[string]string) error {
+	name, file, err := findSecret(path, secrets)
+	if err != nil {
+		return errors.Wrapf(err, "checking layer '%s' for secrets", id)
+	}
+	if name == "" {
+		return nil
+	}
+	rel, err := filepath.Rel(path, file)
+	if err != nil {
+		rel = file
+	}
+	if opts.SecretsPolicy == SecretsPolicyWarn {
+		e.Logger.Warnf("Layer '%s' contains the value of secret '%s' in file '%s'", id, name, rel)
+		return nil
+	}
+	return fmt.Errorf("layer '%s' contains the value of secret '%s' in file '%s'", id, name, rel)
+}
+
 func (e *Exporter) addLauncherLayers(opts ExportOptions, buildMD *platform.BuildMetadata, meta *platform.LayersMetadata) error {
 	launcherLayer, err := e.LayerFactory.LauncherLayer(opts.LauncherConfig.Path)
 	if err != nil {
@@ -244,7 +361,12 @@ func (e *Exporter) addLauncherLayers(opts ExportOptions, buildMD *platform.Build
 	return nil
 }
 
-func (e *Exporter) addAppLayers(opts ExportOptions, slices []layers.Slice, meta *platform.LayersMetadata) error {
+func (e *Exporter) addAppLayers(opts ExportOptions, slices []layers.Slice, secrets map[string]string, meta *platform.LayersMetadata) error {
+	// every slice holds files from the app dir, checking the app dir checks all of them
+	if err := e.checkLayerSecrets(opts, "app", opts.AppDir, secrets); err != nil {
+		return err
+	}
+
 	// creating app layers (slices + app dir)
 	sliceLayers, err := e.LayerFactory.SliceLayers(opts.AppDir, slices)
 	if err != nil {
@@ -369,6 +491,29 @@ func (e *Exporter) setEnv(opts ExportOptions, launchMD launch.Metadata) error {
 	return nil
 }
 
+// HealthCheckSetter is implemented by images that support setting a Docker health check.
+type HealthCheckSetter interface {
+	SetHealthCheck(test []string, interval, timeout, startPeriod time.Duration, retries int) error
+}
+
+// setHealthCheck sets the health check of the default process, selected by the entrypoint, if it declares one.
+func (e *Exporter) setHealthCheck(opts ExportOptions, launchMD launch.Metadata, entrypoint string) error {
+	for _, proc := range launchMD.Processes {
+		if launch.ProcessPath(proc.Type) != entrypoint || proc.HealthCheck == nil {
+			continue
+		}
+		setter, ok := opts.WorkingImage.(HealthCheckSetter)
+		if !ok {
+			e.Logger.Warnf("Not setting health check for process type '%s', image does not support health checks, it is only recorded in the launch metadata", proc.Type)
+			return nil
+		}
+		e.Logger.Debugf("Setting health check for process type '%s'", proc.Type)
+		interval, timeout, startPeriod := proc.HealthCheck.Durations()
+		return setter.SetHealthCheck(launch.HealthCheckTest(proc.Type), interval, timeout, startPeriod, proc.HealthCheck.Retries)
+	}
+	return nil
+}
+
 func (e *Exporter) setWorkingDir(opts ExportOptions) error {
 	return opts.WorkingImage.SetWorkingDir(opts.AppDir)
 }
@@ -940,6 +940,134 @@ version = "4.5.6"
 				assertAddLayerLog(t, logHandler, "buildpack.id:layer2")
 			})
 
+			when("a launch layer contains a build-time secret", func() {
+				it.Before(func() {
+					opts.SecretsDir = filepath.Join(tmpDir, "secrets")
+					h.Mkdir(t, opts.SecretsDir)
+					h.Mkfile(t, "text from layer 1\n", filepath.Join(opts.SecretsDir, "some-secret"))
+				})
+
+				it("fails by default", func() {
+					_, err := exporter.Export(opts)
+					h.AssertError(t, err, "layer 'buildpack.id:layer1' contains the value of secret 'some-secret' in file 'file-from-layer-1'")
+					assertDoesNotHaveLayer(t, fakeAppImage, "buildpack.id:layer1")
+				})
+
+				it("warns when the policy is warn", func() {
+					opts.SecretsPolicy = lifecycle.SecretsPolicyWarn
+					_, err := exporter.Export(opts)
+					h.AssertNil(t, err)
+					assertLogEntry(t, logHandler, "Layer 'buildpack.id:layer1' contains the value of secret 'some-secret' in file 'file-from-layer-1'")
+					assertHasLayer(t, fakeAppImage, "buildpack.id:layer1")
+				})
+
+				it("finds values split across the chunks files are read in", func() {
+					h.Mkfile(t, "some-long-secret-value\n", filepath.Join(opts.SecretsDir, "other-secret"))
+					contents := strings.Repeat("a", 64*1024+16) + "some-long-secret-value"
+					h.Mkfile(t, contents, filepath.Join(opts.LayersDir, "buildpack.id", "layer1", "big-file"))
+
+					_, err := exporter.Export(opts)
+					h.AssertError(t, err, "layer 'buildpack.id:layer1' contains the value of secret 'other-secret' in file 'big-file'")
+				})
+			})
+
+			when("the app dir contains a build-time secret", func() {
+				it.Before(func() {
+					opts.SecretsDir = filepath.Join(tmpDir, "secrets")
+					h.Mkdir(t, opts.SecretsDir)
+					h.Mkfile(t, "PATH: $PATH\n", filepath.Join(opts.SecretsDir, "some-secret"))
+				})
+
+				it("fails before the app layers are created", func() {
+					_, err := exporter.Export(opts)
+					h.AssertError(t, err, "layer 'app' contains the value of secret 'some-secret' in file 'test_app.sh'")
+					assertDoesNotHaveLayer(t, fakeAppImage, "app")
+				})
+
+				it("warns when the policy is warn", func() {
+					opts.SecretsPolicy = lifecycle.SecretsPolicyWarn
+					_, err := exporter.Export(opts)
+					h.AssertNil(t, err)
+					assertLogEntry(t, logHandler, "Layer 'app' contains the value of secret 'some-secret' in file 'test_app.sh'")
+					assertHasLayer(t, fakeAppImage, "app")
+				})
+			})
+
+			when("a build-time secret is too short to check layers for", func() {
+				it.Before(func() {
+					opts.SecretsDir = filepath.Join(tmpDir, "secrets")
+					h.Mkdir(t, opts.SecretsDir)
+					h.Mkfile(t, "layer\n", filepath.Join(opts.SecretsDir, "some-secret"))
+				})
+
+				it("warns and exports the layers", func() {
+					_, err := exporter.Export(opts)
+					h.AssertNil(t, err)
+					assertLogEntry(t, logHandler, "Not checking layers for the value of secret 'some-secret', it is shorter than 8 characters")
+					assertHasLayer(t, fakeAppImage, "buildpack.id:layer1")
+				})
+			})
+
+			when("there are run extensions", func() {
+				it.Before(func() {
+					opts.RunExtensions = []platform.RunExtension{
+						{Instruction: platform.RunExtensionAdd, Source: "/some/packages.tar"},
+						{Instruction: platform.RunExtensionEnv, Key: "SOME_VAR", Value: "some-value"},
+						{Instruction: platform.RunExtensionCopy, Source: "/some/app.conf", Destination: "/etc/app.conf"},
+						{Instruction: platform.RunExtensionLabel, Key: "some.label", Value: "some-label-value"},
+					}
+					layerFactory.EXPECT().TarballLayer("run-extension-1", "/some/packages.tar").
+						DoAndReturn(func(id, _ string) (layers.Layer, error) { return createTestLayer(id, tmpDir) })
+					layerFactory.EXPECT().CopyLayer("run-extension-2", "/some/app.conf", "/etc/app.conf").
+						DoAndReturn(func(id, _, _ string) (layers.Layer, error) { return createTestLayer(id, tmpDir) })
+				})
+
+				it.After(func() {
+					opts.RunExtensions = nil
+					opts.OrigMetadata = platform.LayersMetadata{}
+				})
+
+				it("applies them and records the layers in the run image metadata", func() {
+					_, err := exporter.Export(opts)
+					h.AssertNil(t, err)
+
+					assertHasLayer(t, fakeAppImage, "run-extension-1")
+					assertAddLayerLog(t, logHandler, "run-extension-1")
+					assertHasLayer(t, fakeAppImage, "run-extension-2")
+					assertAddLayerLog(t, logHandler, "run-extension-2")
+
+					val, err := fakeAppImage.Env("SOME_VAR")
+					h.AssertNil(t, err)
+					h.AssertEq(t, val, "some-value")
+					label, err := fakeAppImage.Label("some.label")
+					h.AssertNil(t, err)
+					h.AssertEq(t, label, "some-label-value")
+
+					metadataJSON, err := fakeAppImage.Label("io.buildpacks.lifecycle.metadata")
+					h.AssertNil(t, err)
+					var md platform.LayersMetadata
+					h.AssertNil(t, json.Unmarshal([]byte(metadataJSON), &md))
+					h.AssertEq(t, md.RunImage.TopLayer, "some-top-layer-sha")
+					h.AssertEq(t, md.RunImage.Extensions, []platform.LayerMetadata{
+						{SHA: "run-extension-1-digest"},
+						{SHA: "run-extension-2-digest"},
+					})
+				})
+
+				it("reuses the layers of the previous image", func() {
+					opts.OrigMetadata.RunImage.Extensions = []platform.LayerMetadata{{SHA: "run-extension-1-digest"}}
+					previousLayer, err := createTestLayer("run-extension-1", tmpDir)
+					h.AssertNil(t, err)
+					fakeAppImage.AddPreviousLayer(previousLayer.Digest, previousLayer.TarPath)
+
+					_, err = exporter.Export(opts)
+					h.AssertNil(t, err)
+
+					assertReuseLayerLog(t, logHandler, "run-extension-1")
+					assertAddLayerLog(t, logHandler, "run-extension-2")
+				})
+			})
+
 			it("only creates expected layers", func() {
 				_, err := exporter.Export(opts)
 				h.AssertNil(t, err)
@@ -1162,6 +1290,37 @@ version = "4.5.6"
 				})
 			})
 
+			when("the default process declares a health check", func() {
+				it.Before(func() {
+					h.RecursiveCopy(t, filepath.Join("testdata", "exporter", "default-process", "metadata-with-health-check", "layers"), opts.LayersDir)
+					layerFactory.EXPECT().
+						ProcessTypesLayer(gomock.Any()).
+						DoAndReturn(func(_ launch.Metadata) (layers.Layer, error) {
+							return createTestLayer("process-types", tmpDir)
+						}).
+						AnyTimes()
+				})
+
+				it("sets the health check when the image supports it", func() {
+					image := &healthCheckImage{Image: fakeAppImage}
+					opts.WorkingImage = image
+
+					_, err := exporter.Export(opts)
+					h.AssertNil(t, err)
+
+					h.AssertEq(t, image.test, []string{"CMD", filepath.Join(rootDir, "cnb", "health", "some-process-type"+execExt)})
+					h.AssertEq(t, image.interval, 10*time.Second)
+					h.AssertEq(t, image.retries, 3)
+				})
+
+				it("warns when the image does not support health checks", func() {
+					_, err := exporter.Export(opts)
+					h.AssertNil(t, err)
+
+					assertLogEntry(t, logHandler, "Not setting health check for process type 'some-process-type'")
+				})
+			})
+
 			when("platform API < 0.6", func() {
 				it.Before(func() {
 					exporter.PlatformAPI = api.MustParse("0.5")
@@ -1515,6 +1674,18 @@ version = "4.5.6"
 	})
 }
 
+type healthCheckImage struct {
+	*fakes.Image
+	test     []string
+	interval time.Duration
+	retries  int
+}
+
+func (i *healthCheckImage) SetHealthCheck(test []string, interval, _, _ time.Duration, retries int) error {
+	i.test, i.interval, i.retries = test, interval, retries
+	return nil
+}
+
 func assertHasEntrypoint(t *testing.T, image *fakes.Image, entrypointPath string) {
 	ep, err := image.Entrypoint()
 	h.AssertNil(t, err)
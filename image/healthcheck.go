@@ -0,0 +1,73 @@
+package image
+
+import (
+	"reflect"
+	"time"
+	"unsafe"
+
+	"github.com/buildpacks/imgutil"
+	"github.com/buildpacks/imgutil/local"
+	imgutilremote "github.com/buildpacks/imgutil/remote"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/pkg/errors"
+)
+
+// HealthCheckImage is an image that can set a Docker health check in its config.
+// imgutil cannot set a health check, so it is set on the config of the image that an imgutil remote image writes on Save.
+// Images saved to a docker daemon do not support health checks.
+type HealthCheckImage struct {
+	imgutil.Image
+}
+
+// NewHealthCheckImage wraps an image so that a health check can be set in its config before it is saved.
+func NewHealthCheckImage(image imgutil.Image) *HealthCheckImage {
+	return &HealthCheckImage{Image: image}
+}
+
+// SetHealthCheck sets the health check in the config of the image, it is saved with the image on Save.
+func (i *HealthCheckImage) SetHealthCheck(test []string, interval, timeout, startPeriod time.Duration, retries int) error {
+	var remoteImage *imgutilremote.Image
+	switch img := i.Image.(type) {
+	case *imgutilremote.Image:
+		remoteImage = img
+	case *local.Image:
+		return errors.New("health checks are not supported when exporting to a docker daemon")
+	default:
+		return errors.Errorf("health checks are not supported by image '%s'", i.Name())
+	}
+
+	field, err := v1ImageOf(remoteImage)
+	if err != nil {
+		return err
+	}
+	img := field.Interface().(v1.Image)
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return errors.Wrap(err, "get image config")
+	}
+	configFile = configFile.DeepCopy()
+	configFile.Config.Healthcheck = &v1.HealthConfig{
+		Test:        test,
+		Interval:    interval,
+		Timeout:     timeout,
+		StartPeriod: startPeriod,
+		Retries:     retries,
+	}
+	if img, err = mutate.ConfigFile(img, configFile); err != nil {
+		return errors.Wrap(err, "set health check")
+	}
+	field.Set(reflect.ValueOf(&img).Elem())
+	return nil
+}
+
+var v1ImageType = reflect.TypeOf((*v1.Image)(nil)).Elem()
+
+// v1ImageOf returns a settable value for the unexported v1.Image that the imgutil remote image writes to the registry on Save.
+func v1ImageOf(img *imgutilremote.Image) (reflect.Value, error) {
+	field := reflect.ValueOf(img).Elem().FieldByName("image")
+	if !field.IsValid() || field.Type() != v1ImageType {
+		return reflect.Value{}, errors.New("health checks are not supported by this version of imgutil")
+	}
+	return reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem(), nil // #nosec G103
+}
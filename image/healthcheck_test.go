@@ -0,0 +1,153 @@
+package image_test
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/imgutil/local"
+	imgutilremote "github.com/buildpacks/imgutil/remote"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/image"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestHealthCheckImage(t *testing.T) {
+	spec.Run(t, "HealthCheckImage", testHealthCheckImage, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testHealthCheckImage(t *testing.T, when spec.G, it spec.S) {
+	var (
+		subject      *image.HealthCheckImage
+		server       *httptest.Server
+		host         string
+		manifestPuts int32
+	)
+
+	readConfig := func(ref string) (*v1.ConfigFile, string) {
+		parsed, err := name.ParseReference(ref, name.WeakValidation)
+		h.AssertNil(t, err)
+		img, err := remote.Image(parsed)
+		h.AssertNil(t, err)
+		configFile, err := img.ConfigFile()
+		h.AssertNil(t, err)
+		digest, err := img.Digest()
+		h.AssertNil(t, err)
+		return configFile, digest.String()
+	}
+
+	it.Before(func() {
+		handler := registry.New(registry.Logger(log.New(ioutil.Discard, "", 0)))
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodPut && strings.Contains(r.URL.Path, "/manifests/") {
+				atomic.AddInt32(&manifestPuts, 1)
+			}
+			handler.ServeHTTP(w, r)
+		}))
+		serverURL, err := url.Parse(server.URL)
+		h.AssertNil(t, err)
+		host = serverURL.Host
+
+		base, err := random.Image(100, 1)
+		h.AssertNil(t, err)
+		configFile, err := base.ConfigFile()
+		h.AssertNil(t, err)
+		configFile.OS, configFile.Architecture = "linux", "amd64"
+		base, err = mutate.ConfigFile(base, configFile)
+		h.AssertNil(t, err)
+		baseRef, err := name.ParseReference(host+"/some/run-image", name.WeakValidation)
+		h.AssertNil(t, err)
+		h.AssertNil(t, remote.Write(baseRef, base))
+		atomic.StoreInt32(&manifestPuts, 0)
+
+		appImage, err := imgutilremote.NewImage(host+"/some/app", authn.DefaultKeychain, imgutilremote.FromBaseImage(baseRef.Name()))
+		h.AssertNil(t, err)
+		h.AssertNil(t, appImage.SetLabel("some-label", "some-value"))
+		subject = image.NewHealthCheckImage(appImage)
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	when("#Save", func() {
+		when("a health check is set", func() {
+			it("writes the health check to the config of every saved name", func() {
+				test := []string{"CMD", "/cnb/health/web"}
+				h.AssertNil(t, subject.SetHealthCheck(test, 10*time.Second, 5*time.Second, time.Second, 3))
+
+				h.AssertNil(t, subject.Save(host+"/some/app:other"))
+
+				for _, ref := range []string{host + "/some/app", host + "/some/app:other"} {
+					configFile, _ := readConfig(ref)
+					h.AssertEq(t, configFile.Config.Healthcheck, &v1.HealthConfig{
+						Test:        test,
+						Interval:    10 * time.Second,
+						Timeout:     5 * time.Second,
+						StartPeriod: time.Second,
+						Retries:     3,
+					})
+					h.AssertEq(t, configFile.Config.Labels["some-label"], "some-value")
+				}
+			})
+
+			it("pushes one manifest per saved name", func() {
+				h.AssertNil(t, subject.SetHealthCheck([]string{"CMD", "/cnb/health/web"}, 0, 0, 0, 0))
+
+				h.AssertNil(t, subject.Save(host+"/some/app:other"))
+
+				h.AssertEq(t, atomic.LoadInt32(&manifestPuts), int32(2))
+			})
+
+			it("identifies the image with the health check", func() {
+				h.AssertNil(t, subject.SetHealthCheck([]string{"CMD", "/cnb/health/web"}, 0, 0, 0, 0))
+
+				h.AssertNil(t, subject.Save())
+
+				_, digest := readConfig(host + "/some/app")
+				id, err := subject.Identifier()
+				h.AssertNil(t, err)
+				h.AssertEq(t, id.(imgutilremote.DigestIdentifier).Digest.DigestStr(), digest)
+			})
+		})
+
+		when("no health check is set", func() {
+			it("saves the image as is", func() {
+				h.AssertNil(t, subject.Save())
+
+				configFile, digest := readConfig(host + "/some/app")
+				h.AssertNil(t, configFile.Config.Healthcheck)
+				id, err := subject.Identifier()
+				h.AssertNil(t, err)
+				h.AssertEq(t, id.(imgutilremote.DigestIdentifier).Digest.DigestStr(), digest)
+			})
+		})
+	})
+
+	when("#SetHealthCheck", func() {
+		when("the image is saved to a docker daemon", func() {
+			it("errors", func() {
+				subject = image.NewHealthCheckImage(&local.Image{})
+
+				err := subject.SetHealthCheck([]string{"CMD", "/cnb/health/web"}, 0, 0, 0, 0)
+
+				h.AssertError(t, err, "health checks are not supported when exporting to a docker daemon")
+			})
+		})
+	})
+}
@@ -1,15 +1,21 @@
 package image
 
 import (
-	"github.com/buildpacks/imgutil/remote"
+	"fmt"
+	"net/http"
+	"strings"
+
 	"github.com/google/go-containerregistry/pkg/authn"
 	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/remote/transport"
 	"github.com/pkg/errors"
 )
 
 type RegistryInputs interface {
 	ReadableRegistryImages() []string
 	WriteableRegistryImages() []string
+	PreviousRegistryImage() string // one of the readable images, that may not exist yet; empty if there is none
 }
 
 // ValidateDestinationTags ensures all tags are valid
@@ -36,34 +42,95 @@ func ValidateDestinationTags(daemon bool, repoNames ...string) error {
 	return nil
 }
 
-func VerifyRegistryAccess(regInputs RegistryInputs, keychain authn.Keychain) error {
-	for _, imageRef := range regInputs.ReadableRegistryImages() {
-		err := verifyReadAccess(imageRef, keychain)
-		if err != nil {
-			return err
+// RegistryAccess is the result of checking access to an image reference in a registry.
+type RegistryAccess struct {
+	Reference string
+	Write     bool  // whether write access was checked, read access is always checked
+	Err       error // nil when access was granted
+}
+
+func (a RegistryAccess) String() string {
+	access := "read"
+	if a.Write {
+		access = "read/write"
+	}
+	if a.Err != nil {
+		return fmt.Sprintf("%s access to '%s': %s", access, a.Reference, a.Err)
+	}
+	return fmt.Sprintf("%s access to '%s': ok", access, a.Reference)
+}
+
+// RegistryAccessReport lists the access checks of every readable and writeable image reference.
+type RegistryAccessReport struct {
+	Checks []RegistryAccess
+}
+
+// Failed returns the checks that were denied access.
+func (r RegistryAccessReport) Failed() []RegistryAccess {
+	var failed []RegistryAccess
+	for _, check := range r.Checks {
+		if check.Err != nil {
+			failed = append(failed, check)
 		}
 	}
+	return failed
+}
+
+// Err returns an error listing the checks that were denied access, or nil if there are none.
+func (r RegistryAccessReport) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	var lines []string
+	for _, check := range failed {
+		lines = append(lines, "  "+check.String())
+	}
+	return errors.Errorf("ensure registry access to %d of %d image references:\n%s", len(failed), len(r.Checks), strings.Join(lines, "\n"))
+}
+
+// CheckRegistryAccess checks that the keychain can read every readable image and read and write every writeable image.
+// Readable images must exist, except for the previous image. Writeable images and the previous image
+// that do not exist yet are accessible as long as access to them is not denied.
+// Write access is checked by initiating a blob upload, which is cancelled right away.
+func CheckRegistryAccess(regInputs RegistryInputs, keychain authn.Keychain) RegistryAccessReport {
+	var report RegistryAccessReport
+	previousImageRef := regInputs.PreviousRegistryImage()
+	for _, imageRef := range regInputs.ReadableRegistryImages() {
+		err := checkReadAccess(imageRef, keychain, imageRef == previousImageRef)
+		report.Checks = append(report.Checks, RegistryAccess{Reference: imageRef, Err: err})
+	}
 	for _, imageRef := range regInputs.WriteableRegistryImages() {
-		err := verifyReadWriteAccess(imageRef, keychain)
-		if err != nil {
-			return err
-		}
+		report.Checks = append(report.Checks, RegistryAccess{Reference: imageRef, Write: true, Err: checkReadWriteAccess(imageRef, keychain)})
 	}
-	return nil
+	return report
 }
 
-func verifyReadAccess(imageRef string, keychain authn.Keychain) error {
-	img, _ := remote.NewImage(imageRef, keychain)
-	if !img.CheckReadAccess() {
-		return errors.Errorf("ensure registry read access to %s", imageRef)
+func checkReadAccess(imageRef string, keychain authn.Keychain, allowMissing bool) error {
+	ref, err := name.ParseReference(imageRef, name.WeakValidation)
+	if err != nil {
+		return err
+	}
+	if _, err := remote.Head(ref, remote.WithAuthFromKeychain(keychain)); err != nil {
+		var transportErr *transport.Error
+		if errors.As(err, &transportErr) && transportErr.StatusCode == http.StatusNotFound {
+			if allowMissing {
+				return nil
+			}
+			return errors.Wrap(err, "image not found")
+		}
+		return err
 	}
 	return nil
 }
 
-func verifyReadWriteAccess(imageRef string, keychain authn.Keychain) error {
-	img, _ := remote.NewImage(imageRef, keychain)
-	if !img.CheckReadWriteAccess() {
-		return errors.Errorf("ensure registry read/write access to %s", imageRef)
+func checkReadWriteAccess(imageRef string, keychain authn.Keychain) error {
+	if err := checkReadAccess(imageRef, keychain, true); err != nil {
+		return err
 	}
-	return nil
+	ref, err := name.ParseReference(imageRef, name.WeakValidation)
+	if err != nil {
+		return err
+	}
+	return remote.CheckPushPermission(ref, keychain, http.DefaultTransport)
 }
@@ -1,10 +1,21 @@
 package image_test
 
 import (
+	"encoding/base64"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
 	"github.com/sclevine/spec"
 
+	"github.com/buildpacks/lifecycle/auth"
 	"github.com/buildpacks/lifecycle/image"
 	h "github.com/buildpacks/lifecycle/testhelpers"
 )
@@ -44,4 +55,113 @@ func testImage(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 	})
+
+	when("#CheckRegistryAccess", func() {
+		var (
+			server *httptest.Server
+			host   string
+		)
+
+		keychainFor := func(user string) *auth.ResolvedKeychain {
+			return &auth.ResolvedKeychain{Auths: map[string]string{
+				host: "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":password")),
+			}}
+		}
+
+		it.Before(func() {
+			server = httptest.NewServer(authRegistry(map[string]bool{"reader": false, "writer": true}))
+			serverURL, err := url.Parse(server.URL)
+			h.AssertNil(t, err)
+			host = serverURL.Host
+
+			img, err := random.Image(100, 1)
+			h.AssertNil(t, err)
+			ref, err := name.ParseReference(host+"/some/run-image", name.WeakValidation)
+			h.AssertNil(t, err)
+			h.AssertNil(t, remote.Write(ref, img, remote.WithAuthFromKeychain(keychainFor("writer"))))
+		})
+
+		it.After(func() {
+			server.Close()
+		})
+
+		it("grants access to existing images and to a missing previous image or writeable image", func() {
+			report := image.CheckRegistryAccess(&registryInputs{
+				readable:  []string{host + "/some/run-image", host + "/some/previous-image"},
+				writeable: []string{host + "/some/app-image", host + "/some/cache-image"},
+				previous:  host + "/some/previous-image",
+			}, keychainFor("writer"))
+
+			h.AssertEq(t, len(report.Checks), 4)
+			h.AssertEq(t, len(report.Failed()), 0)
+			h.AssertNil(t, report.Err())
+		})
+
+		it("reports readable images that do not exist", func() {
+			report := image.CheckRegistryAccess(&registryInputs{
+				readable: []string{host + "/some/missing-run-image", host + "/some/previous-image"},
+				previous: host + "/some/previous-image",
+			}, keychainFor("writer"))
+
+			failed := report.Failed()
+			h.AssertEq(t, len(failed), 1)
+			h.AssertEq(t, failed[0].Reference, host+"/some/missing-run-image")
+			h.AssertStringContains(t, failed[0].Err.Error(), "image not found")
+			h.AssertStringContains(t, failed[0].Err.Error(), "404")
+		})
+
+		it("reports every image that cannot be written", func() {
+			report := image.CheckRegistryAccess(&registryInputs{
+				readable:  []string{host + "/some/run-image"},
+				writeable: []string{host + "/some/app-image", host + "/some/cache-image"},
+			}, keychainFor("reader"))
+
+			failed := report.Failed()
+			h.AssertEq(t, len(failed), 2)
+			h.AssertEq(t, failed[0].Reference, host+"/some/app-image")
+			h.AssertEq(t, failed[0].Write, true)
+			h.AssertEq(t, failed[1].Reference, host+"/some/cache-image")
+			h.AssertError(t, report.Err(), "ensure registry access to 2 of 3 image references:\n  read/write access to '"+host+"/some/app-image': ")
+		})
+
+		it("reports images that cannot be read", func() {
+			report := image.CheckRegistryAccess(&registryInputs{
+				readable: []string{host + "/some/run-image"},
+			}, keychainFor("stranger"))
+
+			failed := report.Failed()
+			h.AssertEq(t, len(failed), 1)
+			h.AssertEq(t, failed[0].Write, false)
+			h.AssertStringContains(t, failed[0].String(), "read access to '"+host+"/some/run-image': ")
+			h.AssertStringContains(t, failed[0].Err.Error(), "401 Unauthorized")
+		})
+	})
+}
+
+type registryInputs struct {
+	readable, writeable []string
+	previous            string
+}
+
+func (r *registryInputs) ReadableRegistryImages() []string  { return r.readable }
+func (r *registryInputs) WriteableRegistryImages() []string { return r.writeable }
+func (r *registryInputs) PreviousRegistryImage() string     { return r.previous }
+
+// authRegistry is a registry requiring basic auth with password "password", users mapping to whether they can write.
+func authRegistry(users map[string]bool) http.Handler {
+	reg := registry.New(registry.Logger(log.New(ioutil.Discard, "", 0)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		canWrite, known := users[user]
+		if !ok || !known || password != "password" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && !canWrite {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		reg.ServeHTTP(w, r)
+	})
 }
@@ -0,0 +1,85 @@
+package image
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/docker/docker/client"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/pkg/errors"
+)
+
+// RegistryLayerLister lists the layers of images in a registry.
+type RegistryLayerLister struct {
+	Keychain authn.Keychain
+}
+
+// Layers returns the diff IDs of the layers of the image, from the bottom up.
+func (l *RegistryLayerLister) Layers(imageName string) ([]string, error) {
+	ref, err := name.ParseReference(imageName, name.WeakValidation)
+	if err != nil {
+		return nil, err
+	}
+	img, err := remote.Image(ref, remote.WithAuthFromKeychain(l.Keychain))
+	if err != nil {
+		return nil, errors.Wrapf(err, "get image '%s'", imageName)
+	}
+	configFile, err := img.ConfigFile()
+	if err != nil {
+		return nil, errors.Wrapf(err, "get config of image '%s'", imageName)
+	}
+	var diffIDs []string
+	for _, diffID := range configFile.RootFS.DiffIDs {
+		diffIDs = append(diffIDs, diffID.String())
+	}
+	return diffIDs, nil
+}
+
+// Variant returns the platform variant in the config of the image.
+// The config is decoded as go-containerregistry does not read the variant.
+func (l *RegistryLayerLister) Variant(imageName string) (string, error) {
+	ref, err := name.ParseReference(imageName, name.WeakValidation)
+	if err != nil {
+		return "", err
+	}
+	img, err := remote.Image(ref, remote.WithAuthFromKeychain(l.Keychain))
+	if err != nil {
+		return "", errors.Wrapf(err, "get image '%s'", imageName)
+	}
+	rawConfig, err := img.RawConfigFile()
+	if err != nil {
+		return "", errors.Wrapf(err, "get config of image '%s'", imageName)
+	}
+	var config struct {
+		Variant string `json:"variant"`
+	}
+	if err := json.Unmarshal(rawConfig, &config); err != nil {
+		return "", errors.Wrapf(err, "decode config of image '%s'", imageName)
+	}
+	return config.Variant, nil
+}
+
+// DaemonLayerLister lists the layers of images in a docker daemon.
+type DaemonLayerLister struct {
+	Docker client.CommonAPIClient
+}
+
+// Layers returns the diff IDs of the layers of the image, from the bottom up.
+func (l *DaemonLayerLister) Layers(imageName string) ([]string, error) {
+	inspect, _, err := l.Docker.ImageInspectWithRaw(context.Background(), imageName)
+	if err != nil {
+		return nil, errors.Wrapf(err, "inspect image '%s'", imageName)
+	}
+	return inspect.RootFS.Layers, nil
+}
+
+// Variant returns the platform variant in the config of the image.
+func (l *DaemonLayerLister) Variant(imageName string) (string, error) {
+	inspect, _, err := l.Docker.ImageInspectWithRaw(context.Background(), imageName)
+	if err != nil {
+		return "", errors.Wrapf(err, "inspect image '%s'", imageName)
+	}
+	return inspect.Variant, nil
+}
@@ -0,0 +1,86 @@
+package image
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+
+	"github.com/buildpacks/lifecycle/platform"
+)
+
+// DefaultMirrorProbeTimeout is the time a registry is given to respond to a run image mirror probe.
+const DefaultMirrorProbeTimeout = 10 * time.Second
+
+// MirrorResolver selects a run image mirror by probing the registries serving it.
+type MirrorResolver struct {
+	Keychain authn.Keychain
+	Timeout  time.Duration // for each probe, defaults to DefaultMirrorProbeTimeout
+}
+
+// Resolve probes every mirror concurrently and selects the first reachable one in the given priority order.
+// Reachable mirrors serving a different digest than the selected mirror are recorded as inconsistent.
+// It errors when none of the mirrors is reachable, the returned selection still records the probe results.
+func (r *MirrorResolver) Resolve(mirrors []string) (platform.RunImageMirrorSelection, error) {
+	probes := make([]platform.RunImageMirror, len(mirrors))
+	var wg sync.WaitGroup
+	for i, mirror := range mirrors {
+		wg.Add(1)
+		go func(i int, mirror string) {
+			defer wg.Done()
+			probes[i] = r.probe(mirror)
+		}(i, mirror)
+	}
+	wg.Wait()
+
+	var selection platform.RunImageMirrorSelection
+	for _, probed := range probes {
+		selection.Mirrors = append(selection.Mirrors, probed)
+		if !probed.Reachable {
+			continue
+		}
+		if selection.Selected == "" {
+			selection.Selected, selection.Digest = probed.Reference, probed.Digest
+			continue
+		}
+		if probed.Digest != selection.Digest {
+			selection.Inconsistent = append(selection.Inconsistent, probed.Reference)
+		}
+	}
+	if selection.Selected == "" {
+		var reasons []string
+		for _, probed := range selection.Mirrors {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", probed.Reference, probed.Error))
+		}
+		return selection, fmt.Errorf("none of the run image mirrors are reachable: %s", strings.Join(reasons, "; "))
+	}
+	return selection, nil
+}
+
+func (r *MirrorResolver) probe(mirror string) platform.RunImageMirror {
+	probed := platform.RunImageMirror{Reference: mirror}
+	ref, err := name.ParseReference(mirror, name.WeakValidation)
+	if err != nil {
+		probed.Error = err.Error()
+		return probed
+	}
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = DefaultMirrorProbeTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	desc, err := remote.Head(ref, remote.WithAuthFromKeychain(r.Keychain), remote.WithContext(ctx))
+	if err != nil {
+		probed.Error = err.Error()
+		return probed
+	}
+	probed.Reachable = true
+	probed.Digest = desc.Digest.String()
+	return probed
+}
@@ -0,0 +1,154 @@
+package image_test
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/random"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/image"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestMirrorResolver(t *testing.T) {
+	spec.Run(t, "MirrorResolver", testMirrorResolver, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testMirrorResolver(t *testing.T, when spec.G, it spec.S) {
+	var (
+		subject                    *image.MirrorResolver
+		primary, secondary         *httptest.Server
+		primaryHost, secondaryHost string
+		runImage                   v1.Image
+		runImageDigest             string
+	)
+
+	startRegistry := func() (*httptest.Server, string) {
+		server := httptest.NewServer(registry.New(registry.Logger(log.New(ioutil.Discard, "", 0))))
+		serverURL, err := url.Parse(server.URL)
+		h.AssertNil(t, err)
+		return server, serverURL.Host
+	}
+
+	push := func(ref string, img v1.Image) {
+		tag, err := name.NewTag(ref, name.WeakValidation)
+		h.AssertNil(t, err)
+		h.AssertNil(t, remote.Write(tag, img))
+	}
+
+	it.Before(func() {
+		primary, primaryHost = startRegistry()
+		secondary, secondaryHost = startRegistry()
+
+		var err error
+		runImage, err = random.Image(100, 1)
+		h.AssertNil(t, err)
+		digest, err := runImage.Digest()
+		h.AssertNil(t, err)
+		runImageDigest = digest.String()
+
+		subject = &image.MirrorResolver{Keychain: authn.DefaultKeychain}
+	})
+
+	it.After(func() {
+		primary.Close()
+		secondary.Close()
+	})
+
+	when("#Resolve", func() {
+		it("selects the first reachable mirror", func() {
+			push(secondaryHost+"/some/run", runImage)
+			push(primaryHost+"/other/run", runImage)
+
+			selection, err := subject.Resolve([]string{
+				primaryHost + "/some/run",
+				secondaryHost + "/some/run",
+				primaryHost + "/other/run",
+			})
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, selection.Selected, secondaryHost+"/some/run")
+			h.AssertEq(t, selection.Digest, runImageDigest)
+			h.AssertEq(t, len(selection.Inconsistent), 0)
+			h.AssertEq(t, len(selection.Mirrors), 3)
+			h.AssertEq(t, selection.Mirrors[0].Reachable, false)
+			h.AssertStringContains(t, selection.Mirrors[0].Error, "404 Not Found")
+			h.AssertEq(t, selection.Mirrors[1].Reachable, true)
+			h.AssertEq(t, selection.Mirrors[1].Digest, runImageDigest)
+			h.AssertEq(t, selection.Mirrors[2].Reachable, true)
+			h.AssertEq(t, selection.Mirrors[2].Digest, runImageDigest)
+		})
+
+		it("records reachable mirrors serving a different digest", func() {
+			otherImage, err := random.Image(100, 1)
+			h.AssertNil(t, err)
+			push(primaryHost+"/some/run", runImage)
+			push(secondaryHost+"/some/run", otherImage)
+
+			selection, err := subject.Resolve([]string{primaryHost + "/some/run", secondaryHost + "/some/run"})
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, selection.Selected, primaryHost+"/some/run")
+			h.AssertEq(t, selection.Digest, runImageDigest)
+			h.AssertEq(t, selection.Inconsistent, []string{secondaryHost + "/some/run"})
+		})
+
+		it("errors when no mirror is reachable", func() {
+			secondary.Close()
+
+			selection, err := subject.Resolve([]string{primaryHost + "/some/run", secondaryHost + "/some/run"})
+			h.AssertError(t, err, "none of the run image mirrors are reachable: "+primaryHost+"/some/run: ")
+
+			h.AssertEq(t, selection.Selected, "")
+			h.AssertEq(t, len(selection.Mirrors), 2)
+			h.AssertEq(t, selection.Mirrors[1].Reachable, false)
+		})
+
+		it("records mirrors that do not respond in time as unreachable", func() {
+			push(secondaryHost+"/some/run", runImage)
+			unblock := make(chan struct{})
+			slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-unblock
+			}))
+			defer slow.Close()
+			defer close(unblock)
+			slowURL, err := url.Parse(slow.URL)
+			h.AssertNil(t, err)
+			subject.Timeout = 500 * time.Millisecond
+
+			start := time.Now()
+			selection, err := subject.Resolve([]string{slowURL.Host + "/some/run", secondaryHost + "/some/run"})
+			h.AssertNil(t, err)
+
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Fatalf("expected the probe to time out, took %s", elapsed)
+			}
+			h.AssertEq(t, selection.Selected, secondaryHost+"/some/run")
+			h.AssertEq(t, selection.Mirrors[0].Reachable, false)
+			h.AssertStringContains(t, selection.Mirrors[0].Error, "context deadline exceeded")
+		})
+
+		it("records unparsable mirrors as unreachable", func() {
+			push(primaryHost+"/some/run", runImage)
+
+			selection, err := subject.Resolve([]string{"as@ohd@as@op", primaryHost + "/some/run"})
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, selection.Selected, primaryHost+"/some/run")
+			h.AssertEq(t, selection.Mirrors[0].Reachable, false)
+			h.AssertStringContains(t, selection.Mirrors[0].Error, "could not parse reference")
+		})
+	})
+}
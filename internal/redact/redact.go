@@ -0,0 +1,140 @@
+package redact
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces every occurrence of a secret value.
+const Mask = "********"
+
+// Redactor masks secret values in log output.
+// A nil Redactor masks nothing.
+type Redactor struct {
+	replacer *strings.Replacer
+	values   [][]byte // longest first
+}
+
+// New returns a Redactor masking the given secret values.
+// Values are also masked without surrounding whitespace, since secret files commonly end with a newline.
+func New(secrets []string) *Redactor {
+	seen := map[string]bool{}
+	var values []string
+	for _, secret := range secrets {
+		for _, v := range []string{secret, strings.TrimSpace(secret)} {
+			if v == "" || seen[v] {
+				continue
+			}
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	// the replacer tries values in order, longer values must win over values they contain
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	var (
+		oldnew  []string
+		bvalues [][]byte
+	)
+	for _, v := range values {
+		oldnew = append(oldnew, v, Mask)
+		bvalues = append(bvalues, []byte(v))
+	}
+	return &Redactor{replacer: strings.NewReplacer(oldnew...), values: bvalues}
+}
+
+// String returns s with every secret value masked.
+func (r *Redactor) String(s string) string {
+	if r == nil {
+		return s
+	}
+	return r.replacer.Replace(s)
+}
+
+// Writer returns a writer that masks secret values before writing to w.
+// Output is written line by line so that values split across writes are masked; Flush writes any incomplete line.
+// Lines end with '\n' or '\r', so that progress output that redraws a line is written as it is redrawn.
+// Lines that may be followed by the rest of a multi-line value are held until the value is complete or ruled out.
+func (r *Redactor) Writer(w io.Writer) *Writer {
+	return &Writer{redactor: r, w: w}
+}
+
+// Writer masks secret values in the output written to it.
+type Writer struct {
+	redactor *Redactor
+	w        io.Writer
+
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	if w.redactor == nil {
+		return w.w.Write(p)
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	out, n := w.redactor.maskLines(w.buf)
+	if n == 0 {
+		return len(p), nil
+	}
+	w.buf = append([]byte{}, w.buf[n:]...)
+	if _, err := w.w.Write(out); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// maskLines masks the complete lines at the start of buf and returns them with the number of bytes of buf they were read from.
+// It stops at a value that may continue past the end of buf, since the next write may complete it.
+// Values are matched like the replacer does, longest first at each position.
+func (r *Redactor) maskLines(buf []byte) ([]byte, int) {
+	var out []byte
+	outLen, n := 0, 0
+	for pos := 0; pos < len(buf); {
+		matched, pending := 0, false
+		for _, v := range r.values {
+			if bytes.HasPrefix(buf[pos:], v) {
+				matched = len(v)
+				break
+			}
+			if bytes.HasPrefix(v, buf[pos:]) {
+				pending = true
+				break
+			}
+		}
+		if pending {
+			break
+		}
+		if matched > 0 {
+			out = append(out, Mask...)
+			pos += matched
+		} else {
+			out = append(out, buf[pos])
+			pos++
+		}
+		if c := buf[pos-1]; c == '\n' || c == '\r' {
+			outLen, n = len(out), pos
+		}
+	}
+	return out[:outLen], n
+}
+
+// Flush writes any buffered incomplete line.
+func (w *Writer) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) == 0 {
+		return nil
+	}
+	rest := w.buf
+	w.buf = nil
+	_, err := io.WriteString(w.w, w.redactor.String(string(rest)))
+	return err
+}
@@ -0,0 +1,94 @@
+package redact_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/internal/redact"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestRedact(t *testing.T) {
+	spec.Run(t, "Redact", testRedact, spec.Report(report.Terminal{}))
+}
+
+func testRedact(t *testing.T, when spec.G, it spec.S) {
+	when("#String", func() {
+		it("masks every secret value", func() {
+			r := redact.New([]string{"some-secret\n", "other-secret"})
+			h.AssertEq(t, r.String("a some-secret and other-secret"), "a ******** and ********")
+		})
+
+		it("masks longer values before values they contain", func() {
+			r := redact.New([]string{"secret", "secret-and-more"})
+			h.AssertEq(t, r.String("secret-and-more!"), "********!")
+		})
+
+		it("masks nothing without secrets", func() {
+			r := redact.New([]string{"", " \n"})
+			h.AssertEq(t, r.String("some-message"), "some-message")
+		})
+	})
+
+	when("#Writer", func() {
+		it("masks values split across writes", func() {
+			out := &bytes.Buffer{}
+			w := redact.New([]string{"some-secret"}).Writer(out)
+
+			_, err := w.Write([]byte("token: some-"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "")
+			_, err = w.Write([]byte("secret\nmore some-secret"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "token: ********\n")
+
+			h.AssertNil(t, w.Flush())
+			h.AssertEq(t, out.String(), "token: ********\nmore ********")
+		})
+
+		it("masks multi-line values split across writes at a line end", func() {
+			out := &bytes.Buffer{}
+			w := redact.New([]string{"-----BEGIN KEY-----\nsome-key\n-----END KEY-----"}).Writer(out)
+
+			_, err := w.Write([]byte("key:\n-----BEGIN KEY-----\n"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "key:\n")
+			_, err = w.Write([]byte("some-key\n"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "key:\n")
+			_, err = w.Write([]byte("-----END KEY-----\ndone\n"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "key:\n********\ndone\n")
+		})
+
+		it("writes held lines once they cannot be the start of a value", func() {
+			out := &bytes.Buffer{}
+			w := redact.New([]string{"first-line\nsecond-line"}).Writer(out)
+
+			_, err := w.Write([]byte("first-line\n"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "")
+			_, err = w.Write([]byte("other-line\n"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "first-line\nother-line\n")
+		})
+
+		it("writes lines ending with a carriage return", func() {
+			out := &bytes.Buffer{}
+			w := redact.New([]string{"some-secret"}).Writer(out)
+
+			_, err := w.Write([]byte("10% some-secret\r"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "10% ********\r")
+			_, err = w.Write([]byte("20% some-"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "10% ********\r")
+			_, err = w.Write([]byte("secret\r"))
+			h.AssertNil(t, err)
+			h.AssertEq(t, out.String(), "10% ********\r20% ********\r")
+		})
+	})
+}
@@ -0,0 +1,174 @@
+package launch
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/env"
+)
+
+// DryRunFlag is the launcher argument that prints the resolved process instead of launching it.
+const DryRunFlag = "--dry-run"
+
+// DryRunResult describes how a process would be launched.
+type DryRunResult struct {
+	Type             string         `json:"type,omitempty"`
+	Command          string         `json:"command"`
+	Args             []string       `json:"args"`
+	Direct           bool           `json:"direct"`
+	WorkingDirectory string         `json:"working-dir"`
+	Profiles         []string       `json:"profiles"` // in the order they would be sourced, empty for direct processes unless they are interpreted
+	Env              []DryRunEnvVar `json:"env"`
+	History          env.History    `json:"history,omitempty"` // every change to each variable, if the env records it
+}
+
+// DryRunEnvVar is a variable of the final environment of a process.
+type DryRunEnvVar struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source,omitempty"` // the env file, layer directory or exec.d file that last changed the variable
+}
+
+// DryRun resolves the process for cmd and its environment the same way Launch does, without launching it.
+// exec.d files are executed, since their output is part of the environment.
+func (l *Launcher) DryRun(cmd []string) (DryRunResult, error) {
+	proc, err := l.ProcessFor(cmd)
+	if err != nil {
+		return DryRunResult{}, errors.Wrap(err, "determine start command")
+	}
+	if err := os.Chdir(l.AppDir); err != nil {
+		return DryRunResult{}, errors.Wrap(err, "change to app directory")
+	}
+
+	history := l.envHistory() // changes are recorded in place while l.Env is wrapped
+	recorder := newEnvRecorder(l.Env)
+	origEnv := l.Env
+	l.Env = recorder
+	defer func() { l.Env = origEnv }()
+
+	if err := l.doEnv(proc.Type); err != nil {
+		return DryRunResult{}, errors.Wrap(err, "modify env")
+	}
+	if err := l.doExecD(proc.Type); err != nil {
+		return DryRunResult{}, errors.Wrap(err, "exec.d")
+	}
+
+	result := DryRunResult{
+		Type:             proc.Type,
+		Command:          proc.Command,
+		Args:             proc.Args,
+		Direct:           proc.Direct,
+		WorkingDirectory: getProcessWorkingDirectory(proc, l.AppDir),
+		Profiles:         []string{},
+		Env:              recorder.vars(),
+		History:          history,
+	}
+	if result.Args == nil {
+		result.Args = []string{}
+	}
+	switch {
+	case !proc.Direct:
+		profiles, err := l.getProfiles(proc.Type)
+		if err != nil {
+			return DryRunResult{}, errors.Wrap(err, "find profiles")
+		}
+		result.Profiles = append(result.Profiles, profiles...)
+	case l.InterpretProfiles:
+		profiles, err := l.interpretProfiles(proc.Type)
+		if err != nil {
+			return DryRunResult{}, errors.Wrap(err, "profile scripts")
+		}
+		result.Profiles = append(result.Profiles, profiles...)
+		result.Env = recorder.vars()
+	}
+	return result, nil
+}
+
+// envRecorder records which file last changed each variable of the wrapped Env.
+type envRecorder struct {
+	Env
+	sources map[string]string
+}
+
+func newEnvRecorder(e Env) *envRecorder {
+	return &envRecorder{Env: e, sources: map[string]string{}}
+}
+
+func (r *envRecorder) AddRootDir(baseDir string) error {
+	before := r.snapshot()
+	if err := r.Env.AddRootDir(baseDir); err != nil {
+		return err
+	}
+	r.attribute(before, func(string) string { return baseDir })
+	return nil
+}
+
+func (r *envRecorder) AddEnvDir(envDir string, defaultAction env.ActionType) error {
+	before := r.snapshot()
+	if err := r.Env.AddEnvDir(envDir, defaultAction); err != nil {
+		return err
+	}
+	r.attribute(before, func(name string) string { return envFileFor(envDir, name) })
+	return nil
+}
+
+func (r *envRecorder) Set(name, k string) {
+	r.SetFrom(name, k, "")
+}
+
+// SetFrom is called by the ExecDRunner with the exec.d file that set the variable.
+func (r *envRecorder) SetFrom(name, k, source string) {
+	setFrom(r.Env, name, k, source)
+	r.sources[name] = source
+}
+
+func (r *envRecorder) snapshot() map[string]string {
+	vals := map[string]string{}
+	for _, kv := range r.Env.List() {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) == 2 {
+			vals[parts[0]] = parts[1]
+		}
+	}
+	return vals
+}
+
+func (r *envRecorder) attribute(before map[string]string, source func(name string) string) {
+	for name, val := range r.snapshot() {
+		if prev, ok := before[name]; !ok || prev != val {
+			r.sources[name] = source(name)
+		}
+	}
+}
+
+func (r *envRecorder) vars() []DryRunEnvVar {
+	var result []DryRunEnvVar
+	for name, val := range r.snapshot() {
+		result = append(result, DryRunEnvVar{Name: name, Value: val, Source: r.sources[name]})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// envFileFor returns the env file in envDir that sets the variable name, e.g. <envDir>/PATH.prepend.
+func envFileFor(envDir, name string) string {
+	fis, err := ioutil.ReadDir(envDir)
+	if err != nil {
+		return envDir
+	}
+	for _, fi := range fis {
+		fileName := fi.Name()
+		if fi.IsDir() || strings.HasSuffix(fileName, ".delim") {
+			continue
+		}
+		if fileName == name || strings.HasPrefix(fileName, name+".") {
+			return filepath.Join(envDir, fileName)
+		}
+	}
+	return envDir
+}
@@ -0,0 +1,174 @@
+package launch_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/env"
+	"github.com/buildpacks/lifecycle/launch"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestDryRun(t *testing.T) {
+	spec.Run(t, "DryRun", testDryRun, spec.Sequential(), spec.Report(report.Terminal{}))
+}
+
+func testDryRun(t *testing.T, when spec.G, it spec.S) {
+	var (
+		launcher  *launch.Launcher
+		tmpDir    string
+		layerDir  string
+		wd        string
+		appDir    string
+		layersDir string
+	)
+
+	it.Before(func() {
+		h.SkipIf(t, runtime.GOOS == "windows", "skip dry run tests on windows")
+		var err error
+		wd, err = os.Getwd()
+		h.AssertNil(t, err)
+		tmpDir, err = ioutil.TempDir("", "lifecycle.launch.dry-run")
+		h.AssertNil(t, err)
+		appDir = filepath.Join(tmpDir, "app")
+		layersDir = filepath.Join(tmpDir, "layers")
+		layerDir = filepath.Join(layersDir, "some-buildpack", "some-layer")
+		h.Mkdir(t,
+			appDir,
+			filepath.Join(layerDir, "bin"),
+			filepath.Join(layerDir, "env"),
+			filepath.Join(layerDir, "env.launch", "web"),
+			filepath.Join(layerDir, "exec.d"),
+			filepath.Join(layerDir, "profile.d", "web"),
+		)
+
+		launcher = &launch.Launcher{
+			AppDir:      appDir,
+			LayersDir:   layersDir,
+			Buildpacks:  []launch.Buildpack{{API: api.Buildpack.Latest().String(), ID: "some-buildpack"}},
+			PlatformAPI: api.Platform.Latest(),
+			Processes: []launch.Process{
+				{Type: "web", Command: "some-cmd", Args: []string{"some-arg"}},
+				{Type: "worker", Command: "other-cmd", Direct: true, WorkingDirectory: "/some/dir"},
+			},
+			Env:   env.NewLaunchEnv([]string{"PATH=/usr/bin", "UNTOUCHED=some-val"}, launch.ProcessDir, launch.LifecycleDir),
+			ExecD: launch.NewExecDRunner(),
+		}
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.Chdir(wd))
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	findVar := func(result launch.DryRunResult, name string) launch.DryRunEnvVar {
+		for _, v := range result.Env {
+			if v.Name == name {
+				return v
+			}
+		}
+		t.Fatalf("env var %s not found in %+v", name, result.Env)
+		return launch.DryRunEnvVar{}
+	}
+
+	when("#DryRun", func() {
+		it("resolves the process", func() {
+			launcher.DefaultProcessType = "web"
+
+			result, err := launcher.DryRun([]string{"extra-arg"})
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, result.Type, "web")
+			h.AssertEq(t, result.Command, "some-cmd")
+			h.AssertEq(t, result.Args, []string{"some-arg", "extra-arg"})
+			h.AssertEq(t, result.WorkingDirectory, appDir)
+		})
+
+		it("uses the working directory of the process", func() {
+			launcher.DefaultProcessType = "worker"
+
+			result, err := launcher.DryRun(nil)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, result.WorkingDirectory, "/some/dir")
+			h.AssertEq(t, result.Profiles, []string{})
+		})
+
+		it("annotates env vars with the file that last changed them", func() {
+			h.Mkfile(t, "first", filepath.Join(layerDir, "env", "SOME_VAR.override"))
+			h.Mkfile(t, "second", filepath.Join(layerDir, "env.launch", "web", "SOME_VAR.override"))
+			h.Mkfile(t, "#!/bin/sh\necho 'EXECD_VAR = \"from-exec-d\"' >&3\n", filepath.Join(layerDir, "exec.d", "some-exec-d"))
+			h.AssertNil(t, os.Chmod(filepath.Join(layerDir, "exec.d", "some-exec-d"), 0755))
+			launcher.DefaultProcessType = "web"
+
+			result, err := launcher.DryRun(nil)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, findVar(result, "SOME_VAR"), launch.DryRunEnvVar{
+				Name:   "SOME_VAR",
+				Value:  "second",
+				Source: filepath.Join(layerDir, "env.launch", "web", "SOME_VAR.override"),
+			})
+			h.AssertEq(t, findVar(result, "EXECD_VAR").Source, filepath.Join(layerDir, "exec.d", "some-exec-d"))
+			h.AssertEq(t, findVar(result, "PATH").Value, filepath.Join(layerDir, "bin")+":/usr/bin")
+			h.AssertEq(t, findVar(result, "PATH").Source, layerDir)
+			h.AssertEq(t, findVar(result, "UNTOUCHED").Source, "")
+		})
+
+		it("includes the env history when it is recorded", func() {
+			h.Mkfile(t, "first", filepath.Join(layerDir, "env", "SOME_VAR.override"))
+			h.Mkfile(t, "second", filepath.Join(layerDir, "env.launch", "web", "SOME_VAR.override"))
+			launchEnv := env.NewLaunchEnv([]string{"PATH=/usr/bin"}, launch.ProcessDir, launch.LifecycleDir)
+			launchEnv.History = env.History{}
+			launcher.Env = launchEnv
+			launcher.DefaultProcessType = "web"
+
+			result, err := launcher.DryRun(nil)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, result.History["SOME_VAR"], []env.Change{
+				{Source: filepath.Join(layerDir, "env", "SOME_VAR.override"), Action: env.ActionTypeOverride, Value: "first"},
+				{Source: filepath.Join(layerDir, "env.launch", "web", "SOME_VAR.override"), Action: env.ActionTypeOverride, Value: "second"},
+			})
+		})
+
+		it("includes interpreted profile scripts of direct processes", func() {
+			h.Mkfile(t, "export PROFILE_VAR=some-val\n", filepath.Join(layerDir, "profile.d", "some-profile"))
+			launcher.DefaultProcessType = "worker"
+			launcher.InterpretProfiles = true
+
+			result, err := launcher.DryRun(nil)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, result.Profiles, []string{filepath.Join(layerDir, "profile.d", "some-profile")})
+			h.AssertEq(t, findVar(result, "PROFILE_VAR"), launch.DryRunEnvVar{
+				Name:   "PROFILE_VAR",
+				Value:  "some-val",
+				Source: filepath.Join(layerDir, "profile.d", "some-profile"),
+			})
+		})
+
+		it("lists the profile scripts in order", func() {
+			h.Mkfile(t, "", filepath.Join(layerDir, "profile.d", "some-profile"))
+			h.Mkfile(t, "", filepath.Join(layerDir, "profile.d", "web", "web-profile"))
+			h.Mkfile(t, "", filepath.Join(appDir, ".profile"))
+			launcher.DefaultProcessType = "web"
+
+			result, err := launcher.DryRun(nil)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, result.Profiles, []string{
+				filepath.Join(layerDir, "profile.d", "some-profile"),
+				filepath.Join(layerDir, "profile.d", "web", "web-profile"),
+				".profile",
+			})
+		})
+	})
+}
@@ -1,18 +1,27 @@
 package launch
 
 import (
+	"bytes"
+	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/pkg/errors"
 )
 
+// execDStderrTailLines is the number of lines of stderr included in an ExecDError.
+const execDStderrTailLines = 10
+
 // ExecDRunner is responsible for running ExecD binaries.
 type ExecDRunner struct {
-	Out, Err io.Writer // Out and Err can be used to configure Stdout and Stderr processes run by ExecDRunner.
+	Out, Err io.Writer     // Out and Err can be used to configure Stdout and Stderr processes run by ExecDRunner.
+	Timeout  time.Duration // Timeout is the maximum time each binary may run, zero means no limit.
 }
 
 // NewExecDRunner creates an ExecDRunner with Out and Err set to stdout and stderr
@@ -23,42 +32,194 @@ func NewExecDRunner() *ExecDRunner {
 	}
 }
 
+// ExecDError is returned when an exec.d binary fails.
+type ExecDError struct {
+	BuildpackID string // BuildpackID and Layer are set by the Launcher
+	Layer       string
+	Path        string
+	ExitCode    int    // ExitCode is -1 if the binary did not exit on its own
+	Stderr      string // Stderr contains the last lines the binary wrote to stderr
+	Err         error
+}
+
+func (e *ExecDError) Error() string {
+	msg := fmt.Sprintf("failed to execute exec.d file at path '%s'", e.Path)
+	if e.BuildpackID != "" {
+		msg += fmt.Sprintf(" (buildpack '%s', layer '%s')", e.BuildpackID, e.Layer)
+	}
+	if e.ExitCode >= 0 {
+		msg += fmt.Sprintf(" with exit code %d", e.ExitCode)
+	}
+	msg += ": " + e.Err.Error()
+	if e.Stderr != "" {
+		msg += "\nstderr:\n" + e.Stderr
+	}
+	return msg
+}
+
+func (e *ExecDError) Unwrap() error {
+	return e.Err
+}
+
 // ExecD executes the executable file at path and sets the returned variables in env. The executable at path
 // should implement the ExecD interface in the buildpack specification https://github.com/buildpacks/spec/blob/main/buildpack.md#execd
+// If the executable fails, the returned error is an *ExecDError.
+// Children of the executable that are still running when it exits do not keep ExecD waiting, their output may be lost.
 func (e *ExecDRunner) ExecD(path string, env Env) error {
-	pr, pw, err := os.Pipe()
+	cmd := exec.Command(path)
+	cmd.Env = env.List()
+	setProcessGroup(cmd) // so that children of the binary are killed with it on timeout
+
+	var pipes []*outputPipe
+	defer func() {
+		for _, p := range pipes {
+			p.close()
+		}
+	}()
+	newPipe := func(dst io.Writer) (*os.File, error) {
+		p, err := newOutputPipe(dst)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to create pipe")
+		}
+		pipes = append(pipes, p)
+		return p.w, nil
+	}
+
+	var out bytes.Buffer
+	outFile, err := newPipe(&out)
 	if err != nil {
-		return errors.Wrap(err, "failed to create pipe")
+		return err
 	}
-	errChan := make(chan error, 1)
-	go func() {
-		defer pw.Close()
-		cmd := exec.Command(path)
-		cmd.Stdout = e.Out
-		cmd.Stderr = e.Err
-		cmd.Env = env.List()
-		if err := setHandle(cmd, pw); err != nil {
-			errChan <- err
-		} else {
-			errChan <- cmd.Run()
+	if err := setHandle(cmd, outFile); err != nil {
+		return err
+	}
+	stderr := &tailWriter{}
+	if cmd.Stderr, err = newPipe(io.MultiWriter(e.Err, stderr)); err != nil {
+		return err
+	}
+	cmd.Stdout = e.Out
+	if _, ok := e.Out.(*os.File); !ok && e.Out != nil {
+		if cmd.Stdout, err = newPipe(e.Out); err != nil {
+			return err
 		}
-	}()
+	}
+
+	if err := cmd.Start(); err != nil {
+		return &ExecDError{Path: path, ExitCode: -1, Err: err}
+	}
+	for _, p := range pipes {
+		p.closeWriter()
+	}
+	var timedOut int32
+	if e.Timeout > 0 {
+		timer := time.AfterFunc(e.Timeout, func() {
+			atomic.StoreInt32(&timedOut, 1)
+			_ = kill(cmd.Process)
+		})
+		defer timer.Stop()
+	}
+	cmdErr := cmd.Wait()
+	var readErr error
+	for _, p := range pipes {
+		if err := p.finish(); err != nil && readErr == nil {
+			readErr = err
+		}
+	}
 
-	out, err := ioutil.ReadAll(pr)
-	if cmdErr := <-errChan; cmdErr != nil {
+	if cmdErr != nil {
 		// prefer the error from the command
-		return errors.Wrapf(cmdErr, "failed to execute exec.d file at path '%s'", path)
-	} else if err != nil {
+		execDErr := &ExecDError{Path: path, ExitCode: -1, Stderr: stderr.tail(execDStderrTailLines), Err: cmdErr}
+		if atomic.LoadInt32(&timedOut) == 1 {
+			execDErr.Err = fmt.Errorf("timed out after %s", e.Timeout)
+		} else if exitErr, ok := cmdErr.(*exec.ExitError); ok {
+			execDErr.ExitCode = exitErr.ExitCode()
+		}
+		return execDErr
+	} else if readErr != nil {
 		// return the read error only if the command succeeded
-		return errors.Wrapf(err, "failed to read output from exec.d file at path '%s'", path)
+		return errors.Wrapf(readErr, "failed to read output from exec.d file at path '%s'", path)
 	}
 
 	envVars := map[string]string{}
-	if _, err := toml.Decode(string(out), &envVars); err != nil {
+	if _, err := toml.Decode(out.String(), &envVars); err != nil {
 		return errors.Wrapf(err, "failed to decode output from exec.d file at path '%s'", path)
 	}
 	for k, v := range envVars {
-		env.Set(k, v)
+		setFrom(env, k, v, path)
 	}
 	return nil
 }
+
+// outputPipe copies what a process writes to the write end of a pipe to dst.
+// The write end is closed by the runner once the process has started, and the pipe is not read until EOF,
+// see finish, so that children of the process that inherit the pipe do not keep the runner waiting.
+type outputPipe struct {
+	r, w *os.File
+	dst  io.Writer
+	done chan error
+}
+
+func newOutputPipe(dst io.Writer) (*outputPipe, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	p := &outputPipe{r: r, w: w, dst: dst, done: make(chan error, 1)}
+	go func() {
+		_, err := io.Copy(dst, r)
+		p.done <- err
+	}()
+	return p, nil
+}
+
+// closeWriter closes the runner's copy of the write end, the process has its own.
+func (p *outputPipe) closeWriter() {
+	_ = p.w.Close()
+}
+
+func (p *outputPipe) close() {
+	_ = p.w.Close()
+	_ = p.r.Close()
+}
+
+// sourceSetter is implemented by envs that record where changes come from, e.g. env.Env.
+type sourceSetter interface {
+	SetFrom(name, v, source string)
+}
+
+func setFrom(env Env, name, v, source string) {
+	if recorder, ok := env.(sourceSetter); ok {
+		recorder.SetFrom(name, v, source)
+	} else {
+		env.Set(name, v)
+	}
+}
+
+// tailWriter keeps the last bytes written to it.
+type tailWriter struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+const tailWriterSize = 4096
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > tailWriterSize {
+		w.buf = w.buf[len(w.buf)-tailWriterSize:]
+	}
+	return len(p), nil
+}
+
+// tail returns the last n lines written.
+func (w *tailWriter) tail(n int) string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	lines := strings.Split(strings.TrimRight(string(w.buf), "\n"), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
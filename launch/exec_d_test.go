@@ -99,5 +99,73 @@ func testExecD(t *testing.T, when spec.G, it spec.S) {
 			h.AssertNil(t, runner.ExecD(path, env))
 			h.AssertEq(t, errOut.String(), "stderr from execd\n")
 		})
+
+		writeScript := func(script string) string {
+			scriptPath := filepath.Join(tmpDir, "some-exec-d")
+			h.AssertNil(t, ioutil.WriteFile(scriptPath, []byte("#!/bin/sh\n"+script), 0700)) // #nosec G306
+			return scriptPath
+		}
+
+		when("the binary starts a long-running child", func() {
+			it.Before(func() {
+				h.SkipIf(t, runtime.GOOS == "windows", "skip exec.d script tests on windows")
+			})
+
+			it("does not wait for the child, which holds stderr and the output handle", func() {
+				scriptPath := writeScript("sleep 10 &\necho 'SOME_VAR = \"some-val\"' >&3\necho 'stderr from script' >&2\n")
+				env.EXPECT().List().Return([]string{})
+				env.EXPECT().Set("SOME_VAR", "some-val")
+
+				start := time.Now()
+				h.AssertNil(t, runner.ExecD(scriptPath, env))
+				if elapsed := time.Since(start); elapsed > 5*time.Second {
+					t.Fatalf("expected ExecD to return when the binary exits, took %s", elapsed)
+				}
+				h.AssertEq(t, errOut.String(), "stderr from script\n")
+			})
+
+			it("kills the child with the binary when it times out", func() {
+				scriptPath := writeScript("sleep 10 &\nsleep 10\n")
+				env.EXPECT().List().Return([]string{})
+				runner.Timeout = 100 * time.Millisecond
+
+				start := time.Now()
+				err := runner.ExecD(scriptPath, env)
+				h.AssertError(t, err, "timed out after 100ms")
+				if elapsed := time.Since(start); elapsed > 5*time.Second {
+					t.Fatalf("expected ExecD to return on timeout, took %s", elapsed)
+				}
+			})
+		})
+
+		when("the binary fails", func() {
+			it.Before(func() {
+				h.SkipIf(t, runtime.GOOS == "windows", "skip exec.d script tests on windows")
+			})
+
+			it("returns an error with the exit code and the end of stderr", func() {
+				scriptPath := writeScript("for i in 1 2 3 4 5 6 7 8 9 10 11 12; do echo \"line $i\" >&2; done\nexit 3\n")
+				env.EXPECT().List().Return([]string{})
+
+				err := runner.ExecD(scriptPath, env)
+				execDErr, ok := err.(*launch.ExecDError)
+				if !ok {
+					t.Fatalf("expected an ExecDError, got %v", err)
+				}
+				h.AssertEq(t, execDErr.Path, scriptPath)
+				h.AssertEq(t, execDErr.ExitCode, 3)
+				h.AssertEq(t, execDErr.Stderr, "line 3\nline 4\nline 5\nline 6\nline 7\nline 8\nline 9\nline 10\nline 11\nline 12")
+			})
+
+			it("kills the binary when it times out", func() {
+				scriptPath := writeScript("exec sleep 10\n")
+				env.EXPECT().List().Return([]string{})
+				runner.Timeout = 100 * time.Millisecond
+
+				err := runner.ExecD(scriptPath, env)
+				h.AssertError(t, err, "timed out after 100ms")
+				h.AssertEq(t, err.(*launch.ExecDError).ExitCode, -1)
+			})
+		})
 	})
 }
@@ -4,11 +4,65 @@
 package launch
 
 import (
+	"io"
 	"os"
 	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 func setHandle(cmd *exec.Cmd, f *os.File) error {
 	cmd.ExtraFiles = []*os.File{f}
 	return nil
 }
+
+// finish copies the rest of the output once the process has exited.
+// Everything the process wrote is already in the pipe, so the pipe is drained without waiting for EOF,
+// which does not come while children of the process hold the write end.
+func (p *outputPipe) finish() error {
+	if err := p.r.SetReadDeadline(time.Now()); err != nil {
+		return err
+	}
+	if err := <-p.done; err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+		return err
+	}
+	if err := p.r.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+	return drain(p.r, p.dst)
+}
+
+// drain copies what can be read from f to dst without blocking.
+func drain(f *os.File, dst io.Writer) error {
+	rc, err := f.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var (
+		buf  = make([]byte, 32*1024)
+		rerr error
+	)
+	if err := rc.Read(func(fd uintptr) bool {
+		for {
+			n, err := syscall.Read(int(fd), buf)
+			if n > 0 {
+				if _, rerr = dst.Write(buf[:n]); rerr != nil {
+					return true
+				}
+				continue
+			}
+			if err == syscall.EINTR {
+				continue
+			}
+			if err != nil && err != syscall.EAGAIN {
+				rerr = err
+			}
+			return true // EOF, or nothing left to read
+		}
+	}); err != nil {
+		return err
+	}
+	return rerr
+}
@@ -23,3 +23,8 @@ func setHandle(cmd *exec.Cmd, f *os.File) error {
 	cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%#x", EnvExecDHandle, handle))
 	return nil
 }
+
+// finish waits until the pipe is closed, pipes cannot be drained without blocking on windows.
+func (p *outputPipe) finish() error {
+	return <-p.done
+}
@@ -0,0 +1,170 @@
+package launch
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const defaultHealthCheckTimeout = 30 * time.Second
+
+const (
+	HealthCheckHTTP = "http"
+	HealthCheckTCP  = "tcp"
+	HealthCheckExec = "exec"
+)
+
+// HealthCheck declares how to check that a process is healthy.
+// HTTP and TCP checks connect to Port on localhost, exec checks run Command with the env of the process.
+type HealthCheck struct {
+	Type        string   `toml:"type" json:"type"`
+	Port        int      `toml:"port,omitempty" json:"port,omitempty"`
+	Path        string   `toml:"path,omitempty" json:"path,omitempty"` // http only, defaults to /
+	Command     []string `toml:"command,omitempty" json:"command,omitempty"`
+	Interval    string   `toml:"interval,omitempty" json:"interval,omitempty"`
+	Timeout     string   `toml:"timeout,omitempty" json:"timeout,omitempty"`
+	StartPeriod string   `toml:"start-period,omitempty" json:"start-period,omitempty"`
+	Retries     int      `toml:"retries,omitempty" json:"retries,omitempty"`
+}
+
+// Validate returns an error if the health check is incomplete or its durations cannot be parsed.
+func (h HealthCheck) Validate() error {
+	switch h.Type {
+	case HealthCheckHTTP, HealthCheckTCP:
+		if h.Port <= 0 || h.Port > 65535 {
+			return fmt.Errorf("%s health check requires a port between 1 and 65535", h.Type)
+		}
+	case HealthCheckExec:
+		if len(h.Command) == 0 {
+			return errors.New("exec health check requires a command")
+		}
+	default:
+		return fmt.Errorf("unknown health check type '%s', must be one of: http, tcp, exec", h.Type)
+	}
+	for _, d := range []struct{ name, value string }{
+		{"interval", h.Interval},
+		{"timeout", h.Timeout},
+		{"start-period", h.StartPeriod},
+	} {
+		if _, err := parseHealthCheckDuration(d.value); err != nil {
+			return errors.Wrapf(err, "parsing health check %s", d.name)
+		}
+	}
+	if h.Retries < 0 {
+		return errors.New("health check retries must not be negative")
+	}
+	return nil
+}
+
+// Durations returns the parsed interval, timeout and start period, zero when unset.
+func (h HealthCheck) Durations() (interval, timeout, startPeriod time.Duration) {
+	interval, _ = parseHealthCheckDuration(h.Interval)
+	timeout, _ = parseHealthCheckDuration(h.Timeout)
+	startPeriod, _ = parseHealthCheckDuration(h.StartPeriod)
+	return interval, timeout, startPeriod
+}
+
+func parseHealthCheckDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// HealthCheckTest returns the Docker health check test that runs the health check of the process type with the launcher,
+// invoked through the symlink at HealthCheckPath.
+func HealthCheckTest(procType string) []string {
+	return []string{"CMD", HealthCheckPath(procType)}
+}
+
+// CheckHealth runs the health check declared by the process type and returns an error if the process is unhealthy.
+func (l *Launcher) CheckHealth(procType string) error {
+	proc, ok := l.findProcessType(procType)
+	if !ok {
+		return fmt.Errorf("process type %s was not found", procType)
+	}
+	if proc.HealthCheck == nil {
+		return fmt.Errorf("process type %s does not declare a health check", procType)
+	}
+	check := *proc.HealthCheck
+	if err := check.Validate(); err != nil {
+		return err
+	}
+	_, timeout, _ := check.Durations()
+	if timeout == 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+
+	switch check.Type {
+	case HealthCheckHTTP:
+		return checkHTTP(check, timeout)
+	case HealthCheckTCP:
+		return checkTCP(check, timeout)
+	default:
+		return l.checkExec(proc, check, timeout)
+	}
+}
+
+func checkHTTP(check HealthCheck, timeout time.Duration) error {
+	path := check.Path
+	if path == "" {
+		path = "/"
+	}
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get("http://" + net.JoinHostPort("localhost", strconv.Itoa(check.Port)) + path)
+	if err != nil {
+		return errors.Wrap(err, "http health check")
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		return fmt.Errorf("http health check: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func checkTCP(check HealthCheck, timeout time.Duration) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort("localhost", strconv.Itoa(check.Port)), timeout)
+	if err != nil {
+		return errors.Wrap(err, "tcp health check")
+	}
+	return conn.Close()
+}
+
+// checkExec runs the health check command with the env the process would be launched with.
+func (l *Launcher) checkExec(proc Process, check HealthCheck, timeout time.Duration) error {
+	if err := os.Chdir(l.AppDir); err != nil {
+		return errors.Wrap(err, "change to app directory")
+	}
+	if err := l.doEnv(proc.Type); err != nil {
+		return errors.Wrap(err, "modify env")
+	}
+	if err := l.doExecD(proc.Type); err != nil {
+		return errors.Wrap(err, "exec.d")
+	}
+	if err := l.Setenv("PATH", l.Env.Get("PATH")); err != nil {
+		return errors.Wrap(err, "set path")
+	}
+	binary, err := exec.LookPath(check.Command[0])
+	if err != nil {
+		return errors.Wrap(err, "path lookup")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	c := exec.CommandContext(ctx, binary, check.Command[1:]...) // #nosec G204
+	c.Dir = getProcessWorkingDirectory(proc, l.AppDir)
+	c.Env = l.Env.List()
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return errors.Wrap(err, "exec health check")
+	}
+	return nil
+}
@@ -0,0 +1,151 @@
+package launch_test
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"strconv"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/env"
+	"github.com/buildpacks/lifecycle/launch"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestHealth(t *testing.T) {
+	spec.Run(t, "Health", testHealth, spec.Sequential(), spec.Report(report.Terminal{}))
+}
+
+func testHealth(t *testing.T, when spec.G, it spec.S) {
+	when("HealthCheck", func() {
+		when("#Validate", func() {
+			it("accepts complete health checks", func() {
+				h.AssertNil(t, launch.HealthCheck{Type: "http", Port: 8080, Path: "/healthz", Interval: "30s"}.Validate())
+				h.AssertNil(t, launch.HealthCheck{Type: "tcp", Port: 8080}.Validate())
+				h.AssertNil(t, launch.HealthCheck{Type: "exec", Command: []string{"some-cmd"}}.Validate())
+			})
+
+			it("rejects incomplete health checks", func() {
+				h.AssertError(t, launch.HealthCheck{Type: "tcp"}.Validate(), "tcp health check requires a port between 1 and 65535")
+				h.AssertError(t, launch.HealthCheck{Type: "exec"}.Validate(), "exec health check requires a command")
+				h.AssertError(t, launch.HealthCheck{Type: "some-type"}.Validate(), "unknown health check type 'some-type'")
+			})
+
+			it("rejects invalid durations", func() {
+				err := launch.HealthCheck{Type: "tcp", Port: 8080, Timeout: "soon"}.Validate()
+				h.AssertError(t, err, "parsing health check timeout")
+			})
+		})
+	})
+
+	when("Launcher", func() {
+		var (
+			launcher *launch.Launcher
+			tmpDir   string
+			wd       string
+		)
+
+		it.Before(func() {
+			var err error
+			wd, err = os.Getwd()
+			h.AssertNil(t, err)
+			tmpDir, err = ioutil.TempDir("", "lifecycle.launch.health")
+			h.AssertNil(t, err)
+			launcher = &launch.Launcher{
+				AppDir:      tmpDir,
+				LayersDir:   tmpDir,
+				PlatformAPI: api.Platform.Latest(),
+				Env:         env.NewLaunchEnv(os.Environ(), launch.ProcessDir, launch.LifecycleDir),
+				ExecD:       launch.NewExecDRunner(),
+				Setenv:      os.Setenv,
+			}
+		})
+
+		it.After(func() {
+			h.AssertNil(t, os.Chdir(wd))
+			h.AssertNil(t, os.RemoveAll(tmpDir))
+		})
+
+		withHealthCheck := func(check launch.HealthCheck) {
+			launcher.Processes = []launch.Process{{Type: "web", Command: "some-cmd", HealthCheck: &check}}
+		}
+
+		when("#CheckHealth", func() {
+			it("errors when the process type does not declare a health check", func() {
+				launcher.Processes = []launch.Process{{Type: "web", Command: "some-cmd"}}
+				h.AssertError(t, launcher.CheckHealth("web"), "process type web does not declare a health check")
+			})
+
+			it("errors when the process type does not exist", func() {
+				h.AssertError(t, launcher.CheckHealth("missing"), "process type missing was not found")
+			})
+
+			when("http", func() {
+				var (
+					server *httptest.Server
+					port   int
+				)
+
+				it.Before(func() {
+					server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+						if r.URL.Path != "/healthz" {
+							w.WriteHeader(http.StatusServiceUnavailable)
+						}
+					}))
+					_, portStr, err := net.SplitHostPort(server.Listener.Addr().String())
+					h.AssertNil(t, err)
+					port, err = strconv.Atoi(portStr)
+					h.AssertNil(t, err)
+				})
+
+				it.After(func() {
+					server.Close()
+				})
+
+				it("succeeds when the endpoint responds successfully", func() {
+					withHealthCheck(launch.HealthCheck{Type: "http", Port: port, Path: "/healthz"})
+					h.AssertNil(t, launcher.CheckHealth("web"))
+				})
+
+				it("fails when the endpoint responds with an error", func() {
+					withHealthCheck(launch.HealthCheck{Type: "http", Port: port, Path: "/other"})
+					h.AssertError(t, launcher.CheckHealth("web"), "unexpected status 503")
+				})
+			})
+
+			when("tcp", func() {
+				it("succeeds when the port accepts connections", func() {
+					listener, err := net.Listen("tcp", "127.0.0.1:0")
+					h.AssertNil(t, err)
+					defer listener.Close()
+
+					withHealthCheck(launch.HealthCheck{Type: "tcp", Port: listener.Addr().(*net.TCPAddr).Port})
+					h.AssertNil(t, launcher.CheckHealth("web"))
+				})
+			})
+
+			when("exec", func() {
+				it.Before(func() {
+					h.SkipIf(t, runtime.GOOS == "windows", "skip exec health check tests on windows")
+				})
+
+				it("succeeds when the command succeeds", func() {
+					withHealthCheck(launch.HealthCheck{Type: "exec", Command: []string{"sh", "-c", "exit 0"}})
+					h.AssertNil(t, launcher.CheckHealth("web"))
+				})
+
+				it("fails when the command fails", func() {
+					withHealthCheck(launch.HealthCheck{Type: "exec", Command: []string{"sh", "-c", "exit 1"}})
+					h.AssertError(t, launcher.CheckHealth("web"), "exec health check")
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,5 @@
+package launch
+
+func setSubreaper() error {
+	return nil
+}
@@ -0,0 +1,8 @@
+package launch
+
+import "golang.org/x/sys/unix"
+
+// setSubreaper makes orphaned descendants of the launcher its children, so they can be reaped when it is not PID 1.
+func setSubreaper() error {
+	return unix.Prctl(unix.PR_SET_CHILD_SUBREAPER, 1, 0, 0, 0)
+}
@@ -0,0 +1,125 @@
+package launch_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/launch"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+const (
+	initHelperScriptEnv      = "LIFECYCLE_TEST_INIT_SCRIPT"
+	initHelperCheckReapedEnv = "LIFECYCLE_TEST_INIT_CHECK_REAPED"
+)
+
+func TestInit(t *testing.T) {
+	if script := os.Getenv(initHelperScriptEnv); script != "" {
+		runInitHelper(script)
+		return
+	}
+	spec.Run(t, "Init", testInit, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+// runInitHelper runs the script with RunInit and exits with its status.
+// It runs in a separate test process because RunInit makes the process a subreaper and receives every signal.
+func runInitHelper(script string) {
+	status, err := launch.RunInit("/bin/sh", []string{"sh", "-c", script}, os.Environ())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(100)
+	}
+	if os.Getenv(initHelperCheckReapedEnv) != "" {
+		if pid, err := syscall.Wait4(-1, nil, syscall.WNOHANG, nil); err != syscall.ECHILD {
+			fmt.Fprintf(os.Stderr, "expected no children, found pid %d: %v\n", pid, err)
+			os.Exit(101)
+		}
+	}
+	os.Exit(status)
+}
+
+func testInit(t *testing.T, when spec.G, it spec.S) {
+	var tmpDir string
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "lifecycle.launch.init")
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	initCmd := func(script string, env ...string) *exec.Cmd {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestInit$") // #nosec G204
+		cmd.Env = append(os.Environ(), append(env, initHelperScriptEnv+"="+script)...)
+		cmd.Stderr = os.Stderr
+		return cmd
+	}
+
+	exitCode := func(err error) int {
+		if err == nil {
+			return 0
+		}
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			t.Fatalf("expected an exit error, got: %s", err)
+		}
+		return exitErr.ExitCode()
+	}
+
+	waitForFile := func(path string) {
+		for i := 0; i < 100; i++ {
+			if _, err := os.Stat(path); err == nil {
+				return
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+		t.Fatalf("timed out waiting for %s", path)
+	}
+
+	when("#RunInit", func() {
+		it("returns the exit status of the process", func() {
+			h.AssertEq(t, exitCode(initCmd("exit 7").Run()), 7)
+		})
+
+		it("forwards signals to the process group", func() {
+			ready := filepath.Join(tmpDir, "ready")
+			cmd := initCmd(`trap "exit 42" TERM; touch ` + ready + `; sleep 30 & wait`)
+			h.AssertNil(t, cmd.Start())
+			done := make(chan error, 1)
+			go func() { done <- cmd.Wait() }()
+			waitForFile(ready)
+
+			h.AssertNil(t, cmd.Process.Signal(syscall.SIGTERM))
+
+			select {
+			case err := <-done:
+				h.AssertEq(t, exitCode(err), 42)
+			case <-time.After(10 * time.Second):
+				_ = cmd.Process.Kill()
+				t.Fatal("timed out waiting for the process to exit")
+			}
+		})
+
+		it("reports processes killed by a signal", func() {
+			h.AssertEq(t, exitCode(initCmd("kill -KILL $$").Run()), 128+int(syscall.SIGKILL))
+		})
+
+		it("reaps orphaned processes", func() {
+			// the orphan exits before the process, it must not be left as a zombie
+			cmd := initCmd("(sleep 0.2 &); sleep 1", initHelperCheckReapedEnv+"=true")
+			h.AssertEq(t, exitCode(cmd.Run()), 0)
+		})
+	})
+}
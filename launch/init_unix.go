@@ -0,0 +1,106 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package launch
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+var (
+	InitExecFunc = initExec
+	InitShell    = &BashShell{Exec: InitExecFunc}
+)
+
+// initExec runs the process as a child of the launcher instead of replacing the launcher and exits with its status.
+func initExec(argv0 string, argv []string, envv []string) error {
+	status, err := RunInit(argv0, argv, envv)
+	if err != nil {
+		return err
+	}
+	os.Exit(status)
+	return nil
+}
+
+// RunInit starts argv0 in its own process group and waits for it to exit, acting as an init process in the meantime:
+// catchable signals are forwarded to the process group and orphaned processes are reaped.
+// When stdin is the controlling terminal, the process group is moved to the terminal foreground,
+// so that the process can read from the terminal and receives the signals it generates.
+// It returns the exit status of the process, or 128 + the signal number if it was killed by a signal.
+func RunInit(argv0 string, argv []string, envv []string) (int, error) {
+	sigs := make(chan os.Signal, 32)
+	signal.Notify(sigs)
+	defer signal.Stop(sigs)
+
+	if err := setSubreaper(); err != nil {
+		return 0, errors.Wrap(err, "become subreaper")
+	}
+	sys := &syscall.SysProcAttr{Setpgid: true}
+	if isControllingTerminal(os.Stdin.Fd()) {
+		sys.Foreground = true
+		sys.Ctty = int(os.Stdin.Fd())
+	}
+	pid, err := syscall.ForkExec(argv0, argv, &syscall.ProcAttr{
+		Env:   envv,
+		Files: []uintptr{os.Stdin.Fd(), os.Stdout.Fd(), os.Stderr.Fd()},
+		Sys:   sys,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "start process")
+	}
+
+	for sig := range sigs {
+		switch sig {
+		case syscall.SIGCHLD:
+			if status, exited := reap(pid); exited {
+				return status, nil
+			}
+		case syscall.SIGURG:
+			// used by the go runtime for preemption
+		default:
+			if sysSig, ok := sig.(syscall.Signal); ok {
+				_ = syscall.Kill(-pid, sysSig)
+			}
+		}
+	}
+	return 0, nil
+}
+
+// isControllingTerminal returns true if fd is the controlling terminal of the launcher.
+func isControllingTerminal(fd uintptr) bool {
+	_, err := unix.IoctlGetInt(int(fd), unix.TIOCGPGRP)
+	return err == nil
+}
+
+// reap waits for every exited child and returns the status of child if it was among them.
+func reap(child int) (int, bool) {
+	var (
+		status int
+		exited bool
+	)
+	for {
+		var ws syscall.WaitStatus
+		pid, err := syscall.Wait4(-1, &ws, syscall.WNOHANG, nil)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil || pid <= 0 {
+			return status, exited
+		}
+		if pid == child {
+			status, exited = exitStatus(ws), true
+		}
+	}
+}
+
+func exitStatus(ws syscall.WaitStatus) int {
+	if ws.Signaled() {
+		return 128 + int(ws.Signal())
+	}
+	return ws.ExitStatus()
+}
@@ -0,0 +1,7 @@
+package launch
+
+var (
+	// InitExecFunc is OSExecFunc, processes already run as children of the launcher on Windows
+	InitExecFunc = OSExecFunc
+	InitShell    = DefaultShell
+)
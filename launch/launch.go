@@ -7,13 +7,14 @@ import (
 )
 
 type Process struct {
-	Type             string   `toml:"type" json:"type"`
-	Command          string   `toml:"command" json:"command"`
-	Args             []string `toml:"args" json:"args"`
-	Direct           bool     `toml:"direct" json:"direct"`
-	Default          bool     `toml:"default,omitempty" json:"default,omitempty"`
-	BuildpackID      string   `toml:"buildpack-id" json:"buildpackID"`
-	WorkingDirectory string   `toml:"working-dir,omitempty" json:"working-dir,omitempty"`
+	Type             string       `toml:"type" json:"type"`
+	Command          string       `toml:"command" json:"command"`
+	Args             []string     `toml:"args" json:"args"`
+	Direct           bool         `toml:"direct" json:"direct"`
+	Default          bool         `toml:"default,omitempty" json:"default,omitempty"`
+	BuildpackID      string       `toml:"buildpack-id" json:"buildpackID"`
+	WorkingDirectory string       `toml:"working-dir,omitempty" json:"working-dir,omitempty"`
+	HealthCheck      *HealthCheck `toml:"health-check,omitempty" json:"health-check,omitempty"`
 }
 
 func (p Process) NoDefault() Process {
@@ -26,6 +27,11 @@ func ProcessPath(pType string) string {
 	return filepath.Join(ProcessDir, pType+exe)
 }
 
+// HealthCheckPath returns the absolute path to the symlink that runs the health check of a given process type
+func HealthCheckPath(pType string) string {
+	return filepath.Join(HealthDir, pType+exe)
+}
+
 type Metadata struct {
 	Processes  []Process   `toml:"processes" json:"processes"`
 	Buildpacks []Buildpack `toml:"buildpacks" json:"buildpacks"`
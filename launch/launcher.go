@@ -5,16 +5,20 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
+	"sync"
 
 	"github.com/pkg/errors"
 
 	"github.com/buildpacks/lifecycle/api"
 	"github.com/buildpacks/lifecycle/env"
+	"github.com/buildpacks/lifecycle/internal/encoding"
 )
 
 var (
 	LifecycleDir = filepath.Join(CNBDir, "lifecycle")
 	ProcessDir   = filepath.Join(CNBDir, "process")
+	HealthDir    = filepath.Join(CNBDir, "health")
 	LauncherPath = filepath.Join(LifecycleDir, "launcher"+exe)
 )
 
@@ -23,15 +27,26 @@ type Launcher struct {
 	Buildpacks         []Buildpack
 	DefaultProcessType string
 	Env                Env
+	EnvHistoryPath     string // optional, where to write the history of env changes before launching a process
 	Exec               ExecFunc
 	ExecD              ExecD
+	ExecDParallel      bool // run the exec.d binaries of each layer in parallel, see doLayerExecD
+	InterpretProfiles  bool // apply profile scripts to direct processes without a shell, see interpretProfiles
+	LogEnvHistory      bool // if set, the history of env changes is logged at debug level
 	Shell              Shell
 	LayersDir          string
+	Logger             Logger           // optional, logs process overrides and env history, should not write to stdout which belongs to the process
+	Overrides          ProcessOverrides // optional, applied by ProcessFor
 	PlatformAPI        *api.Version
 	Processes          []Process
 	Setenv             func(string, string) error
 }
 
+type Logger interface {
+	Debugf(fmt string, v ...interface{})
+	Infof(fmt string, v ...interface{})
+}
+
 type ExecFunc func(argv0 string, argv []string, envv []string) error
 
 type ExecD interface {
@@ -68,9 +83,17 @@ func (l *Launcher) LaunchProcess(self string, proc Process) error {
 	if err := l.doExecD(proc.Type); err != nil {
 		return errors.Wrap(err, "exec.d")
 	}
+	if err := l.reportEnvHistory(); err != nil {
+		return errors.Wrap(err, "write env history")
+	}
 	proc.WorkingDirectory = getProcessWorkingDirectory(proc, l.AppDir)
 
 	if proc.Direct {
+		if l.InterpretProfiles {
+			if _, err := l.interpretProfiles(proc.Type); err != nil {
+				return errors.Wrap(err, "profile scripts")
+			}
+		}
 		return l.launchDirect(proc)
 	}
 	return l.launchWithShell(self, proc)
@@ -96,8 +119,41 @@ func (l *Launcher) launchDirect(proc Process) error {
 	return nil
 }
 
+// envHistory returns the history of env changes if the env records it.
+func (l *Launcher) envHistory() env.History {
+	if e, ok := l.Env.(*env.Env); ok {
+		return e.History
+	}
+	return nil
+}
+
+// reportEnvHistory logs which env files changed each env variable and writes the history to EnvHistoryPath.
+// Values are not logged since they may be sensitive.
+func (l *Launcher) reportEnvHistory() error {
+	history := l.envHistory()
+	if history == nil {
+		history = env.History{}
+	}
+	if l.LogEnvHistory && l.Logger != nil {
+		var names []string
+		for name := range history {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			for _, change := range history[name] {
+				l.Logger.Debugf("Env var %s: %s from %s", name, change.Action, change.Source)
+			}
+		}
+	}
+	if l.EnvHistoryPath == "" {
+		return nil
+	}
+	return encoding.WriteJSON(l.EnvHistoryPath, history)
+}
+
 func (l *Launcher) doEnv(procType string) error {
-	return l.eachBuildpack(func(bpAPI *api.Version, bpDir string) error {
+	return l.eachBuildpack(func(_ Buildpack, bpAPI *api.Version, bpDir string) error {
 		if err := eachLayer(bpDir, l.doLayerRoot()); err != nil {
 			return errors.Wrap(err, "add layer root")
 		}
@@ -109,11 +165,11 @@ func (l *Launcher) doEnv(procType string) error {
 }
 
 func (l *Launcher) doExecD(procType string) error {
-	return l.eachBuildpack(func(bpAPI *api.Version, bpDir string) error {
+	return l.eachBuildpack(func(bp Buildpack, bpAPI *api.Version, bpDir string) error {
 		if !supportsExecD(bpAPI) {
 			return nil
 		}
-		return eachLayer(bpDir, l.doLayerExecD(procType))
+		return eachLayer(bpDir, l.doLayerExecD(bp, procType))
 	})
 }
 
@@ -121,7 +177,7 @@ func supportsExecD(bpAPI *api.Version) bool {
 	return bpAPI.AtLeast("0.5")
 }
 
-type bpAction func(bpAPI *api.Version, bpDir string) error
+type bpAction func(bp Buildpack, bpAPI *api.Version, bpDir string) error
 type dirAction func(layerDir string) error
 
 func (l *Launcher) eachBuildpack(fn bpAction) error {
@@ -136,7 +192,7 @@ func (l *Launcher) eachBuildpack(fn bpAction) error {
 		if err != nil {
 			return err
 		}
-		if err := fn(bpAPI, dir); err != nil {
+		if err := fn(bp, bpAPI, dir); err != nil {
 			return err
 		}
 	}
@@ -164,20 +220,77 @@ func (l *Launcher) doLayerEnvFiles(procType string, defaultAction env.ActionType
 	}
 }
 
-func (l *Launcher) doLayerExecD(procType string) dirAction {
-	return func(path string) error {
-		if err := eachFile(filepath.Join(path, "exec.d"), func(path string) error {
-			return l.ExecD.ExecD(path, l.Env)
-		}); err != nil {
-			return err
+// doLayerExecD runs the exec.d binaries of the layer, and then those for the process type.
+// With ExecDParallel, the binaries of each of these dirs are considered independent: they run in parallel,
+// all receive the env as it was before the dir, and their output is applied in file name order.
+func (l *Launcher) doLayerExecD(bp Buildpack, procType string) dirAction {
+	return func(layerDir string) error {
+		dirs := []string{filepath.Join(layerDir, "exec.d")}
+		if procType != "" {
+			dirs = append(dirs, filepath.Join(layerDir, "exec.d", procType))
 		}
-		if procType == "" {
-			return nil
+		for _, dir := range dirs {
+			var err error
+			if l.ExecDParallel {
+				err = l.execDParallel(dir)
+			} else {
+				err = eachFile(dir, func(path string) error {
+					return l.ExecD.ExecD(path, l.Env)
+				})
+			}
+			if execDErr, ok := err.(*ExecDError); ok {
+				execDErr.BuildpackID = bp.ID
+				execDErr.Layer = filepath.Base(layerDir)
+			}
+			if err != nil {
+				return err
+			}
 		}
-		return eachFile(filepath.Join(path, "exec.d", procType), func(path string) error {
-			return l.ExecD.ExecD(path, l.Env)
-		})
+		return nil
+	}
+}
+
+func (l *Launcher) execDParallel(dir string) error {
+	var paths []string
+	if err := eachFile(dir, func(path string) error {
+		paths = append(paths, path)
+		return nil
+	}); err != nil {
+		return err
+	}
+	outputs := make([]*execDOutput, len(paths))
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		i, path := i, path
+		outputs[i] = &execDOutput{Env: l.Env}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = l.ExecD.ExecD(path, outputs[i])
+		}()
 	}
+	wg.Wait()
+	for i, path := range paths {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		for _, v := range outputs[i].vars {
+			setFrom(l.Env, v.name, v.value, path)
+		}
+	}
+	return nil
+}
+
+// execDOutput collects the variables set by an exec.d binary running in parallel with others,
+// reads are passed through to the env, which is not modified until all binaries are done.
+type execDOutput struct {
+	Env
+	vars []struct{ name, value string }
+}
+
+func (o *execDOutput) Set(name, v string) {
+	o.vars = append(o.vars, struct{ name, value string }{name, v})
 }
 
 func eachLayer(bpDir string, action dirAction) error {
@@ -1,6 +1,7 @@
 package launch_test
 
 import (
+	"errors"
 	"io/ioutil"
 	"math/rand"
 	"os"
@@ -185,6 +186,34 @@ func testLauncher(t *testing.T, when spec.G, it spec.S) {
 				h.AssertEq(t, syscallExecArgsColl[0].envv, envList)
 			})
 
+			when("the env records its history", func() {
+				var logger *recordingLogger
+
+				it.Before(func() {
+					launchEnv := env.NewLaunchEnv([]string{"PATH=some-path", "SOME_VAR=some-value"}, "", "")
+					launchEnv.History = env.History{
+						"SOME_VAR": {{Source: "/layers/some-bp/some-layer/env/SOME_VAR", Action: env.ActionTypeOverride, Value: "some-secret-value"}},
+					}
+					launcher.Env = launchEnv
+					logger = &recordingLogger{}
+					launcher.Logger = logger
+				})
+
+				it("logs the history at debug level", func() {
+					launcher.LogEnvHistory = true
+
+					h.AssertNil(t, launcher.LaunchProcess("", process))
+
+					h.AssertEq(t, logger.messages, []string{"Env var SOME_VAR: override from /layers/some-bp/some-layer/env/SOME_VAR"})
+				})
+
+				it("does not log the history unless asked to", func() {
+					h.AssertNil(t, launcher.LaunchProcess("", process))
+
+					h.AssertEq(t, len(logger.messages), 0)
+				})
+			})
+
 			it("should default the working directory to the app directory", func() {
 				process.WorkingDirectory = ""
 				h.AssertNil(t, launcher.LaunchProcess("", process))
@@ -334,6 +363,52 @@ func testLauncher(t *testing.T, when spec.G, it spec.S) {
 						}
 					})
 
+					it("should add the buildpack and layer to exec.d errors", func() {
+						mockEnv.EXPECT().AddRootDir(gomock.Any()).AnyTimes()
+						mockEnv.EXPECT().AddEnvDir(gomock.Any(), gomock.Any()).AnyTimes()
+						execd.EXPECT().ExecD(
+							filepath.Join(tmpDir, "launch", "0.5_buildpack", "layer5", "exec.d", "exec_d_1"),
+							mockEnv,
+						).Return(&launch.ExecDError{Path: "exec_d_1", ExitCode: 3, Stderr: "some-error", Err: errors.New("exit status 3")})
+
+						err := launcher.LaunchProcess("", process)
+						h.AssertError(t, err, "failed to execute exec.d file at path 'exec_d_1' (buildpack '0.5/buildpack', layer 'layer5') with exit code 3: exit status 3\nstderr:\nsome-error")
+					})
+
+					when("exec.d binaries run in parallel", func() {
+						it.Before(func() {
+							launcher.ExecDParallel = true
+						})
+
+						it("should apply their output in order once all of them are done", func() {
+							mockEnv.EXPECT().AddRootDir(gomock.Any()).AnyTimes()
+							mockEnv.EXPECT().AddEnvDir(gomock.Any(), gomock.Any()).AnyTimes()
+							firstDone := make(chan struct{})
+							execd.EXPECT().ExecD(
+								filepath.Join(tmpDir, "launch", "0.5_buildpack", "layer5", "exec.d", "exec_d_1"),
+								gomock.Any(),
+							).DoAndReturn(func(_ string, e launch.Env) error {
+								<-firstDone // exec_d_2 finishes first
+								e.Set("SOME_VAR", "from-exec-d-1")
+								return nil
+							})
+							execd.EXPECT().ExecD(
+								filepath.Join(tmpDir, "launch", "0.5_buildpack", "layer5", "exec.d", "exec_d_2"),
+								gomock.Any(),
+							).DoAndReturn(func(_ string, e launch.Env) error {
+								e.Set("SOME_VAR", "from-exec-d-2")
+								close(firstDone)
+								return nil
+							})
+							gomock.InOrder(
+								mockEnv.EXPECT().Set("SOME_VAR", "from-exec-d-1"),
+								mockEnv.EXPECT().Set("SOME_VAR", "from-exec-d-2"),
+							)
+
+							h.AssertNil(t, launcher.LaunchProcess("", process))
+						})
+					})
+
 					when("process is buildpack-provided", func() {
 						it.Before(func() {
 							process.Type = "some-process-type"
@@ -0,0 +1,136 @@
+package launch
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+)
+
+const processOverrideEnvPrefix = "CNB_PROCESS_"
+
+// ProcessOverride replaces parameters of a process type at launch. Unset fields are not overridden.
+type ProcessOverride struct {
+	Args             []string `toml:"args"`
+	WorkingDirectory *string  `toml:"working-dir"`
+	Direct           *bool    `toml:"direct"`
+}
+
+// ProcessOverrides are the overrides for each process type, keyed by ProcessOverrideKey of the type.
+type ProcessOverrides map[string]ProcessOverride
+
+// ProcessOverrideKey returns the key of the process type in ProcessOverrides and in override env var names,
+// e.g. CNB_PROCESS_SOME_TYPE_ARGS for the process type some-type.
+func ProcessOverrideKey(procType string) string {
+	return strings.Map(func(r rune) rune {
+		if ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		if 'a' <= r && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+		return '_'
+	}, procType)
+}
+
+// ReadProcessOverrides reads the overrides in the TOML file at path, if set, and in environ.
+// The file contains a [processes.<type>] table for each process type, with optional args, working-dir and direct keys.
+// Env vars CNB_PROCESS_<TYPE>_ARGS, CNB_PROCESS_<TYPE>_WORKING_DIR and CNB_PROCESS_<TYPE>_DIRECT take precedence over the file.
+// Args are given as a JSON array, or separated by whitespace.
+func ReadProcessOverrides(path string, environ []string) (ProcessOverrides, error) {
+	overrides := ProcessOverrides{}
+	if path != "" {
+		var file struct {
+			Processes map[string]ProcessOverride `toml:"processes"`
+		}
+		if _, err := toml.DecodeFile(path, &file); err != nil {
+			return nil, errors.Wrapf(err, "read process overrides file '%s'", path)
+		}
+		for procType, override := range file.Processes {
+			overrides[ProcessOverrideKey(procType)] = override
+		}
+	}
+
+	for _, kv := range environ {
+		parts := strings.SplitN(kv, "=", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], processOverrideEnvPrefix) {
+			continue
+		}
+		name, value := strings.TrimPrefix(parts[0], processOverrideEnvPrefix), parts[1]
+		switch {
+		case strings.HasSuffix(name, "_ARGS"):
+			key := strings.TrimSuffix(name, "_ARGS")
+			args, err := parseOverrideArgs(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parse %s", parts[0])
+			}
+			override := overrides[key]
+			override.Args = args
+			overrides[key] = override
+		case strings.HasSuffix(name, "_WORKING_DIR"):
+			key := strings.TrimSuffix(name, "_WORKING_DIR")
+			override := overrides[key]
+			override.WorkingDirectory = &value
+			overrides[key] = override
+		case strings.HasSuffix(name, "_DIRECT"):
+			key := strings.TrimSuffix(name, "_DIRECT")
+			direct, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parse %s", parts[0])
+			}
+			override := overrides[key]
+			override.Direct = &direct
+			overrides[key] = override
+		}
+	}
+	return overrides, nil
+}
+
+func parseOverrideArgs(value string) ([]string, error) {
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		var args []string
+		if err := json.Unmarshal([]byte(value), &args); err != nil {
+			return nil, err
+		}
+		if args == nil {
+			args = []string{}
+		}
+		return args, nil
+	}
+	return strings.Fields(value), nil
+}
+
+// overrideProcess applies the override for the type of proc, if any, and logs each overridden parameter.
+// A relative working directory is resolved against the app dir.
+func (l *Launcher) overrideProcess(proc Process) Process {
+	override, ok := l.Overrides[ProcessOverrideKey(proc.Type)]
+	if !ok || proc.Type == "" {
+		return proc
+	}
+	if override.Args != nil {
+		l.logf("Overriding args of process type '%s': %q", proc.Type, override.Args)
+		proc.Args = append([]string{}, override.Args...)
+	}
+	if override.WorkingDirectory != nil {
+		dir := *override.WorkingDirectory
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(l.AppDir, dir)
+		}
+		l.logf("Overriding working directory of process type '%s': %s", proc.Type, dir)
+		proc.WorkingDirectory = dir
+	}
+	if override.Direct != nil {
+		l.logf("Overriding direct of process type '%s': %t", proc.Type, *override.Direct)
+		proc.Direct = *override.Direct
+	}
+	return proc
+}
+
+func (l *Launcher) logf(format string, v ...interface{}) {
+	if l.Logger != nil {
+		l.Logger.Infof(format, v...)
+	}
+}
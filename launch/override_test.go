@@ -0,0 +1,118 @@
+package launch_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/launch"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestOverride(t *testing.T) {
+	spec.Run(t, "Override", testOverride, spec.Report(report.Terminal{}))
+}
+
+type recordingLogger struct {
+	messages []string
+}
+
+func (l *recordingLogger) Debugf(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func (l *recordingLogger) Infof(format string, v ...interface{}) {
+	l.messages = append(l.messages, fmt.Sprintf(format, v...))
+}
+
+func testOverride(t *testing.T, when spec.G, it spec.S) {
+	var tmpDir string
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "lifecycle.launch.override")
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	when("#ProcessOverrideKey", func() {
+		it("upper cases the type and replaces other characters", func() {
+			h.AssertEq(t, launch.ProcessOverrideKey("some-type.v2"), "SOME_TYPE_V2")
+		})
+	})
+
+	when("#ReadProcessOverrides", func() {
+		it("reads overrides from the file and env vars, preferring env vars", func() {
+			path := filepath.Join(tmpDir, "overrides.toml")
+			h.Mkfile(t, `
+[processes.some-type]
+args = ["from-file"]
+working-dir = "/from/file"
+
+[processes.other]
+direct = true
+`, path)
+
+			overrides, err := launch.ReadProcessOverrides(path, []string{
+				"CNB_PROCESS_SOME_TYPE_ARGS=[\"from env\", \"--flag\"]",
+				"CNB_PROCESS_OTHER_ARGS=  split  on spaces ",
+				"CNB_PROCESS_OTHER_DIRECT=false",
+				"CNB_PROCESS_TYPE=some-type",
+				"OTHER_VAR=val",
+			})
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, overrides["SOME_TYPE"].Args, []string{"from env", "--flag"})
+			h.AssertEq(t, *overrides["SOME_TYPE"].WorkingDirectory, "/from/file")
+			h.AssertEq(t, overrides["OTHER"].Args, []string{"split", "on", "spaces"})
+			h.AssertEq(t, *overrides["OTHER"].Direct, false)
+			h.AssertEq(t, len(overrides), 2)
+		})
+
+		it("errors for invalid values", func() {
+			_, err := launch.ReadProcessOverrides("", []string{"CNB_PROCESS_WEB_DIRECT=maybe"})
+			h.AssertError(t, err, "parse CNB_PROCESS_WEB_DIRECT")
+		})
+	})
+
+	when("#ProcessFor", func() {
+		it("applies and logs the overrides of the process type", func() {
+			logger := &recordingLogger{}
+			direct := true
+			workingDir := "some-dir"
+			launcher := &launch.Launcher{
+				AppDir:             "/workspace",
+				DefaultProcessType: "web",
+				PlatformAPI:        api.Platform.Latest(),
+				Processes: []launch.Process{
+					{Type: "web", Command: "some-cmd", Args: []string{"some-arg"}},
+				},
+				Logger: logger,
+				Overrides: launch.ProcessOverrides{
+					"WEB": {Args: []string{"override-arg"}, WorkingDirectory: &workingDir, Direct: &direct},
+				},
+			}
+
+			proc, err := launcher.ProcessFor([]string{"user-arg"})
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, proc.Args, []string{"override-arg", "user-arg"})
+			h.AssertEq(t, proc.WorkingDirectory, filepath.Join("/workspace", "some-dir"))
+			h.AssertEq(t, proc.Direct, true)
+			h.AssertEq(t, logger.messages, []string{
+				`Overriding args of process type 'web': ["override-arg"]`,
+				"Overriding working directory of process type 'web': " + filepath.Join("/workspace", "some-dir"),
+				"Overriding direct of process type 'web': true",
+			})
+		})
+	})
+}
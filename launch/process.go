@@ -15,6 +15,7 @@ import (
 //   Else
 //     * it constructs a new process from cmd
 //     * If the first element in cmd is `cmd` the process shall be direct
+//   Overrides for the type of the returned process are applied, see ReadProcessOverrides
 func (l *Launcher) ProcessFor(cmd []string) (Process, error) {
 	if l.PlatformAPI.LessThan("0.4") {
 		return l.processForLegacy(cmd)
@@ -32,6 +33,7 @@ func (l *Launcher) ProcessFor(cmd []string) (Process, error) {
 	if !ok {
 		return Process{}, fmt.Errorf("process type %s was not found", l.DefaultProcessType)
 	}
+	process = l.overrideProcess(process)
 	process.Args = append(process.Args, cmd...)
 
 	return process, nil
@@ -40,7 +42,7 @@ func (l *Launcher) ProcessFor(cmd []string) (Process, error) {
 func (l *Launcher) processForLegacy(cmd []string) (Process, error) {
 	if len(cmd) == 0 {
 		if process, ok := l.findProcessType(l.DefaultProcessType); ok {
-			return process, nil
+			return l.overrideProcess(process), nil
 		}
 
 		return Process{}, fmt.Errorf("process type %s was not found", l.DefaultProcessType)
@@ -48,7 +50,7 @@ func (l *Launcher) processForLegacy(cmd []string) (Process, error) {
 
 	if len(cmd) == 1 {
 		if process, ok := l.findProcessType(cmd[0]); ok {
-			return process, nil
+			return l.overrideProcess(process), nil
 		}
 	}
 
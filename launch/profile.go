@@ -0,0 +1,210 @@
+package launch
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var envNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ProfileError is returned when profile scripts contain lines the launcher cannot interpret without a shell.
+type ProfileError struct {
+	Issues []ProfileIssue
+}
+
+// ProfileIssue is a line of a profile script that the launcher cannot interpret.
+type ProfileIssue struct {
+	Path   string
+	Line   int
+	Text   string
+	Reason string
+}
+
+func (e *ProfileError) Error() string {
+	var lines []string
+	for _, issue := range e.Issues {
+		lines = append(lines, fmt.Sprintf("  %s:%d: %s: %s", issue.Path, issue.Line, issue.Reason, issue.Text))
+	}
+	return "cannot interpret profile scripts without a shell, only 'export NAME=value' lines are supported:\n" + strings.Join(lines, "\n")
+}
+
+// interpretProfiles applies the profile scripts of the process type to the env without running a shell.
+// Scripts may only contain comments and 'export NAME=value' lines, where the value may be quoted and may reference
+// variables as $NAME or ${NAME}. Every line that cannot be interpreted is reported in a *ProfileError.
+func (l *Launcher) interpretProfiles(procType string) ([]string, error) {
+	if runtime.GOOS == "windows" {
+		return nil, errors.New("interpreting profile scripts is not supported on Windows")
+	}
+	profiles, err := l.getProfiles(procType)
+	if err != nil {
+		return nil, errors.Wrap(err, "find profiles")
+	}
+	profileErr := &ProfileError{}
+	for _, profile := range profiles {
+		path := profile
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(l.AppDir, path)
+		}
+		issues, err := interpretProfile(path, l.Env)
+		if err != nil {
+			return nil, errors.Wrapf(err, "read profile script '%s'", path)
+		}
+		profileErr.Issues = append(profileErr.Issues, issues...)
+	}
+	if len(profileErr.Issues) > 0 {
+		return nil, profileErr
+	}
+	return profiles, nil
+}
+
+func interpretProfile(path string, env Env) ([]ProfileIssue, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var issues []ProfileIssue
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok, reason := parseExport(line, env)
+		if reason != "" {
+			issues = append(issues, ProfileIssue{Path: path, Line: n, Text: line, Reason: reason})
+			continue
+		}
+		if ok {
+			setFrom(env, name, value, path)
+		}
+	}
+	return issues, scanner.Err()
+}
+
+// parseExport parses an 'export NAME=value' line. ok is false for 'export NAME', which does not change the env.
+// reason describes why the line cannot be interpreted.
+func parseExport(line string, env Env) (name, value string, ok bool, reason string) {
+	if !strings.HasPrefix(line, "export ") && !strings.HasPrefix(line, "export\t") {
+		return "", "", false, "not an export"
+	}
+	assignment := strings.TrimLeft(strings.TrimPrefix(line, "export"), " \t")
+	eq := strings.Index(assignment, "=")
+	if eq < 0 {
+		if envNameRegexp.MatchString(assignment) {
+			return "", "", false, ""
+		}
+		return "", "", false, "unsupported export"
+	}
+	name = assignment[:eq]
+	if !envNameRegexp.MatchString(name) {
+		return "", "", false, "invalid variable name"
+	}
+	value, reason = parseValue(assignment[eq+1:], env)
+	if reason != "" {
+		return "", "", false, reason
+	}
+	return name, value, true, ""
+}
+
+// parseValue parses a single shell word with single quotes, double quotes and $NAME or ${NAME} expansion.
+func parseValue(s string, env Env) (string, string) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return "", "unterminated quote"
+			}
+			b.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			closed := false
+			for i++; i < len(s); i++ {
+				c := s[i]
+				if c == '"' {
+					closed = true
+					break
+				}
+				if c == '\\' && i+1 < len(s) && strings.IndexByte("\"\\$`", s[i+1]) >= 0 {
+					i++
+					b.WriteByte(s[i])
+					continue
+				}
+				if c == '`' {
+					return "", "command substitution is not supported"
+				}
+				if c == '$' {
+					n, val, reason := expand(s[i:], env)
+					if reason != "" {
+						return "", reason
+					}
+					b.WriteString(val)
+					i += n - 1
+					continue
+				}
+				b.WriteByte(c)
+			}
+			if !closed {
+				return "", "unterminated quote"
+			}
+		case c == '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case c == '$':
+			n, val, reason := expand(s[i:], env)
+			if reason != "" {
+				return "", reason
+			}
+			b.WriteString(val)
+			i += n - 1
+		case c == ' ' || c == '\t':
+			if rest := strings.TrimLeft(s[i:], " \t"); rest != "" && !strings.HasPrefix(rest, "#") {
+				return "", "multiple words or commands are not supported"
+			}
+			return b.String(), ""
+		case strings.IndexByte(";&|<>()`", c) >= 0:
+			return "", "shell syntax is not supported"
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), ""
+}
+
+// expand expands the variable reference at the start of s and returns the number of bytes it spans.
+func expand(s string, env Env) (int, string, string) {
+	if strings.HasPrefix(s, "${") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 {
+			return 0, "", "unterminated variable reference"
+		}
+		if !envNameRegexp.MatchString(s[2:end]) {
+			return 0, "", "unsupported variable expansion"
+		}
+		return end + 1, env.Get(s[2:end]), ""
+	}
+	if strings.HasPrefix(s, "$(") {
+		return 0, "", "command substitution is not supported"
+	}
+	n := 1
+	for n < len(s) && (s[n] == '_' || ('a' <= s[n] && s[n] <= 'z') || ('A' <= s[n] && s[n] <= 'Z') || (n > 1 && '0' <= s[n] && s[n] <= '9')) {
+		n++
+	}
+	if n == 1 {
+		return 0, "", "unsupported variable expansion"
+	}
+	return n, env.Get(s[1:n]), ""
+}
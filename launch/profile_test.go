@@ -0,0 +1,111 @@
+package launch_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/env"
+	"github.com/buildpacks/lifecycle/launch"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestProfile(t *testing.T) {
+	spec.Run(t, "Profile", testProfile, spec.Sequential(), spec.Report(report.Terminal{}))
+}
+
+func testProfile(t *testing.T, when spec.G, it spec.S) {
+	var (
+		launcher *launch.Launcher
+		tmpDir   string
+		layerDir string
+		wd       string
+		envv     []string
+	)
+
+	it.Before(func() {
+		h.SkipIf(t, runtime.GOOS == "windows", "skip profile interpretation tests on windows")
+		var err error
+		wd, err = os.Getwd()
+		h.AssertNil(t, err)
+		tmpDir, err = ioutil.TempDir("", "lifecycle.launch.profile")
+		h.AssertNil(t, err)
+		layerDir = filepath.Join(tmpDir, "layers", "some-buildpack", "some-layer")
+		h.Mkdir(t, filepath.Join(tmpDir, "app"), filepath.Join(layerDir, "profile.d", "web"))
+
+		envv = nil
+		launcher = &launch.Launcher{
+			AppDir:            filepath.Join(tmpDir, "app"),
+			LayersDir:         filepath.Join(tmpDir, "layers"),
+			Buildpacks:        []launch.Buildpack{{API: api.Buildpack.Latest().String(), ID: "some-buildpack"}},
+			PlatformAPI:       api.Platform.Latest(),
+			Env:               env.NewLaunchEnv([]string{"PATH=/usr/bin", "HOME=/home/cnb"}, launch.ProcessDir, launch.LifecycleDir),
+			ExecD:             launch.NewExecDRunner(),
+			InterpretProfiles: true,
+			Setenv:            func(string, string) error { return nil },
+			Exec: func(_ string, _ []string, e []string) error {
+				envv = e
+				return nil
+			},
+		}
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.Chdir(wd))
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	launchDirect := func() error {
+		return launcher.LaunchProcess("", launch.Process{Type: "web", Command: "true", Direct: true})
+	}
+
+	it("applies export lines of profile scripts to direct processes", func() {
+		h.Mkfile(t, "# some comment\n\nexport SOME_VAR=some-val\nexport PATH=\"/some/bin:$PATH\"\n",
+			filepath.Join(layerDir, "profile.d", "some-profile"))
+		h.Mkfile(t, "export QUOTED='$HOME literal' # comment\nexport EXPANDED=${HOME}/dir\nexport SOME_VAR\n",
+			filepath.Join(layerDir, "profile.d", "web", "web-profile"))
+		h.Mkfile(t, "export APP_VAR=\"from \\\"app\\\"\"\n", filepath.Join(tmpDir, "app", ".profile"))
+
+		h.AssertNil(t, launchDirect())
+
+		h.AssertContains(t, envv,
+			"SOME_VAR=some-val",
+			"PATH=/some/bin:/usr/bin",
+			"QUOTED=$HOME literal",
+			"EXPANDED=/home/cnb/dir",
+			`APP_VAR=from "app"`,
+		)
+	})
+
+	it("reports every line it cannot interpret", func() {
+		profile := filepath.Join(layerDir, "profile.d", "some-profile")
+		h.Mkfile(t, "export GOOD=val\nif [ -n \"$X\" ]; then\nexport CMD=$(pwd)\nexport A=b c\nexport D=${E:-f}\n", profile)
+
+		err := launchDirect()
+		h.AssertError(t, err, profile+":2: not an export: if [ -n \"$X\" ]; then")
+		h.AssertError(t, err, profile+":3: command substitution is not supported: export CMD=$(pwd)")
+		h.AssertError(t, err, profile+":4: multiple words or commands are not supported: export A=b c")
+		h.AssertError(t, err, profile+":5: unsupported variable expansion: export D=${E:-f}")
+		h.AssertEq(t, envv, []string(nil))
+	})
+
+	it("does not interpret profile scripts when disabled", func() {
+		launcher.InterpretProfiles = false
+		h.Mkfile(t, "export SOME_VAR=some-val\n", filepath.Join(layerDir, "profile.d", "some-profile"))
+
+		h.AssertNil(t, launchDirect())
+
+		h.AssertContains(t, envv, "PATH=/usr/bin")
+		for _, e := range envv {
+			if e == "SOME_VAR=some-val" {
+				t.Fatal("expected SOME_VAR to be unset")
+			}
+		}
+	})
+}
@@ -47,7 +47,7 @@ func (l *Launcher) launchWithShell(self string, proc Process) error {
 
 func (l *Launcher) getProfiles(procType string) ([]string, error) {
 	var profiles []string
-	if err := l.eachBuildpack(func(_ *api.Version, bpDir string) error {
+	if err := l.eachBuildpack(func(_ Buildpack, _ *api.Version, bpDir string) error {
 		return eachLayer(bpDir, l.populateLayerProfiles(procType, &profiles))
 	}); err != nil {
 		return nil, err
@@ -0,0 +1,337 @@
+package launch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SupervisorEntrypoint is the name the launcher may be invoked with to supervise several process types.
+const SupervisorEntrypoint = "supervisor"
+
+const defaultStopTimeout = 10 * time.Second
+
+// RestartPolicy determines whether a supervised process is restarted when it exits.
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "never"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartAlways    RestartPolicy = "always"
+)
+
+// ExitPolicy determines when the Supervisor stops.
+type ExitPolicy string
+
+const (
+	ExitOnAny ExitPolicy = "any" // stop the remaining processes as soon as any process stops
+	ExitOnAll ExitPolicy = "all" // wait for every process to stop
+)
+
+func ParseRestartPolicy(s string) (RestartPolicy, error) {
+	switch p := RestartPolicy(s); p {
+	case RestartNever, RestartOnFailure, RestartAlways:
+		return p, nil
+	case "":
+		return RestartNever, nil
+	default:
+		return "", fmt.Errorf("unknown restart policy '%s', must be one of: never, on-failure, always", s)
+	}
+}
+
+func ParseExitPolicy(s string) (ExitPolicy, error) {
+	switch p := ExitPolicy(s); p {
+	case ExitOnAny, ExitOnAll:
+		return p, nil
+	case "":
+		return ExitOnAny, nil
+	default:
+		return "", fmt.Errorf("unknown exit policy '%s', must be one of: any, all", s)
+	}
+}
+
+// ProcessExitError is returned by Supervisor.Run when a supervised process exits unsuccessfully.
+type ProcessExitError struct {
+	Type string
+	Code int
+}
+
+func (e *ProcessExitError) Error() string {
+	return fmt.Sprintf("process type '%s' exited with code %d", e.Type, e.Code)
+}
+
+// Supervisor runs several process types as children of the launcher.
+// Output of each process is prefixed with its type, signals received by the Supervisor are forwarded to every process,
+// and processes are restarted according to the RestartPolicy until the ExitPolicy is met.
+type Supervisor struct {
+	Processes      []string
+	Command        func(procType string) *exec.Cmd // returns an unstarted command for the process type
+	RestartPolicy  RestartPolicy
+	MaxRestarts    int           // maximum number of restarts per process, unlimited if <= 0
+	RestartDelay   time.Duration // time to wait before restarting a process
+	ExitPolicy     ExitPolicy
+	StopTimeout    time.Duration // time to wait for processes to stop before killing them, defaults to 10s
+	Stdout, Stderr io.Writer
+
+	stdout, stderr *lockedWriter
+}
+
+// ProcessCommand returns a command that runs the launcher at launcherPath for a single process type.
+// The child launcher sets up the env and runs exec.d for the process type as if it were launched directly.
+func (l *Launcher) ProcessCommand(launcherPath, procType string, env []string) *exec.Cmd {
+	c := exec.Command(launcherPath) // #nosec G204
+	if l.PlatformAPI.LessThan("0.4") {
+		c.Args = []string{launcherPath, procType}
+	} else {
+		// the process type is selected by the name the launcher is invoked with
+		c.Args = []string{ProcessPath(procType)}
+	}
+	c.Env = env
+	return c
+}
+
+type supervisedProcess struct {
+	procType     string
+	cmd          *exec.Cmd
+	restarts     int
+	restartTimer *time.Timer // set while a restart is pending
+	running      bool
+	exitCode     int
+	stdout       *prefixWriter
+	stderr       *prefixWriter
+}
+
+type processExit struct {
+	proc *supervisedProcess
+	code int
+}
+
+// Run starts every process and waits until the ExitPolicy is met.
+// It returns a ProcessExitError for the first process that exited unsuccessfully, if any.
+func (s *Supervisor) Run() error {
+	if len(s.Processes) == 0 {
+		return errors.New("no process types to supervise")
+	}
+	sigs := make(chan os.Signal, 8)
+	signal.Notify(sigs, forwardedSignals...)
+	defer signal.Stop(sigs)
+
+	exits := make(chan processExit)
+	s.stdout = &lockedWriter{w: s.Stdout}
+	s.stderr = &lockedWriter{w: s.Stderr}
+	var procs []*supervisedProcess
+	for _, procType := range s.Processes {
+		proc := &supervisedProcess{
+			procType: procType,
+			stdout:   &prefixWriter{w: s.stdout, prefix: "[" + procType + "] "},
+			stderr:   &prefixWriter{w: s.stderr, prefix: "[" + procType + "] "},
+		}
+		if err := s.start(proc, exits); err != nil {
+			s.stop(procs, exits)
+			return err
+		}
+		procs = append(procs, proc)
+	}
+
+	var (
+		result   error
+		running  int
+		stopping bool
+		killed   <-chan time.Time
+		restarts = make(chan *supervisedProcess)
+	)
+	// pending restarts count as running, stopping cancels those that have not fired yet
+	cancelRestart := func(proc *supervisedProcess) {
+		proc.restartTimer = nil
+		running--
+		s.logf("Process type '%s' exited with code %d", proc.procType, proc.exitCode)
+		if result == nil {
+			result = &ProcessExitError{Type: proc.procType, Code: proc.exitCode}
+		}
+	}
+	stopAll := func() {
+		stopping = true
+		killed = time.After(s.stopTimeout())
+		for _, proc := range procs {
+			if proc.restartTimer != nil && proc.restartTimer.Stop() {
+				cancelRestart(proc)
+			}
+		}
+	}
+	for running = len(procs); running > 0; {
+		select {
+		case sig := <-sigs:
+			s.logf("Forwarding signal '%s'", sig)
+			for _, proc := range procs {
+				if proc.running {
+					_ = forwardSignal(proc.cmd.Process, sig)
+				}
+			}
+			if !stopping && stopsProcesses(sig) {
+				stopAll()
+			}
+		case <-killed:
+			for _, proc := range procs {
+				if proc.running {
+					s.logf("Killing process type '%s'", proc.procType)
+					_ = kill(proc.cmd.Process)
+				}
+			}
+		case proc := <-restarts:
+			if stopping {
+				cancelRestart(proc)
+				continue
+			}
+			proc.restartTimer = nil
+			if err := s.start(proc, exits); err != nil {
+				running--
+				if result == nil {
+					result = err
+				}
+				if s.ExitPolicy != ExitOnAll {
+					stopAll()
+				}
+			}
+		case exit := <-exits:
+			proc := exit.proc
+			proc.running = false
+			proc.exitCode = exit.code
+			if !stopping && s.shouldRestart(proc, exit.code) {
+				proc.restarts++
+				s.logf("Restarting process type '%s' after exit code %d (restart %d)", proc.procType, exit.code, proc.restarts)
+				// wait for the restart in the select so that signals are still forwarded in the meantime
+				proc.restartTimer = time.AfterFunc(s.RestartDelay, func() { restarts <- proc })
+				continue
+			}
+			running--
+			s.logf("Process type '%s' exited with code %d", proc.procType, exit.code)
+			if exit.code != 0 && result == nil {
+				result = &ProcessExitError{Type: proc.procType, Code: exit.code}
+			}
+			if !stopping && s.ExitPolicy != ExitOnAll {
+				stopAll()
+				for _, other := range procs {
+					if other.running {
+						_ = terminate(other.cmd.Process)
+					}
+				}
+			}
+		}
+	}
+	return result
+}
+
+func (s *Supervisor) start(proc *supervisedProcess, exits chan<- processExit) error {
+	c := s.Command(proc.procType)
+	c.Stdin = nil
+	c.Stdout = proc.stdout
+	c.Stderr = proc.stderr
+	setProcessGroup(c)
+	if err := c.Start(); err != nil {
+		return errors.Wrapf(err, "start process type '%s'", proc.procType)
+	}
+	proc.cmd = c
+	proc.running = true
+	go func() {
+		err := c.Wait()
+		proc.stdout.flush()
+		proc.stderr.flush()
+		exits <- processExit{proc: proc, code: exitCode(c, err)}
+	}()
+	return nil
+}
+
+// stop terminates the already started processes after a failure to start another one.
+func (s *Supervisor) stop(procs []*supervisedProcess, exits <-chan processExit) {
+	for _, proc := range procs {
+		_ = terminate(proc.cmd.Process)
+	}
+	for range procs {
+		exit := <-exits
+		exit.proc.running = false
+	}
+}
+
+func (s *Supervisor) shouldRestart(proc *supervisedProcess, code int) bool {
+	if s.MaxRestarts > 0 && proc.restarts >= s.MaxRestarts {
+		return false
+	}
+	switch s.RestartPolicy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return code != 0
+	default:
+		return false
+	}
+}
+
+func (s *Supervisor) stopTimeout() time.Duration {
+	if s.StopTimeout > 0 {
+		return s.StopTimeout
+	}
+	return defaultStopTimeout
+}
+
+func (s *Supervisor) logf(format string, args ...interface{}) {
+	fmt.Fprintf(s.stderr, "[supervisor] "+format+"\n", args...)
+}
+
+func exitCode(c *exec.Cmd, err error) int {
+	if c.ProcessState != nil && c.ProcessState.ExitCode() >= 0 {
+		return c.ProcessState.ExitCode()
+	}
+	if err != nil {
+		// killed by a signal or failed to wait
+		return 1
+	}
+	return 0
+}
+
+// lockedWriter serializes writes from several processes to the same writer.
+type lockedWriter struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+func (l *lockedWriter) Write(b []byte) (int, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.w.Write(b)
+}
+
+// prefixWriter writes complete lines to w, each starting with prefix.
+type prefixWriter struct {
+	w      io.Writer
+	prefix string
+	buf    []byte
+}
+
+func (p *prefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	for {
+		i := bytes.IndexByte(p.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if _, err := p.w.Write(append([]byte(p.prefix), p.buf[:i+1]...)); err != nil {
+			return 0, err
+		}
+		p.buf = p.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+func (p *prefixWriter) flush() {
+	if len(p.buf) > 0 {
+		_, _ = p.w.Write(append([]byte(p.prefix), append(p.buf, '\n')...))
+		p.buf = nil
+	}
+}
@@ -0,0 +1,154 @@
+package launch_test
+
+import (
+	"bytes"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/api"
+	"github.com/buildpacks/lifecycle/launch"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestSupervisor(t *testing.T) {
+	spec.Run(t, "Supervisor", testSupervisor, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testSupervisor(t *testing.T, when spec.G, it spec.S) {
+	var (
+		subject     *launch.Supervisor
+		scripts     map[string]string
+		out, errOut bytes.Buffer
+	)
+
+	it.Before(func() {
+		h.SkipIf(t, runtime.GOOS == "windows", "skip supervisor tests on windows")
+		out.Reset()
+		errOut.Reset()
+		scripts = map[string]string{}
+		subject = &launch.Supervisor{
+			Command: func(procType string) *exec.Cmd {
+				return exec.Command("sh", "-c", scripts[procType]) // #nosec G204
+			},
+			StopTimeout: time.Second,
+			Stdout:      &out,
+			Stderr:      &errOut,
+		}
+	})
+
+	when("#Run", func() {
+		it("prefixes the output of each process with its type", func() {
+			scripts["web"] = "echo some-web-output; printf 'partial'"
+			scripts["worker"] = "echo some-worker-error >&2"
+			subject.Processes = []string{"web", "worker"}
+			subject.ExitPolicy = launch.ExitOnAll
+
+			h.AssertNil(t, subject.Run())
+
+			h.AssertStringContains(t, out.String(), "[web] some-web-output\n")
+			h.AssertStringContains(t, out.String(), "[web] partial\n")
+			h.AssertStringContains(t, errOut.String(), "[worker] some-worker-error\n")
+		})
+
+		when("exit policy is any", func() {
+			it("stops the remaining processes and returns the exit code", func() {
+				scripts["web"] = "exit 3"
+				scripts["worker"] = "sleep 30"
+				subject.Processes = []string{"web", "worker"}
+				subject.ExitPolicy = launch.ExitOnAny
+
+				start := time.Now()
+				err := subject.Run()
+
+				exitErr, ok := err.(*launch.ProcessExitError)
+				h.AssertEq(t, ok, true)
+				h.AssertEq(t, exitErr.Type, "web")
+				h.AssertEq(t, exitErr.Code, 3)
+				if time.Since(start) > 10*time.Second {
+					t.Fatalf("expected worker to be stopped")
+				}
+			})
+		})
+
+		when("exit policy is all", func() {
+			it("waits for every process", func() {
+				scripts["web"] = "exit 0"
+				scripts["worker"] = "sleep 1; echo done"
+				subject.Processes = []string{"web", "worker"}
+				subject.ExitPolicy = launch.ExitOnAll
+
+				h.AssertNil(t, subject.Run())
+				h.AssertStringContains(t, out.String(), "[worker] done")
+			})
+		})
+
+		when("restart policy is on-failure", func() {
+			it("restarts failed processes up to the maximum number of restarts", func() {
+				scripts["web"] = "echo attempt; exit 1"
+				subject.Processes = []string{"web"}
+				subject.RestartPolicy = launch.RestartOnFailure
+				subject.MaxRestarts = 2
+
+				err := subject.Run()
+
+				h.AssertError(t, err, "process type 'web' exited with code 1")
+				h.AssertEq(t, strings.Count(out.String(), "[web] attempt"), 3)
+				h.AssertEq(t, strings.Count(errOut.String(), "[supervisor] Restarting process type 'web'"), 2)
+			})
+
+			it("stops pending restarts when the exit policy is met", func() {
+				scripts["web"] = "exit 1"
+				scripts["worker"] = "sleep 1"
+				subject.Processes = []string{"web", "worker"}
+				subject.RestartPolicy = launch.RestartOnFailure
+				subject.RestartDelay = 30 * time.Second
+				subject.ExitPolicy = launch.ExitOnAny
+
+				start := time.Now()
+				err := subject.Run()
+
+				h.AssertError(t, err, "process type 'web' exited with code 1")
+				if time.Since(start) > 10*time.Second {
+					t.Fatalf("expected the pending restart to be stopped")
+				}
+			})
+
+			it("does not restart processes that succeed", func() {
+				scripts["web"] = "echo attempt"
+				subject.Processes = []string{"web"}
+				subject.RestartPolicy = launch.RestartOnFailure
+
+				h.AssertNil(t, subject.Run())
+				h.AssertEq(t, strings.Count(out.String(), "[web] attempt"), 1)
+			})
+		})
+	})
+
+	when("#ProcessCommand", func() {
+		it("selects the process type by argv0", func() {
+			launcher := &launch.Launcher{PlatformAPI: api.MustParse("0.8")}
+
+			c := launcher.ProcessCommand("/some/launcher", "web", []string{"SOME_VAR=some-val"})
+
+			h.AssertEq(t, c.Path, "/some/launcher")
+			h.AssertEq(t, c.Args, []string{launch.ProcessPath("web")})
+			h.AssertEq(t, c.Env, []string{"SOME_VAR=some-val"})
+		})
+
+		when("platform API < 0.4", func() {
+			it("selects the process type by argument", func() {
+				launcher := &launch.Launcher{PlatformAPI: api.MustParse("0.3")}
+
+				c := launcher.ProcessCommand("/some/launcher", "web", nil)
+
+				h.AssertEq(t, c.Args, []string{"/some/launcher", "web"})
+			})
+		})
+	})
+}
@@ -0,0 +1,45 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package launch
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+var forwardedSignals = []os.Signal{
+	syscall.SIGHUP,
+	syscall.SIGINT,
+	syscall.SIGQUIT,
+	syscall.SIGTERM,
+	syscall.SIGUSR1,
+	syscall.SIGUSR2,
+}
+
+// setProcessGroup starts the process in its own process group,
+// so that signals reach any processes it starts as well.
+func setProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func forwardSignal(p *os.Process, sig os.Signal) error {
+	if sysSig, ok := sig.(syscall.Signal); ok {
+		return syscall.Kill(-p.Pid, sysSig)
+	}
+	return p.Signal(sig)
+}
+
+func terminate(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGTERM)
+}
+
+func kill(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
+
+// stopsProcesses returns true if sig is expected to stop the processes it is forwarded to.
+func stopsProcesses(sig os.Signal) bool {
+	return sig == syscall.SIGINT || sig == syscall.SIGQUIT || sig == syscall.SIGTERM
+}
@@ -0,0 +1,27 @@
+package launch
+
+import (
+	"os"
+	"os/exec"
+)
+
+var forwardedSignals = []os.Signal{os.Interrupt}
+
+func setProcessGroup(_ *exec.Cmd) {}
+
+// forwardSignal stops the process, Windows does not support sending signals to other processes.
+func forwardSignal(p *os.Process, _ os.Signal) error {
+	return p.Kill()
+}
+
+func terminate(p *os.Process) error {
+	return p.Kill()
+}
+
+func kill(p *os.Process) error {
+	return p.Kill()
+}
+
+func stopsProcesses(_ os.Signal) bool {
+	return true
+}
@@ -0,0 +1,83 @@
+package layers
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/buildpacks/lifecycle/archive"
+)
+
+// CopyLayer creates a layer with the file or directory at src placed at dest
+// Entries describing src and its children and the parents of dest are root owned
+func (f *Factory) CopyLayer(id, src, dest string) (layer Layer, err error) {
+	src, err = filepath.Abs(src)
+	if err != nil {
+		return Layer{}, err
+	}
+	fi, err := os.Stat(src)
+	if err != nil {
+		return Layer{}, errors.Wrapf(err, "failed to stat '%s'", src)
+	}
+	var parents []string
+	for parent := filepath.Dir(dest); parent != filepath.Dir(parent); parent = filepath.Dir(parent) {
+		parents = append([]string{parent}, parents...)
+	}
+
+	return f.writeLayer(id, func(tw *archive.NormalizingTarWriter) error {
+		for _, parent := range parents {
+			if err := tw.WriteHeader(rootOwnedDir(parent)); err != nil {
+				return err
+			}
+		}
+		tw.WithUID(0)
+		tw.WithGID(0)
+		tw.WithReplacedPrefix(src, dest)
+		if fi.IsDir() {
+			return archive.AddDirToArchive(tw, src)
+		}
+		return archive.AddFileToArchive(tw, src, fi)
+	})
+}
+
+// TarballLayer creates a layer from the tarball at path, which is decompressed if it is gzipped
+func (f *Factory) TarballLayer(id, path string) (layer Layer, err error) {
+	tarball, err := os.Open(path)
+	if err != nil {
+		return Layer{}, errors.Wrapf(err, "failed to open tarball '%s'", path)
+	}
+	defer tarball.Close()
+
+	var r io.Reader = bufio.NewReader(tarball)
+	if magic, _ := r.(*bufio.Reader).Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			return Layer{}, errors.Wrapf(err, "failed to decompress tarball '%s'", path)
+		}
+		defer gzr.Close()
+		r = gzr
+	}
+
+	tarPath := filepath.Join(f.ArtifactsDir, escape(id)+".tar")
+	lw, err := newFileLayerWriter(tarPath)
+	if err != nil {
+		return Layer{}, err
+	}
+	defer func() {
+		if closeErr := lw.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	if _, err := io.Copy(lw, r); err != nil {
+		return Layer{}, errors.Wrapf(err, "failed to copy tarball '%s'", path)
+	}
+	return Layer{
+		ID:      id,
+		TarPath: tarPath,
+		Digest:  lw.Digest(),
+	}, nil
+}
@@ -0,0 +1,151 @@
+package layers_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/apex/log"
+	"github.com/apex/log/handlers/memory"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/layers"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestExtensionLayers(t *testing.T) {
+	spec.Run(t, "Factory", testExtensionLayers, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testExtensionLayers(t *testing.T, when spec.G, it spec.S) {
+	var (
+		factory *layers.Factory
+		tmpDir  string
+	)
+
+	it.Before(func() {
+		var err error
+		artifactDir, err := ioutil.TempDir("", "layers.extension.layer")
+		h.AssertNil(t, err)
+		factory = &layers.Factory{
+			ArtifactsDir: artifactDir,
+			Logger:       &log.Logger{Handler: memory.New()},
+			UID:          1234,
+			GID:          4321,
+		}
+		tmpDir, err = ioutil.TempDir("", "layers.extension")
+		h.AssertNil(t, err)
+	})
+
+	it.After(func() {
+		h.AssertNil(t, os.RemoveAll(factory.ArtifactsDir))
+		h.AssertNil(t, os.RemoveAll(tmpDir))
+	})
+
+	when("#CopyLayer", func() {
+		it.Before(func() {
+			h.SkipIf(t, runtime.GOOS == "windows", "layer paths are unix paths")
+		})
+
+		it("creates a layer with the directory at the destination", func() {
+			layer, err := factory.CopyLayer("extension-1", filepath.Join("testdata", "target-dir", "some-dir"), "/etc/some-app")
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, layer.ID, "extension-1")
+			assertTarEntries(t, layer.TarPath, []*tar.Header{
+				{Name: "/etc", Typeflag: tar.TypeDir, Mode: 0755},
+				{Name: "/etc/some-app", Typeflag: tar.TypeDir},
+				{Name: "/etc/some-app/file.md", Typeflag: tar.TypeReg},
+				{Name: "/etc/some-app/some-file.txt", Typeflag: tar.TypeReg},
+			})
+		})
+
+		it("creates a layer with the file at the destination", func() {
+			src := filepath.Join(tmpDir, "app.conf")
+			h.Mkfile(t, "some-config", src)
+
+			layer, err := factory.CopyLayer("extension-1", src, "/etc/app/app.conf")
+			h.AssertNil(t, err)
+
+			assertTarEntries(t, layer.TarPath, []*tar.Header{
+				{Name: "/etc", Typeflag: tar.TypeDir},
+				{Name: "/etc/app", Typeflag: tar.TypeDir},
+				{Name: "/etc/app/app.conf", Typeflag: tar.TypeReg},
+			})
+			assertEntryContent(t, layer.TarPath, "/etc/app/app.conf", "some-config")
+		})
+
+		it("fails when the source does not exist", func() {
+			_, err := factory.CopyLayer("extension-1", filepath.Join(tmpDir, "missing"), "/etc/missing")
+			h.AssertError(t, err, "failed to stat")
+		})
+	})
+
+	when("#TarballLayer", func() {
+		var (
+			tarball string
+			digest  string
+		)
+
+		it.Before(func() {
+			tarball = filepath.Join(tmpDir, "layer.tar")
+			f, err := os.Create(tarball)
+			h.AssertNil(t, err)
+			tw := tar.NewWriter(f)
+			h.AssertNil(t, tw.WriteHeader(&tar.Header{Name: "/opt/some-file.txt", Typeflag: tar.TypeReg, Size: 12, Mode: 0644}))
+			_, err = tw.Write([]byte("some-content"))
+			h.AssertNil(t, err)
+			h.AssertNil(t, tw.Close())
+			h.AssertNil(t, f.Close())
+			digest = fileDigest(t, tarball)
+		})
+
+		it("creates a layer from the tarball", func() {
+			layer, err := factory.TarballLayer("extension-1", tarball)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, layer.ID, "extension-1")
+			h.AssertEq(t, layer.Digest, digest)
+			assertEntryContent(t, layer.TarPath, "/opt/some-file.txt", "some-content")
+		})
+
+		it("decompresses gzipped tarballs", func() {
+			gzipped := filepath.Join(tmpDir, "layer.tgz")
+			in, err := os.Open(tarball)
+			h.AssertNil(t, err)
+			defer in.Close()
+			out, err := os.Create(gzipped)
+			h.AssertNil(t, err)
+			gzw := gzip.NewWriter(out)
+			_, err = io.Copy(gzw, in)
+			h.AssertNil(t, err)
+			h.AssertNil(t, gzw.Close())
+			h.AssertNil(t, out.Close())
+
+			layer, err := factory.TarballLayer("extension-1", gzipped)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, layer.Digest, digest)
+			assertEntryContent(t, layer.TarPath, "/opt/some-file.txt", "some-content")
+		})
+	})
+}
+
+func fileDigest(t *testing.T, path string) string {
+	t.Helper()
+	f, err := os.Open(path)
+	h.AssertNil(t, err)
+	defer f.Close()
+	hasher := sha256.New()
+	_, err = io.Copy(hasher, f)
+	h.AssertNil(t, err)
+	return fmt.Sprintf("sha256:%x", hasher.Sum(nil))
+}
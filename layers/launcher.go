@@ -62,11 +62,13 @@ func (f *Factory) LauncherLayer(path string) (layer Layer, err error) {
 // ProcessTypesLayer creates a Layer containing symlinks pointing to target where:
 //    * any parents of the symlink files will also be added to the layer
 //    * symlinks and their parent directories shall be root owned and world readable
+// Process types that declare a health check also get a symlink in the health directory, that runs the health check.
 func (f *Factory) ProcessTypesLayer(config launch.Metadata) (layer Layer, err error) {
 	hdrs := []*tar.Header{
 		rootOwnedDir(launch.CNBDir),
 		rootOwnedDir(launch.ProcessDir),
 	}
+	var healthHdrs []*tar.Header
 	for _, proc := range config.Processes {
 		if len(proc.Type) == 0 {
 			return Layer{}, errors.New("type is required for all processes")
@@ -75,6 +77,12 @@ func (f *Factory) ProcessTypesLayer(config launch.Metadata) (layer Layer, err er
 			return Layer{}, errors.Wrapf(err, "invalid process type '%s'", proc.Type)
 		}
 		hdrs = append(hdrs, typeSymlink(launch.ProcessPath(proc.Type)))
+		if proc.HealthCheck != nil {
+			healthHdrs = append(healthHdrs, typeSymlink(launch.HealthCheckPath(proc.Type)))
+		}
+	}
+	if len(healthHdrs) > 0 {
+		hdrs = append(append(hdrs, rootOwnedDir(launch.HealthDir)), healthHdrs...)
 	}
 
 	return f.writeLayer("process-types", func(tw *archive.NormalizingTarWriter) error {
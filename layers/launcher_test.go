@@ -92,6 +92,55 @@ func testLauncherLayers(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		it("adds health check symlinks for process types that declare a health check", func() {
+			proc1 := launch.Process{Type: "some-type", HealthCheck: &launch.HealthCheck{Type: launch.HealthCheckTCP, Port: 8080}}
+			proc2 := launch.Process{Type: "other-type"}
+			configLayer, err := factory.ProcessTypesLayer(launch.Metadata{Processes: []launch.Process{
+				proc1,
+				proc2,
+			}})
+			h.AssertNil(t, err)
+			var mode int64 = 0755
+			if runtime.GOOS == "windows" {
+				mode = 0777
+			}
+			assertTarEntries(t, configLayer.TarPath, []*tar.Header{
+				{
+					Name:     tarPath("/cnb"),
+					Mode:     mode,
+					Typeflag: tar.TypeDir,
+				},
+				{
+					Name:     tarPath("/cnb/process"),
+					Mode:     mode,
+					Typeflag: tar.TypeDir,
+				},
+				{
+					Name:     tarPath(launch.ProcessPath(proc1.Type)),
+					Mode:     mode,
+					Typeflag: tar.TypeSymlink,
+					Linkname: launch.LauncherPath,
+				},
+				{
+					Name:     tarPath(launch.ProcessPath(proc2.Type)),
+					Mode:     mode,
+					Typeflag: tar.TypeSymlink,
+					Linkname: launch.LauncherPath,
+				},
+				{
+					Name:     tarPath("/cnb/health"),
+					Mode:     mode,
+					Typeflag: tar.TypeDir,
+				},
+				{
+					Name:     tarPath(launch.HealthCheckPath(proc1.Type)),
+					Mode:     mode,
+					Typeflag: tar.TypeSymlink,
+					Linkname: launch.LauncherPath,
+				},
+			})
+		})
+
 		when("process-type contains invalid character", func() {
 			it("returns an error", func() {
 				_, err := factory.ProcessTypesLayer(launch.Metadata{Processes: []launch.Process{
@@ -1,6 +1,13 @@
 package platform
 
-import "github.com/buildpacks/lifecycle/buildpack"
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+)
 
 type CacheMetadata struct {
 	BOM        LayerMetadata              `json:"sbom"`
@@ -15,3 +22,134 @@ func (cm *CacheMetadata) MetadataForBuildpack(id string) buildpack.LayersMetadat
 	}
 	return buildpack.LayersMetadata{}
 }
+
+// RemoveLayers removes every cached layer matching one of the provided selectors
+// and returns the identifiers (<buildpack-id>:<layer-name>) of the removed layers in sorted order.
+func (cm *CacheMetadata) RemoveLayers(selectors ...CacheLayerSelector) []string {
+	var removed []string
+	if len(selectors) == 0 {
+		return removed
+	}
+	for _, bpMD := range cm.Buildpacks {
+		for name := range bpMD.Layers {
+			for _, selector := range selectors {
+				if selector.Matches(bpMD.ID, name) {
+					delete(bpMD.Layers, name)
+					removed = append(removed, bpMD.ID+":"+name)
+					break
+				}
+			}
+		}
+	}
+	sort.Strings(removed)
+	return removed
+}
+
+// AddMissingLayers adds the layers of other that are not in cm, matching them by buildpack ID and layer name,
+// and returns the identifiers (<buildpack-id>:<layer-name>) of the added layers in sorted order.
+func (cm *CacheMetadata) AddMissingLayers(other CacheMetadata) []string {
+	var added []string
+	for _, otherMD := range other.Buildpacks {
+		idx := -1
+		for i, bpMD := range cm.Buildpacks {
+			if bpMD.ID == otherMD.ID {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			cm.Buildpacks = append(cm.Buildpacks, buildpack.LayersMetadata{ID: otherMD.ID, Version: otherMD.Version})
+			idx = len(cm.Buildpacks) - 1
+		}
+		if cm.Buildpacks[idx].Layers == nil {
+			cm.Buildpacks[idx].Layers = map[string]buildpack.LayerMetadata{}
+		}
+		for name, lmd := range otherMD.Layers {
+			if _, ok := cm.Buildpacks[idx].Layers[name]; ok {
+				continue
+			}
+			cm.Buildpacks[idx].Layers[name] = lmd
+			added = append(added, otherMD.ID+":"+name)
+		}
+	}
+	sort.Strings(added)
+	return added
+}
+
+// CacheLayerSelector selects cached layers by buildpack ID and, optionally, layer name.
+// Both parts may be glob patterns as understood by path.Match, except that '*' and '?' also match '/',
+// so that '*' selects buildpack IDs like 'paketo-buildpacks/node'.
+type CacheLayerSelector struct {
+	Buildpack string
+	Layer     string
+}
+
+// ParseCacheLayerSelector parses a selector of the form <buildpack-id>[:<layer-name>].
+func ParseCacheLayerSelector(s string) (CacheLayerSelector, error) {
+	bp, layer := s, "*"
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		bp, layer = s[:i], s[i+1:]
+	}
+	if bp == "" || layer == "" {
+		return CacheLayerSelector{}, fmt.Errorf("invalid cache layer selector %q: expected <buildpack-id>[:<layer-name>]", s)
+	}
+	for _, pattern := range []string{bp, layer} {
+		if _, err := globMatch(pattern, ""); err != nil {
+			return CacheLayerSelector{}, fmt.Errorf("invalid cache layer selector %q: %s", s, err)
+		}
+	}
+	return CacheLayerSelector{Buildpack: bp, Layer: layer}, nil
+}
+
+// ParseCacheLayerSelectors parses each of the provided selectors, see ParseCacheLayerSelector.
+func ParseCacheLayerSelectors(selectors []string) ([]CacheLayerSelector, error) {
+	var parsed []CacheLayerSelector
+	for _, s := range selectors {
+		selector, err := ParseCacheLayerSelector(s)
+		if err != nil {
+			return nil, err
+		}
+		parsed = append(parsed, selector)
+	}
+	return parsed, nil
+}
+
+// Matches returns true if the layer with the given name, belonging to the buildpack with the given ID, is selected.
+func (s CacheLayerSelector) Matches(buildpackID, layerName string) bool {
+	bpMatch, _ := globMatch(s.Buildpack, buildpackID)
+	if !bpMatch {
+		return false
+	}
+	layerMatch, _ := globMatch(s.Layer, layerName)
+	return layerMatch
+}
+
+// globMatch is path.Match with '/' treated as an ordinary character.
+func globMatch(pattern, name string) (bool, error) {
+	const sep = "\x00"
+	return path.Match(strings.ReplaceAll(pattern, "/", sep), strings.ReplaceAll(name, "/", sep))
+}
+
+func (s CacheLayerSelector) String() string {
+	return s.Buildpack + ":" + s.Layer
+}
+
+// CacheReport describes the contents of a cache.
+type CacheReport struct {
+	Name       string                 `json:"name"`
+	Buildpacks []CacheBuildpackReport `json:"buildpacks"`
+	SBOM       *CacheLayerReport      `json:"sbom,omitempty"`
+}
+
+type CacheBuildpackReport struct {
+	ID      string             `json:"id"`
+	Version string             `json:"version"`
+	Layers  []CacheLayerReport `json:"layers"`
+}
+
+type CacheLayerReport struct {
+	Name     string `json:"name"`
+	SHA      string `json:"sha"`
+	Size     int64  `json:"size"`               // size as stored in the cache, compressed for cache images
+	Metadata string `json:"metadata,omitempty"` // <layer>.toml contents
+}
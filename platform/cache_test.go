@@ -0,0 +1,131 @@
+package platform_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/platform"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestCache(t *testing.T) {
+	spec.Run(t, "Cache", testCache, spec.Report(report.Terminal{}))
+}
+
+func testCache(t *testing.T, when spec.G, it spec.S) {
+	when("#ParseCacheLayerSelector", func() {
+		it("selects all layers of a buildpack", func() {
+			selector, err := platform.ParseCacheLayerSelector("some/buildpack")
+			h.AssertNil(t, err)
+			h.AssertEq(t, selector, platform.CacheLayerSelector{Buildpack: "some/buildpack", Layer: "*"})
+		})
+
+		it("selects a single layer", func() {
+			selector, err := platform.ParseCacheLayerSelector("some/buildpack:some-layer")
+			h.AssertNil(t, err)
+			h.AssertEq(t, selector, platform.CacheLayerSelector{Buildpack: "some/buildpack", Layer: "some-layer"})
+		})
+
+		it("errors when a part is empty", func() {
+			_, err := platform.ParseCacheLayerSelector("some/buildpack:")
+			h.AssertError(t, err, `invalid cache layer selector "some/buildpack:"`)
+		})
+
+		it("errors when a pattern is malformed", func() {
+			_, err := platform.ParseCacheLayerSelector("some/[buildpack")
+			h.AssertError(t, err, `invalid cache layer selector "some/[buildpack"`)
+		})
+	})
+
+	when("#RemoveLayers", func() {
+		var cacheMeta platform.CacheMetadata
+
+		it.Before(func() {
+			cacheMeta = platform.CacheMetadata{
+				Buildpacks: []buildpack.LayersMetadata{
+					{
+						ID: "some/buildpack",
+						Layers: map[string]buildpack.LayerMetadata{
+							"some-layer":  {SHA: "some-sha"},
+							"other-layer": {SHA: "other-sha"},
+						},
+					},
+					{
+						ID: "other/buildpack",
+						Layers: map[string]buildpack.LayerMetadata{
+							"some-layer": {SHA: "third-sha"},
+						},
+					},
+				},
+			}
+		})
+
+		it("removes nothing without selectors", func() {
+			h.AssertEq(t, len(cacheMeta.RemoveLayers()), 0)
+			h.AssertEq(t, len(cacheMeta.MetadataForBuildpack("some/buildpack").Layers), 2)
+		})
+
+		it("removes all layers of a buildpack", func() {
+			removed := cacheMeta.RemoveLayers(platform.CacheLayerSelector{Buildpack: "some/buildpack", Layer: "*"})
+			h.AssertEq(t, removed, []string{"some/buildpack:other-layer", "some/buildpack:some-layer"})
+			h.AssertEq(t, len(cacheMeta.MetadataForBuildpack("some/buildpack").Layers), 0)
+			h.AssertEq(t, len(cacheMeta.MetadataForBuildpack("other/buildpack").Layers), 1)
+		})
+
+		it("removes layers matching a glob", func() {
+			removed := cacheMeta.RemoveLayers(platform.CacheLayerSelector{Buildpack: "*/buildpack", Layer: "some-*"})
+			h.AssertEq(t, removed, []string{"other/buildpack:some-layer", "some/buildpack:some-layer"})
+			h.AssertEq(t, len(cacheMeta.MetadataForBuildpack("some/buildpack").Layers), 1)
+			h.AssertEq(t, len(cacheMeta.MetadataForBuildpack("other/buildpack").Layers), 0)
+		})
+
+		it("matches buildpack IDs containing '/' with '*'", func() {
+			removed := cacheMeta.RemoveLayers(platform.CacheLayerSelector{Buildpack: "*", Layer: "some-layer"})
+			h.AssertEq(t, removed, []string{"other/buildpack:some-layer", "some/buildpack:some-layer"})
+		})
+
+		it("matches every layer with '*'", func() {
+			selector, err := platform.ParseCacheLayerSelector("*")
+			h.AssertNil(t, err)
+			removed := cacheMeta.RemoveLayers(selector)
+			h.AssertEq(t, len(removed), 3)
+		})
+	})
+
+	when("#AddMissingLayers", func() {
+		it("adds the layers missing by buildpack ID and layer name", func() {
+			cacheMeta := platform.CacheMetadata{
+				Buildpacks: []buildpack.LayersMetadata{{
+					ID:     "some/buildpack",
+					Layers: map[string]buildpack.LayerMetadata{"some-layer": {SHA: "some-sha"}},
+				}},
+			}
+			seedMeta := platform.CacheMetadata{
+				Buildpacks: []buildpack.LayersMetadata{
+					{
+						ID: "some/buildpack",
+						Layers: map[string]buildpack.LayerMetadata{
+							"some-layer":  {SHA: "seed-sha"},
+							"other-layer": {SHA: "other-seed-sha"},
+						},
+					},
+					{
+						ID:     "other/buildpack",
+						Layers: map[string]buildpack.LayerMetadata{"some-layer": {SHA: "third-seed-sha"}},
+					},
+				},
+			}
+
+			added := cacheMeta.AddMissingLayers(seedMeta)
+			h.AssertEq(t, added, []string{"other/buildpack:some-layer", "some/buildpack:other-layer"})
+			h.AssertEq(t, cacheMeta.MetadataForBuildpack("some/buildpack").Layers, map[string]buildpack.LayerMetadata{
+				"some-layer":  {SHA: "some-sha"},
+				"other-layer": {SHA: "other-seed-sha"},
+			})
+			h.AssertEq(t, cacheMeta.MetadataForBuildpack("other/buildpack").Layers["some-layer"].SHA, "third-seed-sha")
+		})
+	})
+}
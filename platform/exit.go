@@ -9,6 +9,7 @@ const (
 	FailedDetectWithErrors                    // no buildpacks detected
 	DetectError                               // no buildpacks detected and at least one errored
 	AnalyzeError                              // generic analyze error
+	RegistryAccessError                       // keychain cannot access an image the build reads or writes
 	RestoreError                              // generic restore error
 	FailedBuildWithErrors                     // buildpack error during /bin/build
 	BuildError                                // generic build error
@@ -30,7 +31,8 @@ var defaultExitCodes = map[LifecycleExitError]int{
 	DetectError:            22, // DetectError indicates generic detect error
 
 	// analyze phase errors: 30-39
-	AnalyzeError: 32, // AnalyzeError indicates generic analyze error
+	RegistryAccessError: 31, // RegistryAccessError indicates that the keychain cannot access an image the build reads or writes
+	AnalyzeError:        32, // AnalyzeError indicates generic analyze error
 
 	// restore phase errors: 40-49
 	RestoreError: 42, // RestoreError indicates generic restore error
@@ -62,7 +64,8 @@ var legacyExitCodes = map[LifecycleExitError]int{
 	DetectError:            102, // DetectError indicates generic detect error
 
 	// analyze phase errors: 200-299
-	AnalyzeError: 202, // AnalyzeError indicates generic analyze error
+	RegistryAccessError: 201, // RegistryAccessError indicates that the keychain cannot access an image the build reads or writes
+	AnalyzeError:        202, // AnalyzeError indicates generic analyze error
 
 	// restore phase errors: 300-399
 	RestoreError: 302, // RestoreError indicates generic restore error
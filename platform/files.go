@@ -17,9 +17,11 @@ import (
 // analyzed.toml
 
 type AnalyzedMetadata struct {
-	PreviousImage *ImageIdentifier `toml:"image"`
-	Metadata      LayersMetadata   `toml:"metadata"`
-	RunImage      *ImageIdentifier `toml:"run-image,omitempty"`
+	PreviousImage   *ImageIdentifier         `toml:"image"`
+	Metadata        LayersMetadata           `toml:"metadata"`
+	MetadataSalvage *MetadataSalvage         `toml:"metadata-salvage,omitempty"`
+	RunImage        *ImageIdentifier         `toml:"run-image,omitempty"`
+	RunImageMirrors *RunImageMirrorSelection `toml:"run-image-mirrors,omitempty"`
 }
 
 // FIXME: fix key names to be accurate in the daemon case
@@ -27,6 +29,21 @@ type ImageIdentifier struct {
 	Reference string `toml:"reference"`
 }
 
+// RunImageMirrorSelection records how the run image was chosen from the run image mirrors in stack.toml.
+type RunImageMirrorSelection struct {
+	Selected     string           `toml:"selected"`
+	Digest       string           `toml:"digest,omitempty"`
+	Inconsistent []string         `toml:"inconsistent,omitempty"` // reachable mirrors serving a different digest than the selected mirror
+	Mirrors      []RunImageMirror `toml:"mirrors"`
+}
+
+type RunImageMirror struct {
+	Reference string `toml:"reference"`
+	Reachable bool   `toml:"reachable"`
+	Digest    string `toml:"digest,omitempty"`
+	Error     string `toml:"error,omitempty"`
+}
+
 // NOTE: This struct MUST be kept in sync with `LayersMetadataCompat`
 type LayersMetadata struct {
 	App          []LayerMetadata            `json:"app" toml:"app"`
@@ -67,8 +84,22 @@ type LayerMetadata struct {
 }
 
 type RunImageMetadata struct {
-	TopLayer  string `json:"topLayer" toml:"top-layer"`
-	Reference string `json:"reference" toml:"reference"`
+	TopLayer   string          `json:"topLayer" toml:"top-layer"`
+	Reference  string          `json:"reference" toml:"reference"`
+	Extensions []LayerMetadata `json:"extensions,omitempty" toml:"extensions,omitempty"` // layers added by run image extensions, above TopLayer
+}
+
+// RebaseHistory lists the run images an app image was previously based on, oldest first.
+type RebaseHistory struct {
+	RunImages []RunImageMetadata `json:"runImages"`
+}
+
+// Previous returns the run image the app image was based on before its last rebase, and false if there is none.
+func (h RebaseHistory) Previous() (RunImageMetadata, bool) {
+	if len(h.RunImages) == 0 {
+		return RunImageMetadata{}, false
+	}
+	return h.RunImages[len(h.RunImages)-1], true
 }
 
 // metadata.toml
@@ -222,31 +253,33 @@ type StackRunImageMetadata struct {
 }
 
 func (sm *StackMetadata) BestRunImageMirror(registry string) (string, error) {
-	if sm.RunImage.Image == "" {
-		return "", errors.New("missing run-image metadata")
-	}
-	runImageMirrors := []string{sm.RunImage.Image}
-	runImageMirrors = append(runImageMirrors, sm.RunImage.Mirrors...)
-	runImageRef, err := byRegistry(registry, runImageMirrors)
+	runImageMirrors, err := sm.RunImageMirrorsByPriority(registry)
 	if err != nil {
-		return "", errors.Wrap(err, "failed to find run-image")
+		return "", err
 	}
-	return runImageRef, nil
+	return runImageMirrors[0], nil
 }
 
-func byRegistry(reg string, imgs []string) (string, error) {
-	if len(imgs) < 1 {
-		return "", errors.New("no images provided to search")
+// RunImageMirrorsByPriority returns the run image and its mirrors, those on the given registry first.
+// Otherwise the order of stack.toml is kept, with the run image before its mirrors.
+func (sm *StackMetadata) RunImageMirrorsByPriority(registry string) ([]string, error) {
+	if sm.RunImage.Image == "" {
+		return nil, errors.New("missing run-image metadata")
 	}
+	runImageMirrors := []string{sm.RunImage.Image}
+	runImageMirrors = append(runImageMirrors, sm.RunImage.Mirrors...)
+	return byRegistry(registry, runImageMirrors), nil
+}
 
+func byRegistry(reg string, imgs []string) []string {
+	var matching, others []string
 	for _, img := range imgs {
 		ref, err := name.ParseReference(img, name.WeakValidation)
-		if err != nil {
+		if err == nil && reg == ref.Context().RegistryStr() {
+			matching = append(matching, img)
 			continue
 		}
-		if reg == ref.Context().RegistryStr() {
-			return img, nil
-		}
+		others = append(others, img)
 	}
-	return imgs[0], nil
+	return append(matching, others...)
 }
@@ -76,6 +76,30 @@ func testMetadata(t *testing.T, when spec.G, it spec.S) {
 		})
 	})
 
+	when("RunImageMirrorsByPriority", func() {
+		it("puts the images on the registry first and otherwise keeps the order", func() {
+			stackMD := &platform.StackMetadata{RunImage: platform.StackRunImageMetadata{
+				Image:   "first.com/org/repo",
+				Mirrors: []string{"myorg/myrepo", "gcr.io/org/repo", "second.com/org/repo", "gcr.io/other/repo"},
+			}}
+
+			mirrors, err := stackMD.RunImageMirrorsByPriority("gcr.io")
+			h.AssertNil(t, err)
+			h.AssertEq(t, mirrors, []string{
+				"gcr.io/org/repo",
+				"gcr.io/other/repo",
+				"first.com/org/repo",
+				"myorg/myrepo",
+				"second.com/org/repo",
+			})
+		})
+
+		it("errors when there is no run image", func() {
+			_, err := (&platform.StackMetadata{}).RunImageMirrorsByPriority("gcr.io")
+			h.AssertError(t, err, "missing run-image metadata")
+		})
+	})
+
 	when("MarshalJSON", func() {
 		var (
 			buildMD    *platform.BuildMetadata
@@ -4,6 +4,10 @@ const (
 	BuildMetadataLabel   = "io.buildpacks.build.metadata"
 	LayerMetadataLabel   = "io.buildpacks.lifecycle.metadata"
 	ProjectMetadataLabel = "io.buildpacks.project.metadata"
+	RebaseHistoryLabel   = "io.buildpacks.rebase.history"
+	RebaseWarningLabel   = "io.buildpacks.rebase.warning"
 	StackIDLabel         = "io.buildpacks.stack.id"
 	MixinsLabel          = "io.buildpacks.stack.mixins"
+	DistroNameLabel      = "io.buildpacks.stack.distro.name"
+	DistroVersionLabel   = "io.buildpacks.stack.distro.version"
 )
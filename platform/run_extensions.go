@@ -0,0 +1,105 @@
+package platform
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	RunExtensionAdd   = "ADD"   // ADD <tarball> adds a pre-built layer tarball
+	RunExtensionCopy  = "COPY"  // COPY <src> <dest> adds a layer with the file or directory at src placed at dest
+	RunExtensionEnv   = "ENV"   // ENV <key>=<value> sets an environment variable
+	RunExtensionLabel = "LABEL" // LABEL <key>=<value> sets a label
+)
+
+// RunExtension is an instruction extending the run image, applied by the exporter before buildpack layers.
+type RunExtension struct {
+	Instruction string // one of RunExtensionAdd, RunExtensionCopy, RunExtensionEnv or RunExtensionLabel
+	Source      string // path of the tarball or the file to copy, for ADD and COPY
+	Destination string // absolute path in the image, for COPY
+	Key         string // for ENV and LABEL
+	Value       string // for ENV and LABEL
+}
+
+// ReadRunExtensions reads the run image extension instructions in the Dockerfile-like file at path, one per line.
+// Blank lines and lines starting with '#' are ignored, relative sources are resolved against the directory of the file.
+// Instructions that would execute commands in the image, like RUN, are not supported.
+func ReadRunExtensions(path string) ([]RunExtension, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var extensions []RunExtension
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		ext, err := parseRunExtension(line, filepath.Dir(path))
+		if err != nil {
+			return nil, fmt.Errorf("line %d of '%s': %s", lineNum, path, err)
+		}
+		extensions = append(extensions, ext)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "read run extensions '%s'", path)
+	}
+	return extensions, nil
+}
+
+func parseRunExtension(line, dir string) (RunExtension, error) {
+	instruction, rest := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		instruction, rest = line[:i], strings.TrimSpace(line[i:])
+	}
+	ext := RunExtension{Instruction: strings.ToUpper(instruction)}
+	switch ext.Instruction {
+	case RunExtensionAdd:
+		args := strings.Fields(rest)
+		if len(args) != 1 {
+			return RunExtension{}, fmt.Errorf("%s requires a tarball", ext.Instruction)
+		}
+		ext.Source = resolve(dir, args[0])
+	case RunExtensionCopy:
+		args := strings.Fields(rest)
+		if len(args) != 2 {
+			return RunExtension{}, fmt.Errorf("%s requires a source and a destination", ext.Instruction)
+		}
+		if !strings.HasPrefix(args[1], "/") {
+			return RunExtension{}, fmt.Errorf("%s destination '%s' must be an absolute path", ext.Instruction, args[1])
+		}
+		ext.Source, ext.Destination = resolve(dir, args[0]), args[1]
+	case RunExtensionEnv, RunExtensionLabel:
+		sep := strings.IndexAny(rest, "= \t")
+		if sep <= 0 {
+			return RunExtension{}, fmt.Errorf("%s requires a key and a value", ext.Instruction)
+		}
+		ext.Key, ext.Value = rest[:sep], unquote(strings.TrimSpace(rest[sep+1:]))
+	default:
+		return RunExtension{}, fmt.Errorf("unsupported instruction '%s', only %s, %s, %s and %s are supported",
+			instruction, RunExtensionAdd, RunExtensionCopy, RunExtensionEnv, RunExtensionLabel)
+	}
+	return ext, nil
+}
+
+func resolve(dir, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
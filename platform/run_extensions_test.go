@@ -0,0 +1,90 @@
+package platform_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/platform"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestRunExtensions(t *testing.T) {
+	spec.Run(t, "RunExtensions", testRunExtensions, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testRunExtensions(t *testing.T, when spec.G, it spec.S) {
+	var (
+		tmpDir string
+		path   string
+	)
+
+	it.Before(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "lifecycle.run-extensions")
+		h.AssertNil(t, err)
+		path = filepath.Join(tmpDir, "run.Dockerfile")
+	})
+
+	it.After(func() {
+		_ = os.RemoveAll(tmpDir)
+	})
+
+	when("#ReadRunExtensions", func() {
+		it("reads the instructions in order", func() {
+			h.Mkfile(t, `# extend the run image
+ADD layers/packages.tar
+COPY config/app.conf /etc/app.conf
+
+env LANG=C.UTF-8
+ENV GREETING "hello world"
+LABEL org.example.team=payments
+`, path)
+
+			extensions, err := platform.ReadRunExtensions(path)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, extensions, []platform.RunExtension{
+				{Instruction: "ADD", Source: filepath.Join(tmpDir, "layers", "packages.tar")},
+				{Instruction: "COPY", Source: filepath.Join(tmpDir, "config", "app.conf"), Destination: "/etc/app.conf"},
+				{Instruction: "ENV", Key: "LANG", Value: "C.UTF-8"},
+				{Instruction: "ENV", Key: "GREETING", Value: "hello world"},
+				{Instruction: "LABEL", Key: "org.example.team", Value: "payments"},
+			})
+		})
+
+		it("keeps absolute sources", func() {
+			h.Mkfile(t, "ADD /some/packages.tar", path)
+
+			extensions, err := platform.ReadRunExtensions(path)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, extensions[0].Source, "/some/packages.tar")
+		})
+
+		it("fails on unsupported instructions", func() {
+			h.Mkfile(t, "ENV A=B\nRUN apt-get install -y curl", path)
+
+			_, err := platform.ReadRunExtensions(path)
+			h.AssertError(t, err, "line 2 of '"+path+"': unsupported instruction 'RUN', only ADD, COPY, ENV and LABEL are supported")
+		})
+
+		it("fails on a relative COPY destination", func() {
+			h.Mkfile(t, "COPY app.conf etc/app.conf", path)
+
+			_, err := platform.ReadRunExtensions(path)
+			h.AssertError(t, err, "COPY destination 'etc/app.conf' must be an absolute path")
+		})
+
+		it("fails on missing arguments", func() {
+			h.Mkfile(t, "LABEL", path)
+
+			_, err := platform.ReadRunExtensions(path)
+			h.AssertError(t, err, "LABEL requires a key and a value")
+		})
+	})
+}
@@ -0,0 +1,158 @@
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+)
+
+// MetadataSalvage summarizes the partial decode of previous image metadata that could not be decoded as a whole.
+type MetadataSalvage struct {
+	Error      string            `toml:"error"` // why the metadata could not be decoded as a whole
+	Buildpacks int               `toml:"buildpacks"`
+	Layers     int               `toml:"layers"`
+	Dropped    []DroppedMetadata `toml:"dropped,omitempty"`
+}
+
+// DroppedMetadata is a part of previous image metadata that could not be decoded.
+type DroppedMetadata struct {
+	Path      string `toml:"path"`                // e.g. buildpacks[1].layers.some-layer
+	Buildpack string `toml:"buildpack,omitempty"` // ID of the buildpack the part belongs to, if known
+	Reason    string `toml:"reason"`
+}
+
+func (d DroppedMetadata) String() string {
+	if d.Buildpack != "" {
+		return fmt.Sprintf("'%s' of buildpack '%s': %s", d.Path, d.Buildpack, d.Reason)
+	}
+	return fmt.Sprintf("'%s': %s", d.Path, d.Reason)
+}
+
+// SalvageLayersMetadata decodes the contents of the io.buildpacks.lifecycle.metadata label piece by piece through
+// LayersMetadataCompat, keeping every top-level field, buildpack and layer that decodes and reporting those that were dropped.
+func SalvageLayersMetadata(label string) (LayersMetadata, []DroppedMetadata) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(label), &fields); err != nil {
+		return LayersMetadata{}, []DroppedMetadata{{Path: LayerMetadataLabel, Reason: err.Error()}}
+	}
+
+	var (
+		compat  LayersMetadataCompat
+		dropped []DroppedMetadata
+	)
+	for key, dest := range map[string]interface{}{
+		"app":           &compat.App,
+		"sbom":          &compat.BOM,
+		"config":        &compat.Config,
+		"launcher":      &compat.Launcher,
+		"process-types": &compat.ProcessTypes,
+		"runImage":      &compat.RunImage,
+		"stack":         &compat.Stack,
+	} {
+		raw, ok := fields[key]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, dest); err != nil {
+			dropped = append(dropped, DroppedMetadata{Path: key, Reason: err.Error()})
+			zero(dest) // drop fields decoded before the error
+		}
+	}
+	if raw, ok := fields["buildpacks"]; ok {
+		var droppedBuildpacks []DroppedMetadata
+		compat.Buildpacks, droppedBuildpacks = salvageBuildpacks(raw)
+		dropped = append(dropped, droppedBuildpacks...)
+	}
+
+	app, err := compatApp(compat.App)
+	if err != nil {
+		dropped = append(dropped, DroppedMetadata{Path: "app", Reason: err.Error()})
+	}
+	sort.Slice(dropped, func(i, j int) bool { // map iteration order is random
+		return dropped[i].Path < dropped[j].Path
+	})
+	return LayersMetadata{
+		App:          app,
+		BOM:          compat.BOM,
+		Buildpacks:   compat.Buildpacks,
+		Config:       compat.Config,
+		Launcher:     compat.Launcher,
+		ProcessTypes: compat.ProcessTypes,
+		RunImage:     compat.RunImage,
+		Stack:        compat.Stack,
+	}, dropped
+}
+
+func salvageBuildpacks(raw json.RawMessage) ([]buildpack.LayersMetadata, []DroppedMetadata) {
+	var rawBuildpacks []json.RawMessage
+	if err := json.Unmarshal(raw, &rawBuildpacks); err != nil {
+		return nil, []DroppedMetadata{{Path: "buildpacks", Reason: err.Error()}}
+	}
+
+	var (
+		buildpacks []buildpack.LayersMetadata
+		dropped    []DroppedMetadata
+	)
+	for i, rawBuildpack := range rawBuildpacks {
+		path := fmt.Sprintf("buildpacks[%d]", i)
+		var bp struct {
+			ID      string                     `json:"key"`
+			Version string                     `json:"version"`
+			Layers  map[string]json.RawMessage `json:"layers"`
+			Store   json.RawMessage            `json:"store"`
+		}
+		if err := json.Unmarshal(rawBuildpack, &bp); err != nil {
+			dropped = append(dropped, DroppedMetadata{Path: path, Reason: err.Error()})
+			continue
+		}
+
+		bpMD := buildpack.LayersMetadata{ID: bp.ID, Version: bp.Version}
+		for name, rawLayer := range bp.Layers {
+			var layerMD buildpack.LayerMetadata
+			if err := json.Unmarshal(rawLayer, &layerMD); err != nil {
+				dropped = append(dropped, DroppedMetadata{Path: path + ".layers." + name, Buildpack: bp.ID, Reason: err.Error()})
+				continue
+			}
+			if bpMD.Layers == nil {
+				bpMD.Layers = map[string]buildpack.LayerMetadata{}
+			}
+			bpMD.Layers[name] = layerMD
+		}
+		if len(bp.Store) > 0 {
+			if err := json.Unmarshal(bp.Store, &bpMD.Store); err != nil {
+				dropped = append(dropped, DroppedMetadata{Path: path + ".store", Buildpack: bp.ID, Reason: err.Error()})
+				bpMD.Store = nil
+			}
+		}
+		buildpacks = append(buildpacks, bpMD)
+	}
+	return buildpacks, dropped
+}
+
+// compatApp converts the app layers of LayersMetadataCompat, which older lifecycles wrote as a single layer.
+func compatApp(app interface{}) ([]LayerMetadata, error) {
+	if app == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(app)
+	if err != nil {
+		return nil, err
+	}
+	var layers []LayerMetadata
+	if err := json.Unmarshal(raw, &layers); err == nil {
+		return layers, nil
+	}
+	var layer LayerMetadata
+	if err := json.Unmarshal(raw, &layer); err != nil {
+		return nil, err
+	}
+	return []LayerMetadata{layer}, nil
+}
+
+func zero(ptr interface{}) {
+	v := reflect.ValueOf(ptr).Elem()
+	v.Set(reflect.Zero(v.Type()))
+}
@@ -0,0 +1,77 @@
+package platform_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+
+	"github.com/buildpacks/lifecycle/buildpack"
+	"github.com/buildpacks/lifecycle/platform"
+	h "github.com/buildpacks/lifecycle/testhelpers"
+)
+
+func TestSalvage(t *testing.T) {
+	spec.Run(t, "Salvage", testSalvage, spec.Parallel(), spec.Report(report.Terminal{}))
+}
+
+func testSalvage(t *testing.T, when spec.G, it spec.S) {
+	when("#SalvageLayersMetadata", func() {
+		it("keeps the buildpacks and layers that decode", func() {
+			md, dropped := platform.SalvageLayersMetadata(`{
+  "app": [{"sha": "app-sha"}],
+  "config": {"sha": 1},
+  "launcher": {"sha": "launcher-sha"},
+  "buildpacks": [
+    {"key": "bp.one", "version": "v1", "layers": {
+      "good": {"sha": "good-sha", "launch": true, "data": {"key": "value"}},
+      "bad": {"sha": "bad-sha", "launch": "yes"}
+    }, "store": {"metadata": "not-a-map"}},
+    {"key": ["bp.two"], "layers": {}},
+    {"key": "bp.three", "layers": {"other": {"sha": "other-sha", "cache": true}}}
+  ]
+}`)
+
+			h.AssertEq(t, md.App, []platform.LayerMetadata{{SHA: "app-sha"}})
+			h.AssertEq(t, md.Config, platform.LayerMetadata{})
+			h.AssertEq(t, md.Launcher, platform.LayerMetadata{SHA: "launcher-sha"})
+			h.AssertEq(t, md.Buildpacks, []buildpack.LayersMetadata{
+				{ID: "bp.one", Version: "v1", Layers: map[string]buildpack.LayerMetadata{
+					"good": {SHA: "good-sha", LayerMetadataFile: buildpack.LayerMetadataFile{
+						Launch: true,
+						Data:   map[string]interface{}{"key": "value"},
+					}},
+				}},
+				{ID: "bp.three", Layers: map[string]buildpack.LayerMetadata{
+					"other": {SHA: "other-sha", LayerMetadataFile: buildpack.LayerMetadataFile{Cache: true}},
+				}},
+			})
+
+			h.AssertEq(t, len(dropped), 4)
+			h.AssertEq(t, dropped[0].Path, "buildpacks[0].layers.bad")
+			h.AssertEq(t, dropped[0].Buildpack, "bp.one")
+			h.AssertStringContains(t, dropped[0].Reason, "cannot unmarshal string")
+			h.AssertEq(t, dropped[1].Path, "buildpacks[0].store")
+			h.AssertEq(t, dropped[1].Buildpack, "bp.one")
+			h.AssertEq(t, dropped[2].Path, "buildpacks[1]")
+			h.AssertEq(t, dropped[2].Buildpack, "")
+			h.AssertEq(t, dropped[3].Path, "config")
+		})
+
+		it("converts app metadata written as a single layer", func() {
+			md, dropped := platform.SalvageLayersMetadata(`{"app": {"sha": "app-sha"}, "launcher": {"sha": 1}}`)
+
+			h.AssertEq(t, md.App, []platform.LayerMetadata{{SHA: "app-sha"}})
+			h.AssertEq(t, len(dropped), 1)
+			h.AssertEq(t, dropped[0].Path, "launcher")
+		})
+
+		it("drops everything when the label is not a json object", func() {
+			md, dropped := platform.SalvageLayersMetadata(`{["bad", "metadata"]}`)
+
+			h.AssertEq(t, md, platform.LayersMetadata{})
+			h.AssertEq(t, len(dropped), 1)
+			h.AssertEq(t, dropped[0].Path, platform.LayerMetadataLabel)
+		})
+	})
+}
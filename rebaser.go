@@ -3,8 +3,10 @@ package lifecycle
 import (
 	"encoding/json"
 	"fmt"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/buildpacks/imgutil"
 	"github.com/pkg/errors"
@@ -16,46 +18,122 @@ import (
 )
 
 type Rebaser struct {
-	Logger      Logger
-	PlatformAPI *api.Version
+	Force         bool        // rebase despite OS, architecture and distro mismatches, recording them in a label on the app image
+	LayerLister   LayerLister // optional, verifies the run image top layer of app images before rebasing
+	Logger        Logger
+	Parallelism   int // maximum number of images rebased concurrently by RebaseBatch, defaults to the number of CPUs
+	PlatformAPI   *api.Version
+	VariantReader VariantReader // optional, compares the platform variant of app images and run images
+}
+
+// LayerLister lists the diff IDs of the layers of an image, from the bottom up.
+type LayerLister interface {
+	Layers(imageName string) ([]string, error)
+}
+
+// VariantReader reads the platform variant from the config of an image, it is empty if the image has none.
+type VariantReader interface {
+	Variant(imageName string) (string, error)
 }
 
 type RebaseReport struct {
 	Image platform.ImageReport `toml:"image"`
 }
 
+// maxRebaseHistory is the number of previous run images kept in the rebase history of an app image.
+const maxRebaseHistory = 10
+
+// Rebase rebases appImage onto newBaseImage, and records the run image it replaces in the rebase history of appImage.
 func (r *Rebaser) Rebase(appImage imgutil.Image, newBaseImage imgutil.Image, additionalNames []string) (RebaseReport, error) {
-	var origMetadata platform.LayersMetadataCompat
-	if err := image.DecodeLabel(appImage, platform.LayerMetadataLabel, &origMetadata); err != nil {
-		return RebaseReport{}, errors.Wrap(err, "get image metadata")
+	newBaseVariant, err := r.variant(newBaseImage)
+	if err != nil {
+		return RebaseReport{}, errors.Wrap(err, "get run image variant")
 	}
+	return r.rebase(appImage, newBaseImage, newBaseVariant, additionalNames, appendRebaseHistory)
+}
 
-	appStackID, err := appImage.Label(platform.StackIDLabel)
-	if err != nil {
-		return RebaseReport{}, errors.Wrap(err, "get app image stack")
+// appendRebaseHistory records the run image replaced by a rebase in the rebase history.
+func appendRebaseHistory(history *platform.RebaseHistory, replaced platform.RunImageMetadata) {
+	if replaced.TopLayer == "" {
+		return
 	}
+	history.RunImages = append(history.RunImages, platform.RunImageMetadata{TopLayer: replaced.TopLayer, Reference: replaced.Reference})
+	if len(history.RunImages) > maxRebaseHistory {
+		history.RunImages = history.RunImages[len(history.RunImages)-maxRebaseHistory:]
+	}
+}
 
-	newBaseStackID, err := newBaseImage.Label(platform.StackIDLabel)
+// Rollback rebases appImage back onto previousBaseImage, which must be the last run image in the rebase history of appImage,
+// and removes that run image from the history.
+func (r *Rebaser) Rollback(appImage imgutil.Image, previousBaseImage imgutil.Image, additionalNames []string) (RebaseReport, error) {
+	var history platform.RebaseHistory
+	if err := image.DecodeLabel(appImage, platform.RebaseHistoryLabel, &history); err != nil {
+		return RebaseReport{}, errors.Wrap(err, "get rebase history")
+	}
+	previous, ok := history.Previous()
+	if !ok {
+		return RebaseReport{}, errors.New("no previous run image in rebase history")
+	}
+	topLayer, err := previousBaseImage.TopLayer()
 	if err != nil {
-		return RebaseReport{}, errors.Wrap(err, "get new base image stack")
+		return RebaseReport{}, errors.Wrap(err, "get previous run image top layer SHA")
+	}
+	if topLayer != previous.TopLayer {
+		return RebaseReport{}, fmt.Errorf("previous run image top layer '%s' does not match '%s' in rebase history", topLayer, previous.TopLayer)
 	}
+	previousBaseVariant, err := r.variant(previousBaseImage)
+	if err != nil {
+		return RebaseReport{}, errors.Wrap(err, "get run image variant")
+	}
+	return r.rebase(appImage, previousBaseImage, previousBaseVariant, additionalNames, func(history *platform.RebaseHistory, _ platform.RunImageMetadata) {
+		history.RunImages = history.RunImages[:len(history.RunImages)-1]
+	})
+}
 
-	if appStackID == "" {
-		return RebaseReport{}, errors.New("stack not defined on app image")
+// rebase rebases appImage onto newBaseImage, newBaseVariant is the variant of newBaseImage read by the caller
+// so that it is read once for the images of a batch.
+func (r *Rebaser) rebase(
+	appImage imgutil.Image,
+	newBaseImage imgutil.Image,
+	newBaseVariant string,
+	additionalNames []string,
+	updateHistory func(history *platform.RebaseHistory, replaced platform.RunImageMetadata),
+) (RebaseReport, error) {
+	var origMetadata platform.LayersMetadataCompat
+	if err := image.DecodeLabel(appImage, platform.LayerMetadataLabel, &origMetadata); err != nil {
+		return RebaseReport{}, errors.Wrap(err, "get image metadata")
 	}
 
-	if newBaseStackID == "" {
-		return RebaseReport{}, errors.New("stack not defined on new base image")
+	appLayers, err := r.listLayers(appImage)
+	if err != nil {
+		return RebaseReport{}, errors.Wrap(err, "list app image layers")
+	}
+	if err := r.verifyTopLayer(appImage, appLayers, origMetadata); err != nil {
+		return RebaseReport{}, err
 	}
 
-	if appStackID != newBaseStackID {
-		return RebaseReport{}, fmt.Errorf("incompatible stack: '%s' is not compatible with '%s'", newBaseStackID, appStackID)
+	if err := validateStack(appImage, newBaseImage); err != nil {
+		return RebaseReport{}, err
 	}
 
 	if err := validateMixins(appImage, newBaseImage); err != nil {
 		return RebaseReport{}, err
 	}
 
+	mismatches, err := r.platformMismatches(appImage, newBaseImage, newBaseVariant)
+	if err != nil {
+		return RebaseReport{}, err
+	}
+	if len(mismatches) > 0 && !r.Force {
+		return RebaseReport{}, fmt.Errorf("incompatible run image: %s", strings.Join(mismatches, "; "))
+	}
+
+	var history platform.RebaseHistory
+	if err := image.DecodeLabel(appImage, platform.RebaseHistoryLabel, &history); err != nil {
+		return RebaseReport{}, errors.Wrap(err, "get rebase history")
+	}
+	updateHistory(&history, origMetadata.RunImage)
+
 	if err := appImage.Rebase(origMetadata.RunImage.TopLayer, newBaseImage); err != nil {
 		return RebaseReport{}, errors.Wrap(err, "rebase app image")
 	}
@@ -80,11 +158,23 @@ func (r *Rebaser) Rebase(appImage imgutil.Image, newBaseImage imgutil.Image, add
 		return RebaseReport{}, errors.Wrap(err, "set app image metadata label")
 	}
 
-	hasPrefix := func(l string) bool { return strings.HasPrefix(l, "io.buildpacks.stack.") }
-	if err := image.SyncLabels(newBaseImage, appImage, hasPrefix); err != nil {
+	data, err = json.Marshal(history)
+	if err != nil {
+		return RebaseReport{}, errors.Wrap(err, "marshall rebase history")
+	}
+
+	if err := appImage.SetLabel(platform.RebaseHistoryLabel, string(data)); err != nil {
+		return RebaseReport{}, errors.Wrap(err, "set app image rebase history label")
+	}
+
+	if err := image.SyncLabels(newBaseImage, appImage, isStackLabel); err != nil {
 		return RebaseReport{}, errors.Wrap(err, "set stack labels")
 	}
 
+	if err := r.setWarningLabel(appImage, mismatches); err != nil {
+		return RebaseReport{}, err
+	}
+
 	report := RebaseReport{}
 	report.Image, err = saveImage(appImage, additionalNames, r.Logger)
 	if err != nil {
@@ -98,6 +188,265 @@ func (r *Rebaser) Rebase(appImage imgutil.Image, newBaseImage imgutil.Image, add
 	return report, err
 }
 
+// BatchRebaseReport is the report of RebaseBatch, with a result for each app image in the order they were given.
+type BatchRebaseReport struct {
+	Images []BatchRebaseResult `json:"images" toml:"images"`
+}
+
+// BatchRebaseResult is the outcome of rebasing a single app image of a batch.
+type BatchRebaseResult struct {
+	Name    string `json:"name" toml:"name"`
+	Success bool   `json:"success" toml:"success"`
+	Error   string `json:"error,omitempty" toml:"error,omitempty"`
+	ImageID string `json:"imageID,omitempty" toml:"image-id,omitempty"`
+	Digest  string `json:"digest,omitempty" toml:"digest,omitempty"`
+}
+
+// Failed returns the number of app images that could not be rebased.
+func (r BatchRebaseReport) Failed() int {
+	var failed int
+	for _, result := range r.Images {
+		if !result.Success {
+			failed++
+		}
+	}
+	return failed
+}
+
+// RebaseBatch rebases each of the named app images onto newBaseImage, saving each image under its own name.
+// Images are opened with openImage and rebased concurrently, up to Parallelism at a time.
+// A failure to open or rebase an image is recorded in its result and does not stop the others.
+func (r *Rebaser) RebaseBatch(names []string, openImage func(name string) (imgutil.Image, error), newBaseImage imgutil.Image) BatchRebaseReport {
+	results := make([]BatchRebaseResult, len(names))
+	newBaseVariant, err := r.variant(newBaseImage)
+	if err != nil {
+		for i, name := range names {
+			results[i] = BatchRebaseResult{Name: name, Error: errors.Wrap(err, "get run image variant").Error()}
+			r.Logger.Errorf("Failed to rebase image '%s': %s", name, results[i].Error)
+		}
+		return BatchRebaseReport{Images: results}
+	}
+	sem := make(chan struct{}, r.parallelism())
+	var wg sync.WaitGroup
+	for i, name := range names {
+		i, name := i, name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = r.rebaseOne(name, openImage, newBaseImage, newBaseVariant)
+		}()
+	}
+	wg.Wait()
+	return BatchRebaseReport{Images: results}
+}
+
+func (r *Rebaser) rebaseOne(name string, openImage func(name string) (imgutil.Image, error), newBaseImage imgutil.Image, newBaseVariant string) BatchRebaseResult {
+	result := BatchRebaseResult{Name: name}
+	appImage, err := openImage(name)
+	if err != nil {
+		result.Error = errors.Wrap(err, "access image to rebase").Error()
+		r.Logger.Errorf("Failed to rebase image '%s': %s", name, result.Error)
+		return result
+	}
+	report, err := r.rebase(appImage, newBaseImage, newBaseVariant, nil, appendRebaseHistory)
+	if err != nil {
+		result.Error = err.Error()
+		r.Logger.Errorf("Failed to rebase image '%s': %s", name, result.Error)
+		return result
+	}
+	result.Success = true
+	result.ImageID = report.Image.ImageID
+	result.Digest = report.Image.Digest
+	return result
+}
+
+func (r *Rebaser) parallelism() int {
+	if r.Parallelism > 0 {
+		return r.Parallelism
+	}
+	return runtime.NumCPU()
+}
+
+// DryRun performs the validation of Rebase and reports the changes that rebasing appImage onto newBaseImage would make,
+// without modifying or saving the app image. Validation failures are reported rather than returned.
+func (r *Rebaser) DryRun(appImage imgutil.Image, newBaseImage imgutil.Image) (RebaseDryRunReport, error) {
+	var origMetadata platform.LayersMetadataCompat
+	if err := image.DecodeLabel(appImage, platform.LayerMetadataLabel, &origMetadata); err != nil {
+		return RebaseDryRunReport{}, errors.Wrap(err, "get image metadata")
+	}
+
+	report := RebaseDryRunReport{Image: appImage.Name()}
+	report.RunImage.OldReference = origMetadata.RunImage.Reference
+	report.RunImage.OldTopLayer = origMetadata.RunImage.TopLayer
+
+	var err error
+	report.RunImage.NewTopLayer, err = newBaseImage.TopLayer()
+	if err != nil {
+		return RebaseDryRunReport{}, errors.Wrap(err, "get rebase run image top layer SHA")
+	}
+	identifier, err := newBaseImage.Identifier()
+	if err != nil {
+		return RebaseDryRunReport{}, errors.Wrap(err, "get run image id or digest")
+	}
+	report.RunImage.NewReference = identifier.String()
+
+	if report.Labels, err = stackLabelChanges(appImage, newBaseImage); err != nil {
+		return RebaseDryRunReport{}, err
+	}
+	if report.Mixins, err = mixinChanges(appImage, newBaseImage); err != nil {
+		return RebaseDryRunReport{}, err
+	}
+
+	for _, validate := range []func(appImg, newBaseImg imgutil.Image) error{validateStack, validateMixins} {
+		if err := validate(appImage, newBaseImage); err != nil {
+			report.Errors = append(report.Errors, err.Error())
+		}
+	}
+	if appLayers, err := r.listLayers(appImage); err != nil {
+		report.Errors = append(report.Errors, errors.Wrap(err, "list app image layers").Error())
+	} else if err := r.verifyTopLayer(appImage, appLayers, origMetadata); err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	} else if newLayers, err := r.listLayers(newBaseImage); err != nil {
+		report.Errors = append(report.Errors, errors.Wrap(err, "list run image layers").Error())
+	} else if r.LayerLister != nil {
+		report.RunImage.RemovedLayers, report.RunImage.AddedLayers = swappedLayers(report.RunImage.OldTopLayer, appLayers, newLayers)
+	}
+	newBaseVariant, err := r.variant(newBaseImage)
+	if err != nil {
+		return RebaseDryRunReport{}, errors.Wrap(err, "get run image variant")
+	}
+	mismatches, err := r.platformMismatches(appImage, newBaseImage, newBaseVariant)
+	if err != nil {
+		return RebaseDryRunReport{}, err
+	}
+	if r.Force {
+		report.Warnings = mismatches
+	} else if len(mismatches) > 0 {
+		report.Errors = append(report.Errors, fmt.Sprintf("incompatible run image: %s", strings.Join(mismatches, "; ")))
+	}
+	report.Valid = len(report.Errors) == 0
+	if report.Valid {
+		r.Logger.Infof("Image '%s' can be rebased onto '%s'", appImage.Name(), newBaseImage.Name())
+	} else {
+		r.Logger.Warnf("Image '%s' cannot be rebased onto '%s'", appImage.Name(), newBaseImage.Name())
+	}
+	return report, nil
+}
+
+// RebaseDryRunReport describes the result of Rebaser.DryRun.
+type RebaseDryRunReport struct {
+	Image    string               `json:"image" toml:"image"`
+	Valid    bool                 `json:"valid" toml:"valid"`
+	Errors   []string             `json:"errors,omitempty" toml:"errors,omitempty"`
+	Warnings []string             `json:"warnings,omitempty" toml:"warnings,omitempty"`
+	RunImage RebaseRunImageChange `json:"runImage" toml:"run-image"`
+	Labels   []RebaseLabelChange  `json:"labels,omitempty" toml:"labels,omitempty"`
+	Mixins   RebaseMixinChanges   `json:"mixins" toml:"mixins"`
+}
+
+// RebaseRunImageChange describes the run image that would be swapped by a rebase.
+// The layers of the app image up to OldTopLayer would be replaced by the layers of the new run image up to NewTopLayer.
+// RemovedLayers and AddedLayers are the diff IDs of the swapped layers that the images do not share, from the bottom up;
+// they are only listed when the Rebaser has a LayerLister.
+type RebaseRunImageChange struct {
+	OldReference  string   `json:"oldReference" toml:"old-reference"`
+	NewReference  string   `json:"newReference" toml:"new-reference"`
+	OldTopLayer   string   `json:"oldTopLayer" toml:"old-top-layer"`
+	NewTopLayer   string   `json:"newTopLayer" toml:"new-top-layer"`
+	RemovedLayers []string `json:"removedLayers,omitempty" toml:"removed-layers,omitempty"`
+	AddedLayers   []string `json:"addedLayers,omitempty" toml:"added-layers,omitempty"`
+}
+
+// RebaseLabelChange is an io.buildpacks.stack.* label that differs between the app image and the new run image.
+// An empty Old or New means the label is missing on the app image or the new run image.
+type RebaseLabelChange struct {
+	Name string `json:"name" toml:"name"`
+	Old  string `json:"old,omitempty" toml:"old,omitempty"`
+	New  string `json:"new,omitempty" toml:"new,omitempty"`
+}
+
+// RebaseMixinChanges lists the mixins the new run image adds and removes compared to the app image, without stage prefixes.
+type RebaseMixinChanges struct {
+	Added   []string `json:"added,omitempty" toml:"added,omitempty"`
+	Removed []string `json:"removed,omitempty" toml:"removed,omitempty"`
+}
+
+func isStackLabel(l string) bool {
+	return strings.HasPrefix(l, "io.buildpacks.stack.")
+}
+
+func stackLabelChanges(appImg, newBaseImg imgutil.Image) ([]RebaseLabelChange, error) {
+	appLabels, err := appImg.Labels()
+	if err != nil {
+		return nil, errors.Wrap(err, "get app image labels")
+	}
+	newBaseLabels, err := newBaseImg.Labels()
+	if err != nil {
+		return nil, errors.Wrap(err, "get run image labels")
+	}
+
+	names := map[string]struct{}{}
+	for _, labels := range []map[string]string{appLabels, newBaseLabels} {
+		for name := range labels {
+			if isStackLabel(name) {
+				names[name] = struct{}{}
+			}
+		}
+	}
+	var changes []RebaseLabelChange
+	for name := range names {
+		if appLabels[name] != newBaseLabels[name] {
+			changes = append(changes, RebaseLabelChange{Name: name, Old: appLabels[name], New: newBaseLabels[name]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	return changes, nil
+}
+
+func mixinChanges(appImg, newBaseImg imgutil.Image) (RebaseMixinChanges, error) {
+	var appImageMixins []string
+	var newBaseImageMixins []string
+
+	if err := image.DecodeLabel(appImg, platform.MixinsLabel, &appImageMixins); err != nil {
+		return RebaseMixinChanges{}, errors.Wrap(err, "get app image mixins")
+	}
+	if err := image.DecodeLabel(newBaseImg, platform.MixinsLabel, &newBaseImageMixins); err != nil {
+		return RebaseMixinChanges{}, errors.Wrap(err, "get run image mixins")
+	}
+
+	added, removed, _ := str.Compare(removeStagePrefixes(newBaseImageMixins), removeStagePrefixes(appImageMixins))
+	sort.Strings(added)
+	sort.Strings(removed)
+	return RebaseMixinChanges{Added: added, Removed: removed}, nil
+}
+
+func validateStack(appImg, newBaseImg imgutil.Image) error {
+	appStackID, err := appImg.Label(platform.StackIDLabel)
+	if err != nil {
+		return errors.Wrap(err, "get app image stack")
+	}
+
+	newBaseStackID, err := newBaseImg.Label(platform.StackIDLabel)
+	if err != nil {
+		return errors.Wrap(err, "get new base image stack")
+	}
+
+	if appStackID == "" {
+		return errors.New("stack not defined on app image")
+	}
+
+	if newBaseStackID == "" {
+		return errors.New("stack not defined on new base image")
+	}
+
+	if appStackID != newBaseStackID {
+		return fmt.Errorf("incompatible stack: '%s' is not compatible with '%s'", newBaseStackID, appStackID)
+	}
+	return nil
+}
+
 func validateMixins(appImg, newBaseImg imgutil.Image) error {
 	var appImageMixins []string
 	var newBaseImageMixins []string
@@ -123,6 +472,170 @@ func validateMixins(appImg, newBaseImg imgutil.Image) error {
 	return nil
 }
 
+// listLayers lists the diff IDs of the layers of img, they are nil without a LayerLister.
+func (r *Rebaser) listLayers(img imgutil.Image) ([]string, error) {
+	if r.LayerLister == nil {
+		return nil, nil
+	}
+	return r.LayerLister.Layers(img.Name())
+}
+
+// verifyTopLayer ensures that the run image top layer recorded in the metadata of the app image is present in diffIDs,
+// the layers of the app image, and that the layers the lifecycle exported are above it,
+// so that rebasing replaces exactly the run image layers.
+func (r *Rebaser) verifyTopLayer(appImage imgutil.Image, diffIDs []string, md platform.LayersMetadataCompat) error {
+	if r.LayerLister == nil {
+		return nil
+	}
+
+	topIdx := -1
+	for idx, diffID := range diffIDs {
+		if diffID == md.RunImage.TopLayer {
+			topIdx = idx
+			break
+		}
+	}
+	if topIdx == -1 {
+		return fmt.Errorf("run image top layer '%s' is not present in app image '%s', the image may have been modified after it was built", md.RunImage.TopLayer, appImage.Name())
+	}
+	exported := exportedLayers(md)
+	for _, diffID := range diffIDs[:topIdx+1] {
+		if exported[diffID] {
+			return fmt.Errorf("layer '%s' is below the run image top layer '%s' in app image '%s', the image may have been modified after it was built", diffID, md.RunImage.TopLayer, appImage.Name())
+		}
+	}
+	return nil
+}
+
+// swappedLayers returns the layers that rebasing an app image with appLayers onto a run image with newLayers would remove and add.
+// The run image top layer of the app image, oldTopLayer, must have been verified.
+func swappedLayers(oldTopLayer string, appLayers, newLayers []string) (removed, added []string) {
+	var oldLayers []string
+	for _, diffID := range appLayers {
+		oldLayers = append(oldLayers, diffID)
+		if diffID == oldTopLayer {
+			break
+		}
+	}
+	return layersNotIn(oldLayers, newLayers), layersNotIn(newLayers, oldLayers)
+}
+
+// layersNotIn returns the diff IDs in layers that are not in other, in order.
+func layersNotIn(layers, other []string) []string {
+	skip := map[string]bool{}
+	for _, diffID := range other {
+		skip[diffID] = true
+	}
+	var result []string
+	for _, diffID := range layers {
+		if !skip[diffID] {
+			result = append(result, diffID)
+		}
+	}
+	return result
+}
+
+// exportedLayers returns the diff IDs of the layers recorded in the metadata that the lifecycle added on top of the run image.
+func exportedLayers(md platform.LayersMetadataCompat) map[string]bool {
+	layers := map[string]bool{}
+	for _, layer := range append([]platform.LayerMetadata{md.Config, md.Launcher, md.ProcessTypes}, md.RunImage.Extensions...) {
+		layers[layer.SHA] = true
+	}
+	if md.BOM != nil {
+		layers[md.BOM.SHA] = true
+	}
+	if app, ok := md.App.([]interface{}); ok {
+		for _, layer := range app {
+			if layer, ok := layer.(map[string]interface{}); ok {
+				if sha, ok := layer["sha"].(string); ok {
+					layers[sha] = true
+				}
+			}
+		}
+	}
+	for _, bp := range md.Buildpacks {
+		for _, layer := range bp.Layers {
+			layers[layer.SHA] = true
+		}
+	}
+	delete(layers, "")
+	return layers
+}
+
+// setWarningLabel records the mismatches a forced rebase ignored, and removes the record of a previous forced rebase otherwise.
+func (r *Rebaser) setWarningLabel(appImage imgutil.Image, mismatches []string) error {
+	if len(mismatches) == 0 {
+		warning, err := appImage.Label(platform.RebaseWarningLabel)
+		if err != nil {
+			return errors.Wrap(err, "get app image rebase warning label")
+		}
+		if warning == "" {
+			return nil
+		}
+		return errors.Wrap(appImage.RemoveLabel(platform.RebaseWarningLabel), "remove app image rebase warning label")
+	}
+	for _, mismatch := range mismatches {
+		r.Logger.Warnf("Forcing rebase of incompatible run image: %s", mismatch)
+	}
+	return errors.Wrap(
+		appImage.SetLabel(platform.RebaseWarningLabel, strings.Join(mismatches, "; ")),
+		"set app image rebase warning label",
+	)
+}
+
+// platformMismatches compares the OS, architecture, variant and distro of the run image the app image is based on with
+// those of the new run image, and describes each difference. The app image has the config and stack labels of its run image.
+// The variant is only compared when the Rebaser has a VariantReader, as imgutil does not expose it;
+// the variant of the new run image, newBaseVariant, is read by the caller.
+func (r *Rebaser) platformMismatches(appImg, newBaseImg imgutil.Image, newBaseVariant string) ([]string, error) {
+	type property struct {
+		name string
+		get  func(imgutil.Image) (string, error)
+	}
+	label := func(name string) func(imgutil.Image) (string, error) {
+		return func(img imgutil.Image) (string, error) { return img.Label(name) }
+	}
+	properties := []property{
+		{"os", imgutil.Image.OS},
+		{"architecture", imgutil.Image.Architecture},
+	}
+	if r.VariantReader != nil {
+		properties = append(properties, property{"variant", func(img imgutil.Image) (string, error) {
+			if img == newBaseImg {
+				return newBaseVariant, nil
+			}
+			return r.variant(img)
+		}})
+	}
+	properties = append(properties,
+		property{"distro name", label(platform.DistroNameLabel)},
+		property{"distro version", label(platform.DistroVersionLabel)},
+	)
+	var mismatches []string
+	for _, p := range properties {
+		appValue, err := p.get(appImg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get app image %s", p.name)
+		}
+		newBaseValue, err := p.get(newBaseImg)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get run image %s", p.name)
+		}
+		if appValue != "" && newBaseValue != "" && appValue != newBaseValue {
+			mismatches = append(mismatches, fmt.Sprintf("%s '%s' does not match '%s'", p.name, newBaseValue, appValue))
+		}
+	}
+	return mismatches, nil
+}
+
+// variant returns the platform variant of img, it is empty without a VariantReader.
+func (r *Rebaser) variant(img imgutil.Image) (string, error) {
+	if r.VariantReader == nil {
+		return "", nil
+	}
+	return r.VariantReader.Variant(img.Name())
+}
+
 func (r *Rebaser) supportsManifestSize() bool {
 	return r.PlatformAPI.AtLeast("0.6")
 }
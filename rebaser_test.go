@@ -1,12 +1,16 @@
 package lifecycle_test
 
 import (
+	"fmt"
 	"math/rand"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/apex/log"
 	"github.com/apex/log/handlers/discard"
+	"github.com/buildpacks/imgutil"
 	"github.com/buildpacks/imgutil/fakes"
 	"github.com/buildpacks/imgutil/local"
 	"github.com/buildpacks/imgutil/remote"
@@ -117,6 +121,21 @@ func testRebaser(t *testing.T, when spec.G, it spec.S) {
 				h.AssertEq(t, md.App, []interface{}{map[string]interface{}{"sha": "123456"}})
 			})
 
+			it("preserves the run image extensions in the metadata", func() {
+				h.AssertNil(t, fakeAppImage.SetLabel(
+					platform.LayerMetadataLabel,
+					`{"runImage": {"topLayer": "some-top-layer-sha", "reference": "some-run-id", "extensions": [{"sha": "extension-sha"}]}}`,
+				))
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertNil(t, err)
+				h.AssertNil(t, image.DecodeLabel(fakeAppImage, platform.LayerMetadataLabel, &md))
+
+				h.AssertEq(t, md.RunImage.Extensions, []platform.LayerMetadata{{SHA: "extension-sha"}})
+				var history platform.RebaseHistory
+				h.AssertNil(t, image.DecodeLabel(fakeAppImage, platform.RebaseHistoryLabel, &history))
+				h.AssertEq(t, history.RunImages, []platform.RunImageMetadata{{TopLayer: "some-top-layer-sha", Reference: "some-run-id"}})
+			})
+
 			when("image has io.buildpacks.stack.* labels", func() {
 				var tests = []struct {
 					label         string
@@ -328,6 +347,160 @@ func testRebaser(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 
+		when("app image and run image are based on different platforms", func() {
+			it("returns an error when the OS or architecture differ", func() {
+				h.AssertNil(t, fakeNewBaseImage.SetOS("windows"))
+				h.AssertNil(t, fakeNewBaseImage.SetArchitecture("arm64"))
+
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertError(t, err, "incompatible run image: os 'windows' does not match 'linux'; architecture 'arm64' does not match 'amd64'")
+				h.AssertEq(t, fakeAppImage.IsSaved(), false)
+			})
+
+			it("returns an error when the distro version differs", func() {
+				h.AssertNil(t, fakeAppImage.SetLabel(platform.DistroVersionLabel, "18.04"))
+				h.AssertNil(t, fakeNewBaseImage.SetLabel(platform.DistroVersionLabel, "22.04"))
+
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertError(t, err, "incompatible run image: distro version '22.04' does not match '18.04'")
+			})
+
+			it("returns an error when the variant differs", func() {
+				rebaser.VariantReader = fakeVariantReader{
+					"some-repo/app-image":      "v8",
+					"some-repo/new-base-image": "v7",
+				}
+
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertError(t, err, "incompatible run image: variant 'v7' does not match 'v8'")
+			})
+
+			it("ignores the OS version", func() {
+				h.AssertNil(t, fakeAppImage.SetOSVersion("10.0.17763.1"))
+				h.AssertNil(t, fakeNewBaseImage.SetOSVersion("10.0.17763.2"))
+
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertNil(t, err)
+			})
+
+			it("ignores distro labels missing on either image", func() {
+				h.AssertNil(t, fakeNewBaseImage.SetLabel(platform.DistroNameLabel, "ubuntu"))
+
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertNil(t, err)
+			})
+
+			when("forced", func() {
+				it.Before(func() {
+					rebaser.Force = true
+					h.AssertNil(t, fakeNewBaseImage.SetArchitecture("arm64"))
+				})
+
+				it("rebases and records a warning label", func() {
+					_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+					h.AssertNil(t, err)
+
+					h.AssertEq(t, fakeAppImage.Base(), "some-repo/new-base-image")
+					warning, err := fakeAppImage.Label(platform.RebaseWarningLabel)
+					h.AssertNil(t, err)
+					h.AssertEq(t, warning, "architecture 'arm64' does not match 'amd64'")
+				})
+			})
+
+			it("removes the warning label of a previous forced rebase", func() {
+				h.AssertNil(t, fakeAppImage.SetLabel(platform.RebaseWarningLabel, "architecture 'arm64' does not match 'amd64'"))
+
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertNil(t, err)
+
+				warning, err := fakeAppImage.Label(platform.RebaseWarningLabel)
+				h.AssertNil(t, err)
+				h.AssertEq(t, warning, "")
+			})
+		})
+
+		when("verifying the run image top layer", func() {
+			var layers fakeLayerLister
+
+			it.Before(func() {
+				h.AssertNil(t, fakeAppImage.SetLabel(
+					platform.LayerMetadataLabel,
+					`{"app": [{"sha": "app-layer-sha"}], "config": {"sha": "config-layer-sha"}, "runImage": {"topLayer": "some-top-layer-sha", "reference": "some-run-id"}}`,
+				))
+				layers = fakeLayerLister{}
+				rebaser.LayerLister = layers
+			})
+
+			it("allows rebase when the exported layers are above the top layer", func() {
+				layers["some-repo/app-image"] = []string{"run-layer-sha", "some-top-layer-sha", "app-layer-sha", "config-layer-sha"}
+
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertNil(t, err)
+			})
+
+			it("returns an error when the top layer is not present", func() {
+				layers["some-repo/app-image"] = []string{"run-layer-sha", "app-layer-sha", "config-layer-sha"}
+
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertError(t, err, "run image top layer 'some-top-layer-sha' is not present in app image 'some-repo/app-image', the image may have been modified after it was built")
+				h.AssertEq(t, fakeAppImage.IsSaved(), false)
+			})
+
+			it("returns an error when an exported layer is below the top layer", func() {
+				layers["some-repo/app-image"] = []string{"run-layer-sha", "app-layer-sha", "some-top-layer-sha", "config-layer-sha"}
+
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertError(t, err, "layer 'app-layer-sha' is below the run image top layer 'some-top-layer-sha' in app image 'some-repo/app-image', the image may have been modified after it was built")
+			})
+
+			it("reports the error in a dry run", func() {
+				layers["some-repo/app-image"] = []string{"run-layer-sha"}
+
+				report, err := rebaser.DryRun(fakeAppImage, fakeNewBaseImage)
+				h.AssertNil(t, err)
+				h.AssertEq(t, report.Valid, false)
+				h.AssertEq(t, report.Errors, []string{
+					"run image top layer 'some-top-layer-sha' is not present in app image 'some-repo/app-image', the image may have been modified after it was built",
+				})
+			})
+		})
+
+		when("rebase history", func() {
+			it("records the replaced run image", func() {
+				h.AssertNil(t, fakeAppImage.SetLabel(
+					platform.LayerMetadataLabel,
+					`{"runImage": {"topLayer": "some-top-layer-sha", "reference": "some-run-id"}}`,
+				))
+
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertNil(t, err)
+
+				var history platform.RebaseHistory
+				h.AssertNil(t, image.DecodeLabel(fakeAppImage, platform.RebaseHistoryLabel, &history))
+				h.AssertEq(t, history.RunImages, []platform.RunImageMetadata{
+					{TopLayer: "some-top-layer-sha", Reference: "some-run-id"},
+				})
+			})
+
+			it("keeps a bounded number of run images", func() {
+				var previous []string
+				for i := 0; i < 12; i++ {
+					previous = append(previous, fmt.Sprintf(`{"topLayer": "top-layer-%d", "reference": "run-id-%d"}`, i, i))
+				}
+				h.AssertNil(t, fakeAppImage.SetLabel(platform.RebaseHistoryLabel, `{"runImages": [`+strings.Join(previous[:10], ",")+`]}`))
+				h.AssertNil(t, fakeAppImage.SetLabel(platform.LayerMetadataLabel, `{"runImage": `+previous[10]+`}`))
+
+				_, err := rebaser.Rebase(fakeAppImage, fakeNewBaseImage, additionalNames)
+				h.AssertNil(t, err)
+
+				var history platform.RebaseHistory
+				h.AssertNil(t, image.DecodeLabel(fakeAppImage, platform.RebaseHistoryLabel, &history))
+				h.AssertEq(t, len(history.RunImages), 10)
+				h.AssertEq(t, history.RunImages[0].Reference, "run-id-1")
+				h.AssertEq(t, history.RunImages[9].Reference, "run-id-10")
+			})
+		})
+
 		when("app image and run image are based on different stacks", func() {
 			it("returns an error and prevents the rebase from taking place when the stacks are different", func() {
 				h.AssertNil(t, fakeAppImage.SetLabel(platform.StackIDLabel, "io.buildpacks.stacks.bionic"))
@@ -354,4 +527,331 @@ func testRebaser(t *testing.T, when spec.G, it spec.S) {
 			})
 		})
 	})
+
+	when("#DryRun", func() {
+		it.Before(func() {
+			h.AssertNil(t, fakeAppImage.SetLabel(
+				platform.LayerMetadataLabel,
+				`{"runImage": {"topLayer": "some-top-layer-sha", "reference": "some-run-id"}}`,
+			))
+		})
+
+		it("does not modify or save the app image", func() {
+			_, err := rebaser.DryRun(fakeAppImage, fakeNewBaseImage)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, fakeAppImage.IsSaved(), false)
+			h.AssertEq(t, fakeAppImage.Base(), "")
+			h.AssertNil(t, image.DecodeLabel(fakeAppImage, platform.LayerMetadataLabel, &md))
+			h.AssertEq(t, md.RunImage.TopLayer, "some-top-layer-sha")
+		})
+
+		it("reports the old and new run image", func() {
+			report, err := rebaser.DryRun(fakeAppImage, fakeNewBaseImage)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, report.Image, "some-repo/app-image")
+			h.AssertEq(t, report.Valid, true)
+			h.AssertEq(t, report.RunImage, lifecycle.RebaseRunImageChange{
+				OldReference: "some-run-id",
+				NewReference: "new-run-id",
+				OldTopLayer:  "some-top-layer-sha",
+				NewTopLayer:  "new-top-layer-sha",
+			})
+		})
+
+		it("reports the layers being swapped", func() {
+			rebaser.LayerLister = fakeLayerLister{
+				"some-repo/app-image":      {"shared-layer-sha", "old-layer-sha", "some-top-layer-sha", "app-layer-sha"},
+				"some-repo/new-base-image": {"shared-layer-sha", "new-layer-sha", "new-top-layer-sha"},
+			}
+
+			report, err := rebaser.DryRun(fakeAppImage, fakeNewBaseImage)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, report.Valid, true)
+			h.AssertEq(t, report.RunImage.RemovedLayers, []string{"old-layer-sha", "some-top-layer-sha"})
+			h.AssertEq(t, report.RunImage.AddedLayers, []string{"new-layer-sha", "new-top-layer-sha"})
+		})
+
+		it("lists the layers of each image once", func() {
+			lister := &countingLayerLister{fakeLayerLister: fakeLayerLister{
+				"some-repo/app-image":      {"some-top-layer-sha", "app-layer-sha"},
+				"some-repo/new-base-image": {"new-top-layer-sha"},
+			}}
+			rebaser.LayerLister = lister
+
+			_, err := rebaser.DryRun(fakeAppImage, fakeNewBaseImage)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, lister.calls, map[string]int{"some-repo/app-image": 1, "some-repo/new-base-image": 1})
+		})
+
+		it("reports the error when the new run image layers cannot be listed", func() {
+			rebaser.LayerLister = fakeLayerLister{
+				"some-repo/app-image": {"some-top-layer-sha"},
+			}
+
+			report, err := rebaser.DryRun(fakeAppImage, fakeNewBaseImage)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, report.Valid, false)
+			h.AssertEq(t, report.Errors, []string{"list run image layers: image 'some-repo/new-base-image' not found"})
+		})
+
+		it("reports changed io.buildpacks.stack.* labels", func() {
+			h.AssertNil(t, fakeAppImage.SetLabel("io.buildpacks.stack.distro.name", "ubuntu"))
+			h.AssertNil(t, fakeNewBaseImage.SetLabel("io.buildpacks.stack.distro.name", "ubuntu"))
+			h.AssertNil(t, fakeAppImage.SetLabel("io.buildpacks.stack.changed", "v1"))
+			h.AssertNil(t, fakeNewBaseImage.SetLabel("io.buildpacks.stack.changed", "v2"))
+			h.AssertNil(t, fakeNewBaseImage.SetLabel("io.buildpacks.stack.added", "new"))
+			h.AssertNil(t, fakeAppImage.SetLabel("io.buildpacks.stack.removed", "old"))
+			h.AssertNil(t, fakeAppImage.SetLabel("io.custom.changed", "v1"))
+
+			report, err := rebaser.DryRun(fakeAppImage, fakeNewBaseImage)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, report.Labels, []lifecycle.RebaseLabelChange{
+				{Name: "io.buildpacks.stack.added", New: "new"},
+				{Name: "io.buildpacks.stack.changed", Old: "v1", New: "v2"},
+				{Name: "io.buildpacks.stack.removed", Old: "old"},
+			})
+		})
+
+		it("reports added and removed mixins", func() {
+			h.AssertNil(t, fakeAppImage.SetLabel(platform.MixinsLabel, "[\"mixin-1\", \"run:mixin-2\"]"))
+			h.AssertNil(t, fakeNewBaseImage.SetLabel(platform.MixinsLabel, "[\"mixin-1\", \"run:mixin-3\"]"))
+
+			report, err := rebaser.DryRun(fakeAppImage, fakeNewBaseImage)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, report.Mixins.Added, []string{"mixin-3"})
+			h.AssertEq(t, report.Mixins.Removed, []string{"mixin-2"})
+			h.AssertEq(t, report.Valid, false)
+		})
+
+		when("the rebase would not be valid", func() {
+			it("reports all validation errors", func() {
+				h.AssertNil(t, fakeNewBaseImage.SetLabel(platform.StackIDLabel, "io.buildpacks.stacks.cflinuxfs3"))
+				h.AssertNil(t, fakeAppImage.SetLabel(platform.MixinsLabel, "[\"mixin-1\"]"))
+
+				report, err := rebaser.DryRun(fakeAppImage, fakeNewBaseImage)
+				h.AssertNil(t, err)
+
+				h.AssertEq(t, report.Valid, false)
+				h.AssertEq(t, report.Errors, []string{
+					"incompatible stack: 'io.buildpacks.stacks.cflinuxfs3' is not compatible with 'io.buildpacks.stacks.bionic'",
+					"missing required mixin(s): mixin-1",
+				})
+				h.AssertEq(t, fakeAppImage.IsSaved(), false)
+			})
+
+			it("reports platform mismatches as errors", func() {
+				h.AssertNil(t, fakeNewBaseImage.SetOS("windows"))
+
+				report, err := rebaser.DryRun(fakeAppImage, fakeNewBaseImage)
+				h.AssertNil(t, err)
+
+				h.AssertEq(t, report.Valid, false)
+				h.AssertEq(t, report.Errors, []string{"incompatible run image: os 'windows' does not match 'linux'"})
+			})
+
+			it("reports platform mismatches as warnings when forced", func() {
+				rebaser.Force = true
+				h.AssertNil(t, fakeNewBaseImage.SetOS("windows"))
+
+				report, err := rebaser.DryRun(fakeAppImage, fakeNewBaseImage)
+				h.AssertNil(t, err)
+
+				h.AssertEq(t, report.Valid, true)
+				h.AssertEq(t, report.Warnings, []string{"os 'windows' does not match 'linux'"})
+			})
+		})
+	})
+
+	when("#RebaseBatch", func() {
+		var (
+			fakeOtherAppImage *fakes.Image
+			images            map[string]*fakes.Image
+			openImage         func(name string) (imgutil.Image, error)
+		)
+
+		it.Before(func() {
+			fakeOtherAppImage = fakes.NewImage("some-repo/other-app-image", "some-top-layer-sha", nil)
+			digestRef, err := name.NewDigest("some-repo/other-app-image@sha256:c27a27006b74a056bed5d9edcebc394783880abe8691a8c87c78b7cffa6fa5ad")
+			h.AssertNil(t, err)
+			fakeOtherAppImage.SetIdentifier(remote.DigestIdentifier{Digest: digestRef})
+			h.AssertNil(t, fakeOtherAppImage.SetLabel(platform.StackIDLabel, "io.buildpacks.stacks.bionic"))
+
+			images = map[string]*fakes.Image{
+				"some-repo/app-image":       fakeAppImage,
+				"some-repo/other-app-image": fakeOtherAppImage,
+			}
+			openImage = func(name string) (imgutil.Image, error) {
+				img, ok := images[name]
+				if !ok {
+					return nil, fmt.Errorf("image '%s' not found", name)
+				}
+				return img, nil
+			}
+			rebaser.Parallelism = 2
+		})
+
+		it.After(func() {
+			h.AssertNil(t, fakeOtherAppImage.Cleanup())
+		})
+
+		it("rebases and saves each image", func() {
+			report := rebaser.RebaseBatch([]string{"some-repo/app-image", "some-repo/other-app-image"}, openImage, fakeNewBaseImage)
+
+			h.AssertEq(t, report.Failed(), 0)
+			h.AssertEq(t, report.Images, []lifecycle.BatchRebaseResult{
+				{Name: "some-repo/app-image", Success: true, ImageID: "some-image-id"},
+				{Name: "some-repo/other-app-image", Success: true, Digest: "sha256:c27a27006b74a056bed5d9edcebc394783880abe8691a8c87c78b7cffa6fa5ad"},
+			})
+			for _, img := range images {
+				h.AssertEq(t, img.Base(), "some-repo/new-base-image")
+				h.AssertEq(t, img.IsSaved(), true)
+			}
+		})
+
+		it("reads the variant of the new run image once", func() {
+			reader := &countingVariantReader{fakeVariantReader: fakeVariantReader{
+				"some-repo/app-image":       "v8",
+				"some-repo/other-app-image": "v8",
+				"some-repo/new-base-image":  "v8",
+			}}
+			rebaser.VariantReader = reader
+
+			report := rebaser.RebaseBatch([]string{"some-repo/app-image", "some-repo/other-app-image"}, openImage, fakeNewBaseImage)
+
+			h.AssertEq(t, report.Failed(), 0)
+			h.AssertEq(t, reader.calls, map[string]int{"some-repo/app-image": 1, "some-repo/other-app-image": 1, "some-repo/new-base-image": 1})
+		})
+
+		it("continues past individual failures", func() {
+			h.AssertNil(t, fakeAppImage.SetLabel(platform.StackIDLabel, "io.buildpacks.stacks.cflinuxfs3"))
+
+			report := rebaser.RebaseBatch([]string{"some-repo/app-image", "some-repo/missing-image", "some-repo/other-app-image"}, openImage, fakeNewBaseImage)
+
+			h.AssertEq(t, report.Failed(), 2)
+			h.AssertEq(t, report.Images[0].Success, false)
+			h.AssertEq(t, report.Images[0].Error, "incompatible stack: 'io.buildpacks.stacks.bionic' is not compatible with 'io.buildpacks.stacks.cflinuxfs3'")
+			h.AssertEq(t, report.Images[1].Success, false)
+			h.AssertEq(t, report.Images[1].Error, "access image to rebase: image 'some-repo/missing-image' not found")
+			h.AssertEq(t, report.Images[2].Success, true)
+			h.AssertEq(t, fakeAppImage.IsSaved(), false)
+			h.AssertEq(t, fakeOtherAppImage.IsSaved(), true)
+		})
+	})
+
+	when("#Rollback", func() {
+		var fakePreviousBaseImage *fakes.Image
+
+		it.Before(func() {
+			fakePreviousBaseImage = fakes.NewImage(
+				"some-repo/previous-base-image",
+				"previous-top-layer-sha",
+				local.IDIdentifier{
+					ImageID: "previous-run-id",
+				},
+			)
+			h.AssertNil(t, fakePreviousBaseImage.SetLabel(platform.StackIDLabel, "io.buildpacks.stacks.bionic"))
+
+			h.AssertNil(t, fakeAppImage.SetLabel(
+				platform.LayerMetadataLabel,
+				`{"runImage": {"topLayer": "some-top-layer-sha", "reference": "some-run-id"}}`,
+			))
+			h.AssertNil(t, fakeAppImage.SetLabel(
+				platform.RebaseHistoryLabel,
+				`{"runImages": [{"topLayer": "older-top-layer-sha", "reference": "older-run-id"}, {"topLayer": "previous-top-layer-sha", "reference": "previous-run-id"}]}`,
+			))
+		})
+
+		it.After(func() {
+			h.AssertNil(t, fakePreviousBaseImage.Cleanup())
+		})
+
+		it("rebases onto the previous run image and removes it from the history", func() {
+			_, err := rebaser.Rollback(fakeAppImage, fakePreviousBaseImage, additionalNames)
+			h.AssertNil(t, err)
+
+			h.AssertEq(t, fakeAppImage.Base(), "some-repo/previous-base-image")
+			h.AssertNil(t, image.DecodeLabel(fakeAppImage, platform.LayerMetadataLabel, &md))
+			h.AssertEq(t, md.RunImage, platform.RunImageMetadata{TopLayer: "previous-top-layer-sha", Reference: "previous-run-id"})
+
+			var history platform.RebaseHistory
+			h.AssertNil(t, image.DecodeLabel(fakeAppImage, platform.RebaseHistoryLabel, &history))
+			h.AssertEq(t, history.RunImages, []platform.RunImageMetadata{
+				{TopLayer: "older-top-layer-sha", Reference: "older-run-id"},
+			})
+		})
+
+		it("validates the stack and mixins", func() {
+			h.AssertNil(t, fakeAppImage.SetLabel(platform.MixinsLabel, "[\"mixin-1\"]"))
+
+			_, err := rebaser.Rollback(fakeAppImage, fakePreviousBaseImage, additionalNames)
+			h.AssertError(t, err, "missing required mixin(s): mixin-1")
+			h.AssertEq(t, fakeAppImage.IsSaved(), false)
+		})
+
+		it("fails when the previous run image does not match the history", func() {
+			_, err := rebaser.Rollback(fakeAppImage, fakeNewBaseImage, additionalNames)
+			h.AssertError(t, err, "previous run image top layer 'new-top-layer-sha' does not match 'previous-top-layer-sha' in rebase history")
+		})
+
+		it("fails when there is no history", func() {
+			h.AssertNil(t, fakeAppImage.RemoveLabel(platform.RebaseHistoryLabel))
+
+			_, err := rebaser.Rollback(fakeAppImage, fakePreviousBaseImage, additionalNames)
+			h.AssertError(t, err, "no previous run image in rebase history")
+		})
+	})
+}
+
+type fakeLayerLister map[string][]string
+
+func (f fakeLayerLister) Layers(imageName string) ([]string, error) {
+	layers, ok := f[imageName]
+	if !ok {
+		return nil, fmt.Errorf("image '%s' not found", imageName)
+	}
+	return layers, nil
+}
+
+type fakeVariantReader map[string]string
+
+func (f fakeVariantReader) Variant(imageName string) (string, error) {
+	return f[imageName], nil
+}
+
+// countingLayerLister counts the calls for each image.
+type countingLayerLister struct {
+	fakeLayerLister
+	calls map[string]int
+}
+
+func (c *countingLayerLister) Layers(imageName string) ([]string, error) {
+	if c.calls == nil {
+		c.calls = map[string]int{}
+	}
+	c.calls[imageName]++
+	return c.fakeLayerLister.Layers(imageName)
+}
+
+// countingVariantReader counts the calls for each image, it is safe for concurrent use.
+type countingVariantReader struct {
+	fakeVariantReader
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+func (c *countingVariantReader) Variant(imageName string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.calls == nil {
+		c.calls = map[string]int{}
+	}
+	c.calls[imageName]++
+	return c.fakeVariantReader.Variant(imageName)
 }
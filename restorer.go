@@ -1,7 +1,13 @@
 package lifecycle
 
 import (
+	"encoding/json"
+	"io"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
@@ -18,19 +24,46 @@ type Restorer struct {
 	Logger    Logger
 
 	Buildpacks            []buildpack.GroupBuildpack
+	InvalidateLayers      []platform.CacheLayerSelector
 	LayerMetadataRestorer layer.MetadataRestorer  // Platform API >= 0.7
 	LayersMetadata        platform.LayersMetadata // Platform API >= 0.7
 	Platform              Platform
 	SBOMRestorer          layer.SBOMRestorer
+	SeedCache             Cache // optional, read-only, Platform API >= 0.7
+
+	Parallelism      int           // maximum number of layers, including the SBOM layer, restored concurrently, defaults to the number of CPUs
+	ProgressInterval time.Duration // how often to report progress while restoring a layer, never if <= 0
+	ProgressWriter   io.Writer     // optional, receives RestoreEvents as JSON lines
+}
+
+// RestoreEvent reports the progress of restoring cached layer data.
+// Total is only known for layers restored from a volume cache: image caches store compressed layers,
+// so the blob sizes in their manifest cannot be compared with the uncompressed bytes that are restored.
+type RestoreEvent struct {
+	Type      string `json:"type"` // one of "progress", "restored" or "summary"
+	Buildpack string `json:"buildpack"`
+	Layer     string `json:"layer,omitempty"`
+	Bytes     int64  `json:"bytes"`
+	Total     int64  `json:"total,omitempty"` // only set when the layer size is known up front
 }
 
 // Restore restores metadata for launch and cache layers into the layers directory and attempts to restore layer data for cache=true layers, removing the layer when unsuccessful.
 // If a usable cache is not provided, Restore will not restore any cache=true layer metadata.
+// Cached layers matching InvalidateLayers are treated as if they were not in the cache.
+// Cached layers missing from the cache are restored from SeedCache, if provided, when their buildpack ID and layer name match.
+// Below Platform API 0.7 the analyzer writes the layer metadata without consulting the seed cache, so SeedCache is ignored.
 func (r *Restorer) Restore(cache Cache) error {
 	cacheMeta, err := retrieveCacheMetadata(cache, r.Logger)
 	if err != nil {
 		return err
 	}
+	for _, id := range cacheMeta.RemoveLayers(r.InvalidateLayers...) {
+		r.Logger.Infof("Invalidating cached layer %q", id)
+	}
+	seededLayers, err := r.addSeedLayers(&cacheMeta)
+	if err != nil {
+		return err
+	}
 
 	useShaFiles := !r.restoresLayerMetadata()
 	layerSHAStore := layer.NewSHAStore(useShaFiles)
@@ -41,6 +74,15 @@ func (r *Restorer) Restore(cache Cache) error {
 	}
 
 	var g errgroup.Group
+	progress := newRestoreProgress(r.Logger, r.ProgressInterval, r.ProgressWriter)
+	sem := make(chan struct{}, r.parallelism())
+	restoreLayer := func(cache Cache, bpID, layerName, sha string) {
+		g.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			return r.restoreCacheLayer(cache, sha, progress.track(bpID, layerName, layerSize(cache, sha)))
+		})
+	}
 	for _, bp := range r.Buildpacks {
 		cachedLayers := cacheMeta.MetadataForBuildpack(bp.ID).Layers
 
@@ -87,17 +129,20 @@ func (r *Restorer) Restore(cache Cache) error {
 				if err := bpLayer.Remove(); err != nil {
 					return errors.Wrapf(err, "removing layer")
 				}
+			} else if seededLayers[bp.ID+":"+bpLayer.Name()] {
+				r.Logger.Infof("Restoring data for %q from seed cache", bpLayer.Identifier())
+				restoreLayer(r.SeedCache, bp.ID, bpLayer.Name(), cachedLayer.SHA)
 			} else {
 				r.Logger.Infof("Restoring data for %q from cache", bpLayer.Identifier())
-				g.Go(func() error {
-					return r.restoreCacheLayer(cache, cachedLayer.SHA)
-				})
+				restoreLayer(cache, bp.ID, bpLayer.Name(), cachedLayer.SHA)
 			}
 		}
 	}
 
 	if r.Platform.API().AtLeast("0.8") {
 		g.Go(func() error {
+			sem <- struct{}{}
+			defer func() { <-sem }()
 			if cacheMeta.BOM.SHA != "" {
 				r.Logger.Infof("Restoring data for SBOM from cache")
 				if err := r.SBOMRestorer.RestoreFromCache(cache, cacheMeta.BOM.SHA); err != nil {
@@ -111,15 +156,45 @@ func (r *Restorer) Restore(cache Cache) error {
 	if err := g.Wait(); err != nil {
 		return errors.Wrap(err, "restoring data")
 	}
+	progress.summarize()
 
 	return nil
 }
 
+func (r *Restorer) parallelism() int {
+	if r.Parallelism > 0 {
+		return r.Parallelism
+	}
+	return runtime.NumCPU()
+}
+
+// addSeedLayers adds the seed cache layers missing from cacheMeta and returns their identifiers.
+func (r *Restorer) addSeedLayers(cacheMeta *platform.CacheMetadata) (map[string]bool, error) {
+	seeded := map[string]bool{}
+	if r.SeedCache == nil {
+		return seeded, nil
+	}
+	if !r.restoresLayerMetadata() {
+		r.Logger.Warn("Ignoring seed cache, it requires Platform API 0.7 or later")
+		return seeded, nil
+	}
+	seedMeta, err := r.SeedCache.RetrieveMetadata()
+	if err != nil {
+		return nil, errors.Wrap(err, "retrieving seed cache metadata")
+	}
+	seedMeta.RemoveLayers(r.InvalidateLayers...)
+	for _, id := range cacheMeta.AddMissingLayers(seedMeta) {
+		r.Logger.Debugf("Using seed cache entry for %q", id)
+		seeded[id] = true
+	}
+	return seeded, nil
+}
+
 func (r *Restorer) restoresLayerMetadata() bool {
 	return r.Platform.API().AtLeast("0.7")
 }
 
-func (r *Restorer) restoreCacheLayer(cache Cache, sha string) error {
+func (r *Restorer) restoreCacheLayer(cache Cache, sha string, counter *layerProgress) error {
 	// Sanity check to prevent panic.
 	if cache == nil {
 		return errors.New("restoring layer: cache not provided")
@@ -131,5 +206,117 @@ func (r *Restorer) restoreCacheLayer(cache Cache, sha string) error {
 	}
 	defer rc.Close()
 
-	return layers.Extract(rc, "")
+	counter.reader = rc
+	if err := layers.Extract(counter, ""); err != nil {
+		return err
+	}
+	counter.done()
+	return nil
+}
+
+func layerSize(cache Cache, sha string) int64 {
+	if sizer, ok := cache.(layerSizer); ok {
+		if size, err := sizer.LayerSize(sha); err == nil {
+			return size
+		}
+	}
+	return 0
+}
+
+// restoreProgress tracks the bytes restored for each layer and buildpack.
+type restoreProgress struct {
+	logger   Logger
+	interval time.Duration
+	writer   io.Writer
+
+	mutex        sync.Mutex
+	bpBytes      map[string]int64
+	buildpackIDs []string
+}
+
+func newRestoreProgress(logger Logger, interval time.Duration, writer io.Writer) *restoreProgress {
+	return &restoreProgress{
+		logger:   logger,
+		interval: interval,
+		writer:   writer,
+		bpBytes:  map[string]int64{},
+	}
+}
+
+func (p *restoreProgress) track(bpID, layerName string, total int64) *layerProgress {
+	return &layerProgress{
+		progress:   p,
+		buildpack:  bpID,
+		layer:      layerName,
+		total:      total,
+		lastReport: time.Now(),
+	}
+}
+
+func (p *restoreProgress) emit(event RestoreEvent) {
+	if p.writer == nil {
+		return
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if _, err := p.writer.Write(append(data, '\n')); err != nil {
+		p.logger.Debugf("Failed to write restore event: %s", err)
+	}
+}
+
+func (p *restoreProgress) restored(bpID string, bytes int64) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if _, ok := p.bpBytes[bpID]; !ok {
+		p.buildpackIDs = append(p.buildpackIDs, bpID)
+	}
+	p.bpBytes[bpID] += bytes
+}
+
+// summarize logs and emits the bytes restored for each buildpack.
+func (p *restoreProgress) summarize() {
+	p.mutex.Lock()
+	ids := append([]string{}, p.buildpackIDs...)
+	p.mutex.Unlock()
+	sort.Strings(ids)
+	for _, id := range ids {
+		p.logger.Infof("Restored %d bytes of cached data for %q", p.bpBytes[id], id)
+		p.emit(RestoreEvent{Type: "summary", Buildpack: id, Bytes: p.bpBytes[id]})
+	}
+}
+
+// layerProgress counts the bytes read while restoring a single layer.
+type layerProgress struct {
+	progress   *restoreProgress
+	reader     io.Reader
+	buildpack  string
+	layer      string
+	bytes      int64
+	total      int64
+	lastReport time.Time
+}
+
+func (l *layerProgress) Read(b []byte) (int, error) {
+	n, err := l.reader.Read(b)
+	l.bytes += int64(n)
+	if l.progress.interval > 0 && time.Since(l.lastReport) >= l.progress.interval {
+		l.lastReport = time.Now()
+		if l.total > 0 {
+			l.progress.logger.Infof("Restoring %s:%s: %d/%d bytes", l.buildpack, l.layer, l.bytes, l.total)
+		} else {
+			l.progress.logger.Infof("Restoring %s:%s: %d bytes", l.buildpack, l.layer, l.bytes)
+		}
+		l.progress.emit(RestoreEvent{Type: "progress", Buildpack: l.buildpack, Layer: l.layer, Bytes: l.bytes, Total: l.total})
+	}
+	return n, err
+}
+
+func (l *layerProgress) done() {
+	l.progress.logger.Debugf("Restored %d bytes for %s:%s", l.bytes, l.buildpack, l.layer)
+	l.progress.emit(RestoreEvent{Type: "restored", Buildpack: l.buildpack, Layer: l.layer, Bytes: l.bytes, Total: l.total})
+	l.progress.restored(l.buildpack, l.bytes)
 }
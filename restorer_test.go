@@ -1,11 +1,13 @@
 package lifecycle_test
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/apex/log"
@@ -225,6 +227,76 @@ func testRestorerBuilder(buildpackAPI, platformAPI string) func(t *testing.T, wh
 				})
 			})
 
+			when("there is a seed cache", func() {
+				var (
+					seedDir    string
+					tarTempDir string
+					layerSHA   string
+				)
+
+				it.Before(func() {
+					var err error
+					seedDir, err = ioutil.TempDir("", "restorer-test-seed-cache")
+					h.AssertNil(t, err)
+					tarTempDir, err = ioutil.TempDir("", "restorer-test-temp-layer")
+					h.AssertNil(t, err)
+
+					h.RecursiveCopy(t, filepath.Join("testdata", "restorer"), layersDir)
+					lf := layers.Factory{ArtifactsDir: tarTempDir}
+					layer, err := lf.DirLayer("buildpack.id:cache-only", filepath.Join(layersDir, "buildpack.id", "cache-only"))
+					h.AssertNil(t, err)
+					layerSHA = layer.Digest
+					h.AssertNil(t, os.RemoveAll(layersDir))
+					h.AssertNil(t, os.Mkdir(layersDir, 0777))
+
+					seed, err := cache.NewVolumeCache(seedDir)
+					h.AssertNil(t, err)
+					h.AssertNil(t, seed.AddLayerFile(layer.TarPath, layer.Digest))
+					h.AssertNil(t, seed.SetMetadata(platform.CacheMetadata{
+						Buildpacks: []buildpack.LayersMetadata{{
+							ID: "buildpack.id",
+							Layers: map[string]buildpack.LayerMetadata{
+								"cache-only": {SHA: layerSHA, LayerMetadataFile: buildpack.LayerMetadataFile{Cache: true}},
+							},
+						}},
+					}))
+					h.AssertNil(t, seed.Commit())
+
+					restorer.SeedCache, err = cache.NewReadOnlyVolumeCache(seedDir)
+					h.AssertNil(t, err)
+
+					var meta, sha string
+					if api.MustParse(buildpackAPI).LessThan("0.6") {
+						meta = "build = false\nlaunch = false\ncache = true\n\n"
+					}
+					if api.MustParse(platformAPI).LessThan("0.7") {
+						sha = layerSHA
+					}
+					h.AssertNil(t, writeLayer(layersDir, "buildpack.id", "cache-only", meta, sha))
+					h.AssertNil(t, restorer.Restore(testCache))
+				})
+
+				it.After(func() {
+					h.AssertNil(t, os.RemoveAll(seedDir))
+					h.AssertNil(t, os.RemoveAll(tarTempDir))
+				})
+
+				it("restores data missing from the cache from the seed cache", func() {
+					h.SkipIf(t, api.MustParse(platformAPI).LessThan("0.7"), "Platform API < 0.7 does not support seed caches")
+
+					got := h.MustReadFile(t, filepath.Join(layersDir, "buildpack.id", "cache-only", "file-from-cache-only-layer"))
+					h.AssertEq(t, string(got), "echo text from cache-only layer\n")
+					assertLogEntry(t, logHandler, `Restoring data for "buildpack.id:cache-only" from seed cache`)
+				})
+
+				it("ignores the seed cache on Platform API < 0.7", func() {
+					h.SkipIf(t, api.MustParse(platformAPI).AtLeast("0.7"), "")
+
+					h.AssertPathDoesNotExist(t, filepath.Join(layersDir, "buildpack.id", "cache-only", "file-from-cache-only-layer"))
+					assertLogEntry(t, logHandler, "Ignoring seed cache, it requires Platform API 0.7 or later")
+				})
+			})
+
 			when("there is a cache", func() {
 				var (
 					tarTempDir          string
@@ -369,6 +441,48 @@ func testRestorerBuilder(buildpackAPI, platformAPI string) func(t *testing.T, wh
 					})
 				})
 
+				when("restore events are requested", func() {
+					var events *bytes.Buffer
+
+					it.Before(func() {
+						var meta, sha string
+						if api.MustParse(buildpackAPI).LessThan("0.6") {
+							meta = "build = false\nlaunch = false\ncache = true\n\n"
+						}
+						if api.MustParse(platformAPI).LessThan("0.7") {
+							sha = cacheOnlyLayerSHA
+						}
+						h.AssertNil(t, writeLayer(layersDir, "buildpack.id", "cache-only", meta, sha))
+						events = &bytes.Buffer{}
+						restorer.Parallelism = 1
+						restorer.ProgressWriter = events
+						h.AssertNil(t, restorer.Restore(testCache))
+					})
+
+					it("writes an event for each restored layer and a summary for each buildpack", func() {
+						var restored, summary lifecycle.RestoreEvent
+						for _, line := range strings.Split(strings.TrimSpace(events.String()), "\n") {
+							var event lifecycle.RestoreEvent
+							h.AssertNil(t, json.Unmarshal([]byte(line), &event))
+							switch {
+							case event.Type == "restored" && event.Layer == "cache-only":
+								restored = event
+							case event.Type == "summary" && event.Buildpack == "buildpack.id":
+								summary = event
+							}
+						}
+						h.AssertEq(t, restored.Buildpack, "buildpack.id")
+						h.AssertEq(t, restored.Layer, "cache-only")
+						h.AssertEq(t, restored.Bytes > 0, true)
+						h.AssertEq(t, summary.Buildpack, "buildpack.id")
+						h.AssertEq(t, summary.Bytes >= restored.Bytes, true)
+					})
+
+					it("logs the bytes restored for each buildpack", func() {
+						assertLogEntry(t, logHandler, `bytes of cached data for "buildpack.id"`)
+					})
+				})
+
 				when("there is a cache=false layer", func() {
 					var meta string
 					it.Before(func() {
@@ -397,6 +511,29 @@ func testRestorerBuilder(buildpackAPI, platformAPI string) func(t *testing.T, wh
 					})
 				})
 
+				when("there is a cache=true layer that is invalidated", func() {
+					it.Before(func() {
+						var meta, sha string
+						if api.MustParse(buildpackAPI).LessThan("0.6") {
+							meta = "build = false\nlaunch = false\ncache = true\n\n"
+						}
+						if api.MustParse(platformAPI).LessThan("0.7") {
+							sha = cacheOnlyLayerSHA
+						}
+						h.AssertNil(t, writeLayer(layersDir, "buildpack.id", "cache-only", meta, sha))
+						restorer.InvalidateLayers = []platform.CacheLayerSelector{{Buildpack: "buildpack.id", Layer: "cache-*"}}
+						h.AssertNil(t, restorer.Restore(testCache))
+					})
+
+					it("does not restore layer data", func() {
+						h.AssertPathDoesNotExist(t, filepath.Join(layersDir, "buildpack.id", "cache-only", "file-from-cache-only-layer"))
+					})
+
+					it("logs the invalidated layers", func() {
+						assertLogEntry(t, logHandler, `Invalidating cached layer "buildpack.id:cache-only"`)
+					})
+				})
+
 				when("there is a cache=true layer with wrong sha", func() {
 					var otherSHA string
 					it.Before(func() {
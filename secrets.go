@@ -0,0 +1,117 @@
+package lifecycle
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	SecretsPolicyFail = "fail"
+	SecretsPolicyWarn = "warn"
+)
+
+// minSecretLength is the minimum length of a secret value that layers are checked for,
+// shorter values are too likely to occur in layer files by chance.
+const minSecretLength = 8
+
+// secretScanChunkSize is the size of the chunks files are read in when checking them for secrets.
+const secretScanChunkSize = 64 * 1024
+
+var errSecretFound = errors.New("secret found")
+
+// shortSecrets returns the sorted names of the secrets whose values are too short to check layers for.
+func shortSecrets(secrets map[string]string) []string {
+	var names []string
+	for name, value := range secrets {
+		if v := strings.TrimSpace(value); v != "" && len(v) < minSecretLength {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// findSecret returns the name of a secret whose value is contained in a regular file under dir, and the path of that file.
+// Values are compared without surrounding whitespace, values shorter than minSecretLength are ignored.
+// The name is empty if no file contains a secret.
+func findSecret(dir string, secrets map[string]string) (name, path string, err error) {
+	var (
+		names  []string
+		values = map[string][]byte{}
+		maxLen int
+	)
+	for name, value := range secrets {
+		v := strings.TrimSpace(value)
+		if len(v) < minSecretLength {
+			continue
+		}
+		names = append(names, name)
+		values[name] = []byte(v)
+		if len(v) > maxLen {
+			maxLen = len(v)
+		}
+	}
+	if len(names) == 0 {
+		return "", "", nil
+	}
+	sort.Strings(names)
+
+	buf := make([]byte, maxLen-1+secretScanChunkSize)
+	err = filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		found, err := fileContainsSecret(p, names, values, buf)
+		if err != nil {
+			return err
+		}
+		if found != "" {
+			name, path = found, p
+			return errSecretFound
+		}
+		return nil
+	})
+	if err == errSecretFound {
+		return name, path, nil
+	}
+	return "", "", err
+}
+
+// fileContainsSecret returns the name of a secret whose value is contained in the file at path.
+// The file is read in chunks into buf, each chunk is preceded by the end of the previous chunk,
+// so that values split across chunks are found; buf must hold a chunk and the longest value less one byte.
+func fileContainsSecret(path string, names []string, values map[string][]byte, buf []byte) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	keep := 0 // bytes at the start of buf kept from the previous chunk
+	for {
+		n, err := io.ReadFull(f, buf[keep:])
+		end := keep + n
+		for _, name := range names {
+			if bytes.Contains(buf[:end], values[name]) {
+				return name, nil
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		// keep the bytes that may be the start of a value continuing in the next chunk
+		keep = len(buf) - secretScanChunkSize
+		copy(buf, buf[end-keep:end])
+	}
+}
@@ -36,6 +36,21 @@ func (m *MockLayerFactory) EXPECT() *MockLayerFactoryMockRecorder {
 	return m.recorder
 }
 
+// CopyLayer mocks base method.
+func (m *MockLayerFactory) CopyLayer(arg0, arg1, arg2 string) (layers.Layer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CopyLayer", arg0, arg1, arg2)
+	ret0, _ := ret[0].(layers.Layer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CopyLayer indicates an expected call of CopyLayer.
+func (mr *MockLayerFactoryMockRecorder) CopyLayer(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CopyLayer", reflect.TypeOf((*MockLayerFactory)(nil).CopyLayer), arg0, arg1, arg2)
+}
+
 // DirLayer mocks base method.
 func (m *MockLayerFactory) DirLayer(arg0, arg1 string) (layers.Layer, error) {
 	m.ctrl.T.Helper()
@@ -95,3 +110,18 @@ func (mr *MockLayerFactoryMockRecorder) SliceLayers(arg0, arg1 interface{}) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SliceLayers", reflect.TypeOf((*MockLayerFactory)(nil).SliceLayers), arg0, arg1)
 }
+
+// TarballLayer mocks base method.
+func (m *MockLayerFactory) TarballLayer(arg0, arg1 string) (layers.Layer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TarballLayer", arg0, arg1)
+	ret0, _ := ret[0].(layers.Layer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TarballLayer indicates an expected call of TarballLayer.
+func (mr *MockLayerFactoryMockRecorder) TarballLayer(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TarballLayer", reflect.TypeOf((*MockLayerFactory)(nil).TarballLayer), arg0, arg1)
+}